@@ -0,0 +1,607 @@
+// Package ast declares the types used to represent JavaScript syntax trees.
+// The node types follow the ESTree specification, so that a tree encoded as
+// JSON has the same shape as one built by Acorn or ESLint's parser, except
+// that locations are token.SourceLocations with zero-based lines.
+package ast
+
+import "github.com/morinokami/js-lexer/token"
+
+// Base holds the fields common to all nodes: the ESTree node type and the
+// byte offsets and location of the source text the node was parsed from.
+type Base struct {
+	Type  string               `json:"type"`
+	Start int                  `json:"start"`
+	End   int                  `json:"end"`
+	Loc   token.SourceLocation `json:"loc"`
+}
+
+// Info returns the fields common to all nodes.
+func (b *Base) Info() *Base { return b }
+
+type Node interface {
+	Info() *Base
+}
+
+// Statement is implemented by statements, declarations, and module
+// declarations.
+type Statement interface {
+	Node
+	statementNode()
+}
+
+// Expression is implemented by expressions. SpreadElement implements it as
+// well, since it appears among array elements and call arguments.
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+// Pattern is implemented by the nodes that can be the target of a binding
+// or an assignment.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+type Program struct {
+	Base
+	SourceType string      `json:"sourceType"`
+	Body       []Statement `json:"body"`
+}
+
+// Function holds the fields shared by function declarations and
+// expressions, arrow functions, and methods. Body is a *BlockStatement, or
+// an Expression for arrow functions with an expression body.
+type Function struct {
+	ID         *Identifier `json:"id"`
+	Params     []Pattern   `json:"params"`
+	Body       Node        `json:"body"`
+	Generator  bool        `json:"generator"`
+	Async      bool        `json:"async"`
+	Expression bool        `json:"expression"`
+}
+
+// Class holds the fields shared by class declarations and expressions.
+type Class struct {
+	ID         *Identifier `json:"id"`
+	SuperClass Expression  `json:"superClass"`
+	Body       *ClassBody  `json:"body"`
+}
+
+// Statements
+
+type ExpressionStatement struct {
+	Base
+	Expression Expression `json:"expression"`
+	// Directive is the raw text of the string literal of a directive, such
+	// as "use strict", without the quotes.
+	Directive string `json:"directive,omitempty"`
+}
+
+type BlockStatement struct {
+	Base
+	Body []Statement `json:"body"`
+}
+
+type StaticBlock struct {
+	Base
+	Body []Statement `json:"body"`
+}
+
+type EmptyStatement struct {
+	Base
+}
+
+type DebuggerStatement struct {
+	Base
+}
+
+type WithStatement struct {
+	Base
+	Object Expression `json:"object"`
+	Body   Statement  `json:"body"`
+}
+
+type ReturnStatement struct {
+	Base
+	Argument Expression `json:"argument"`
+}
+
+type LabeledStatement struct {
+	Base
+	Label *Identifier `json:"label"`
+	Body  Statement   `json:"body"`
+}
+
+type BreakStatement struct {
+	Base
+	Label *Identifier `json:"label"`
+}
+
+type ContinueStatement struct {
+	Base
+	Label *Identifier `json:"label"`
+}
+
+type IfStatement struct {
+	Base
+	Test       Expression `json:"test"`
+	Consequent Statement  `json:"consequent"`
+	Alternate  Statement  `json:"alternate"`
+}
+
+type SwitchStatement struct {
+	Base
+	Discriminant Expression    `json:"discriminant"`
+	Cases        []*SwitchCase `json:"cases"`
+}
+
+type SwitchCase struct {
+	Base
+	// Test is nil for the default case.
+	Test       Expression  `json:"test"`
+	Consequent []Statement `json:"consequent"`
+}
+
+type ThrowStatement struct {
+	Base
+	Argument Expression `json:"argument"`
+}
+
+type TryStatement struct {
+	Base
+	Block     *BlockStatement `json:"block"`
+	Handler   *CatchClause    `json:"handler"`
+	Finalizer *BlockStatement `json:"finalizer"`
+}
+
+type CatchClause struct {
+	Base
+	Param Pattern         `json:"param"`
+	Body  *BlockStatement `json:"body"`
+}
+
+type WhileStatement struct {
+	Base
+	Test Expression `json:"test"`
+	Body Statement  `json:"body"`
+}
+
+type DoWhileStatement struct {
+	Base
+	Body Statement  `json:"body"`
+	Test Expression `json:"test"`
+}
+
+type ForStatement struct {
+	Base
+	// Init is a *VariableDeclaration or an Expression.
+	Init   Node       `json:"init"`
+	Test   Expression `json:"test"`
+	Update Expression `json:"update"`
+	Body   Statement  `json:"body"`
+}
+
+type ForInStatement struct {
+	Base
+	// Left is a *VariableDeclaration or a Pattern.
+	Left  Node       `json:"left"`
+	Right Expression `json:"right"`
+	Body  Statement  `json:"body"`
+}
+
+type ForOfStatement struct {
+	Base
+	// Left is a *VariableDeclaration or a Pattern.
+	Left  Node       `json:"left"`
+	Right Expression `json:"right"`
+	Body  Statement  `json:"body"`
+	Await bool       `json:"await"`
+}
+
+// Declarations
+
+type FunctionDeclaration struct {
+	Base
+	Function
+}
+
+type VariableDeclaration struct {
+	Base
+	Declarations []*VariableDeclarator `json:"declarations"`
+	// Kind is "var", "let", or "const".
+	Kind string `json:"kind"`
+}
+
+type VariableDeclarator struct {
+	Base
+	ID   Pattern    `json:"id"`
+	Init Expression `json:"init"`
+}
+
+type ClassDeclaration struct {
+	Base
+	Class
+}
+
+// Expressions
+
+type Identifier struct {
+	Base
+	Name string `json:"name"`
+}
+
+type PrivateIdentifier struct {
+	Base
+	Name string `json:"name"`
+}
+
+// Literal is a null, boolean, numeric, string, or regular expression
+// literal. Value is nil, a bool, a float64, a string, or, for regular
+// expressions, nil as well.
+type Literal struct {
+	Base
+	Value  interface{}    `json:"value"`
+	Raw    string         `json:"raw"`
+	Regex  *RegExpLiteral `json:"regex,omitempty"`
+	Bigint string         `json:"bigint,omitempty"`
+}
+
+type RegExpLiteral struct {
+	Pattern string `json:"pattern"`
+	Flags   string `json:"flags"`
+}
+
+type ThisExpression struct {
+	Base
+}
+
+type Super struct {
+	Base
+}
+
+type ArrayExpression struct {
+	Base
+	// Elements holds nil for holes.
+	Elements []Expression `json:"elements"`
+}
+
+type ObjectExpression struct {
+	Base
+	// Properties holds *Property and *SpreadElement nodes.
+	Properties []Node `json:"properties"`
+}
+
+// Property is a property of an object literal or an object pattern. In an
+// object pattern, Value is a Pattern.
+type Property struct {
+	Base
+	Key   Expression `json:"key"`
+	Value Node       `json:"value"`
+	// Kind is "init", "get", or "set".
+	Kind      string `json:"kind"`
+	Method    bool   `json:"method"`
+	Shorthand bool   `json:"shorthand"`
+	Computed  bool   `json:"computed"`
+}
+
+type FunctionExpression struct {
+	Base
+	Function
+}
+
+type ArrowFunctionExpression struct {
+	Base
+	Function
+}
+
+type ClassExpression struct {
+	Base
+	Class
+}
+
+type TemplateLiteral struct {
+	Base
+	Quasis      []*TemplateElement `json:"quasis"`
+	Expressions []Expression       `json:"expressions"`
+}
+
+type TemplateElement struct {
+	Base
+	Tail  bool                 `json:"tail"`
+	Value TemplateElementValue `json:"value"`
+}
+
+type TemplateElementValue struct {
+	// Cooked is nil if the raw text has an invalid escape sequence, which
+	// is allowed in tagged templates.
+	Cooked *string `json:"cooked"`
+	Raw    string  `json:"raw"`
+}
+
+type TaggedTemplateExpression struct {
+	Base
+	Tag   Expression       `json:"tag"`
+	Quasi *TemplateLiteral `json:"quasi"`
+}
+
+type MemberExpression struct {
+	Base
+	Object   Expression `json:"object"`
+	Property Expression `json:"property"`
+	Computed bool       `json:"computed"`
+	Optional bool       `json:"optional"`
+}
+
+type ChainExpression struct {
+	Base
+	Expression Expression `json:"expression"`
+}
+
+type MetaProperty struct {
+	Base
+	Meta     *Identifier `json:"meta"`
+	Property *Identifier `json:"property"`
+}
+
+type CallExpression struct {
+	Base
+	Callee    Expression   `json:"callee"`
+	Arguments []Expression `json:"arguments"`
+	Optional  bool         `json:"optional"`
+}
+
+type NewExpression struct {
+	Base
+	Callee    Expression   `json:"callee"`
+	Arguments []Expression `json:"arguments"`
+}
+
+type ImportExpression struct {
+	Base
+	Source  Expression `json:"source"`
+	Options Expression `json:"options"`
+}
+
+type SpreadElement struct {
+	Base
+	Argument Expression `json:"argument"`
+}
+
+type UpdateExpression struct {
+	Base
+	Operator string     `json:"operator"`
+	Prefix   bool       `json:"prefix"`
+	Argument Expression `json:"argument"`
+}
+
+type UnaryExpression struct {
+	Base
+	Operator string     `json:"operator"`
+	Prefix   bool       `json:"prefix"`
+	Argument Expression `json:"argument"`
+}
+
+type AwaitExpression struct {
+	Base
+	Argument Expression `json:"argument"`
+}
+
+type BinaryExpression struct {
+	Base
+	Operator string `json:"operator"`
+	// Left is a *PrivateIdentifier in `#x in obj`.
+	Left  Expression `json:"left"`
+	Right Expression `json:"right"`
+}
+
+type LogicalExpression struct {
+	Base
+	Operator string     `json:"operator"`
+	Left     Expression `json:"left"`
+	Right    Expression `json:"right"`
+}
+
+type ConditionalExpression struct {
+	Base
+	Test       Expression `json:"test"`
+	Consequent Expression `json:"consequent"`
+	Alternate  Expression `json:"alternate"`
+}
+
+type YieldExpression struct {
+	Base
+	Argument Expression `json:"argument"`
+	Delegate bool       `json:"delegate"`
+}
+
+type AssignmentExpression struct {
+	Base
+	Operator string     `json:"operator"`
+	Left     Pattern    `json:"left"`
+	Right    Expression `json:"right"`
+}
+
+type SequenceExpression struct {
+	Base
+	Expressions []Expression `json:"expressions"`
+}
+
+// Patterns
+
+type ObjectPattern struct {
+	Base
+	// Properties holds *Property and *RestElement nodes.
+	Properties []Node `json:"properties"`
+}
+
+type ArrayPattern struct {
+	Base
+	// Elements holds nil for holes.
+	Elements []Pattern `json:"elements"`
+}
+
+type RestElement struct {
+	Base
+	Argument Pattern `json:"argument"`
+}
+
+type AssignmentPattern struct {
+	Base
+	Left  Pattern    `json:"left"`
+	Right Expression `json:"right"`
+}
+
+// Classes
+
+type ClassBody struct {
+	Base
+	// Body holds *MethodDefinition, *PropertyDefinition, and *StaticBlock
+	// nodes.
+	Body []Node `json:"body"`
+}
+
+type MethodDefinition struct {
+	Base
+	Key   Expression          `json:"key"`
+	Value *FunctionExpression `json:"value"`
+	// Kind is "constructor", "method", "get", or "set".
+	Kind     string `json:"kind"`
+	Computed bool   `json:"computed"`
+	Static   bool   `json:"static"`
+}
+
+type PropertyDefinition struct {
+	Base
+	Key      Expression `json:"key"`
+	Value    Expression `json:"value"`
+	Computed bool       `json:"computed"`
+	Static   bool       `json:"static"`
+}
+
+// Modules
+
+type ImportDeclaration struct {
+	Base
+	// Specifiers holds *ImportSpecifier, *ImportDefaultSpecifier, and
+	// *ImportNamespaceSpecifier nodes.
+	Specifiers []Node             `json:"specifiers"`
+	Source     *Literal           `json:"source"`
+	Attributes []*ImportAttribute `json:"attributes"`
+}
+
+type ImportSpecifier struct {
+	Base
+	// Imported is an *Identifier or, for arbitrary module namespace names,
+	// a string *Literal.
+	Imported Expression  `json:"imported"`
+	Local    *Identifier `json:"local"`
+}
+
+type ImportDefaultSpecifier struct {
+	Base
+	Local *Identifier `json:"local"`
+}
+
+type ImportNamespaceSpecifier struct {
+	Base
+	Local *Identifier `json:"local"`
+}
+
+type ImportAttribute struct {
+	Base
+	Key   Expression `json:"key"`
+	Value *Literal   `json:"value"`
+}
+
+type ExportNamedDeclaration struct {
+	Base
+	Declaration Statement          `json:"declaration"`
+	Specifiers  []*ExportSpecifier `json:"specifiers"`
+	Source      *Literal           `json:"source"`
+	Attributes  []*ImportAttribute `json:"attributes"`
+}
+
+type ExportSpecifier struct {
+	Base
+	// Local and Exported are *Identifiers or string *Literals.
+	Local    Expression `json:"local"`
+	Exported Expression `json:"exported"`
+}
+
+type ExportDefaultDeclaration struct {
+	Base
+	// Declaration is a *FunctionDeclaration, a *ClassDeclaration, or an
+	// Expression.
+	Declaration Node `json:"declaration"`
+}
+
+type ExportAllDeclaration struct {
+	Base
+	Exported   Expression         `json:"exported"`
+	Source     *Literal           `json:"source"`
+	Attributes []*ImportAttribute `json:"attributes"`
+}
+
+func (*ExpressionStatement) statementNode()      {}
+func (*BlockStatement) statementNode()           {}
+func (*StaticBlock) statementNode()              {}
+func (*EmptyStatement) statementNode()           {}
+func (*DebuggerStatement) statementNode()        {}
+func (*WithStatement) statementNode()            {}
+func (*ReturnStatement) statementNode()          {}
+func (*LabeledStatement) statementNode()         {}
+func (*BreakStatement) statementNode()           {}
+func (*ContinueStatement) statementNode()        {}
+func (*IfStatement) statementNode()              {}
+func (*SwitchStatement) statementNode()          {}
+func (*ThrowStatement) statementNode()           {}
+func (*TryStatement) statementNode()             {}
+func (*WhileStatement) statementNode()           {}
+func (*DoWhileStatement) statementNode()         {}
+func (*ForStatement) statementNode()             {}
+func (*ForInStatement) statementNode()           {}
+func (*ForOfStatement) statementNode()           {}
+func (*FunctionDeclaration) statementNode()      {}
+func (*VariableDeclaration) statementNode()      {}
+func (*ClassDeclaration) statementNode()         {}
+func (*ImportDeclaration) statementNode()        {}
+func (*ExportNamedDeclaration) statementNode()   {}
+func (*ExportDefaultDeclaration) statementNode() {}
+func (*ExportAllDeclaration) statementNode()     {}
+
+func (*Identifier) expressionNode()               {}
+func (*PrivateIdentifier) expressionNode()        {}
+func (*Literal) expressionNode()                  {}
+func (*ThisExpression) expressionNode()           {}
+func (*Super) expressionNode()                    {}
+func (*ArrayExpression) expressionNode()          {}
+func (*ObjectExpression) expressionNode()         {}
+func (*FunctionExpression) expressionNode()       {}
+func (*ArrowFunctionExpression) expressionNode()  {}
+func (*ClassExpression) expressionNode()          {}
+func (*TemplateLiteral) expressionNode()          {}
+func (*TaggedTemplateExpression) expressionNode() {}
+func (*MemberExpression) expressionNode()         {}
+func (*ChainExpression) expressionNode()          {}
+func (*MetaProperty) expressionNode()             {}
+func (*CallExpression) expressionNode()           {}
+func (*NewExpression) expressionNode()            {}
+func (*ImportExpression) expressionNode()         {}
+func (*SpreadElement) expressionNode()            {}
+func (*UpdateExpression) expressionNode()         {}
+func (*UnaryExpression) expressionNode()          {}
+func (*AwaitExpression) expressionNode()          {}
+func (*BinaryExpression) expressionNode()         {}
+func (*LogicalExpression) expressionNode()        {}
+func (*ConditionalExpression) expressionNode()    {}
+func (*YieldExpression) expressionNode()          {}
+func (*AssignmentExpression) expressionNode()     {}
+func (*SequenceExpression) expressionNode()       {}
+
+func (*Identifier) patternNode()        {}
+func (*MemberExpression) patternNode()  {}
+func (*ObjectPattern) patternNode()     {}
+func (*ArrayPattern) patternNode()      {}
+func (*RestElement) patternNode()       {}
+func (*AssignmentPattern) patternNode() {}
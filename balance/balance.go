@@ -0,0 +1,258 @@
+// Package balance matches the delimiters of JavaScript code: parentheses,
+// brackets, braces, template substitutions, and the backticks of template
+// literals. It reports the delimiters left unclosed or closed by the wrong
+// one, and guesses from the indentation of the code where a missing
+// delimiter belongs, which points closer to the cause than the errors of a
+// parser. The pairs it matches can be used for bracket-pair colorization.
+package balance
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Pair is a matching opening and closing delimiter. Depth is the number of
+// pairs enclosing it.
+type Pair struct {
+	Open  token.Token
+	Close token.Token
+	Depth int
+}
+
+// ProblemKind is the kind of a Problem.
+type ProblemKind int
+
+const (
+	// Unclosed is an opening delimiter without a closing one.
+	Unclosed ProblemKind = iota
+	// Unmatched is a closing delimiter without an opening one.
+	Unmatched
+	// Mismatched is a closing delimiter of another kind than the opening
+	// one it closes.
+	Mismatched
+)
+
+// Problem is a delimiter that does not match.
+type Problem struct {
+	Kind ProblemKind
+	// The opening delimiter, and the closing delimiter. For Unclosed, Close
+	// is the delimiter that closed an enclosing pair before Open was
+	// closed, or nil at the end of the input. For Unmatched, Open is the
+	// innermost delimiter open at Close, if any.
+	Open  *token.Token
+	Close *token.Token
+	// For Unclosed, the opening delimiter most likely missing its closing
+	// delimiter according to the indentation of the code, which is Open or
+	// a delimiter inside it, and where the closing delimiter probably
+	// belongs.
+	Culprit *token.Token
+	Insert  *token.Position
+}
+
+// Message returns a description of p.
+func (p Problem) Message() string {
+	switch p.Kind {
+	case Unclosed:
+		return fmt.Sprintf("Unclosed '%s'", text(p.Open))
+	case Mismatched:
+		return fmt.Sprintf("Mismatched '%s' for '%s' at %d:%d", text(p.Close), text(p.Open),
+			p.Open.Loc.Start.Line, p.Open.Loc.Start.Column)
+	}
+	return fmt.Sprintf("Unmatched '%s'", text(p.Close))
+}
+
+// text returns the text of the delimiter tok.
+func text(tok *token.Token) string {
+	switch tok.Type.Label {
+	case token.TemplateStart, token.TemplateEnd:
+		return "`"
+	case token.SubstitutionStart:
+		return "${"
+	case token.SubstitutionEnd:
+		return "}"
+	}
+	return tok.Type.Label
+}
+
+// Pos returns the position p is reported at: that of the opening
+// delimiter for Unclosed, and that of the closing one otherwise.
+func (p Problem) Pos() token.Position {
+	if p.Kind == Unclosed {
+		return p.Open.Loc.Start
+	}
+	return p.Close.Loc.Start
+}
+
+func (p Problem) String() string {
+	pos := p.Pos()
+	return fmt.Sprintf("%s (%d:%d)", p.Message(), pos.Line, pos.Column)
+}
+
+// Result is the pairs of matching delimiters of some code, in the order of
+// their opening delimiters, and the problems found, in the order of their
+// positions.
+type Result struct {
+	Pairs    []Pair
+	Problems []Problem
+}
+
+// closers maps the labels of closing delimiters to those of the opening
+// ones.
+var closers = map[string]string{
+	token.RParen:          token.LParen,
+	token.RBracket:        token.LBracket,
+	token.RBrace:          token.LBrace,
+	token.SubstitutionEnd: token.SubstitutionStart,
+	token.TemplateEnd:     token.TemplateStart,
+}
+
+// CheckSource checks the delimiters of src, which is lexed with the given
+// options, past any syntax errors.
+func CheckSource(src string, opts ...lexer.Option) Result {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic()}, opts...)
+	return Check(lexer.NewDocument(src, opts...).Tokens())
+}
+
+// Check checks the delimiters among toks.
+func Check(toks []token.Token) Result {
+	c := &checker{toks: toks, indents: map[int]int{}}
+	for i := len(toks) - 1; i >= 0; i-- {
+		c.indents[toks[i].Loc.Start.Line] = toks[i].Loc.Start.Column
+	}
+	c.check()
+	sort.Slice(c.pairs, func(i, j int) bool { return c.pairs[i].Open.Start < c.pairs[j].Open.Start })
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		pi, pj := c.problems[i].Pos(), c.problems[j].Pos()
+		return pi.Line < pj.Line || pi.Line == pj.Line && pi.Column < pj.Column
+	})
+	return Result{Pairs: c.pairs, Problems: c.problems}
+}
+
+type checker struct {
+	toks []token.Token
+	// Column of the first token of each line
+	indents  map[int]int
+	stack    []int
+	pairs    []Pair
+	problems []Problem
+}
+
+func (c *checker) check() {
+	for i := range c.toks {
+		tok := &c.toks[i]
+		switch tok.Type.Label {
+		case token.LParen, token.LBracket, token.LBrace, token.SubstitutionStart, token.TemplateStart:
+			c.stack = append(c.stack, i)
+			continue
+		}
+		open, ok := closers[tok.Type.Label]
+		if !ok {
+			continue
+		}
+
+		// The innermost opening delimiter the token closes
+		j := len(c.stack) - 1
+		for j >= 0 && c.toks[c.stack[j]].Type.Label != open {
+			j--
+		}
+		switch {
+		case j >= 0:
+			// The delimiters opened after it are unclosed
+			for len(c.stack) > j+1 {
+				c.unclosed(c.pop(), tok)
+			}
+			c.pair(c.pop(), i)
+		case len(c.stack) > 0 && c.startsLine(tok) && c.indent(tok) == c.indent(&c.toks[c.top()]):
+			// Lined up with the innermost opening delimiter, so probably
+			// meant to close it
+			k := c.pop()
+			c.problems = append(c.problems, Problem{Kind: Mismatched, Open: &c.toks[k], Close: tok})
+		default:
+			p := Problem{Kind: Unmatched, Close: tok}
+			if len(c.stack) > 0 {
+				p.Open = &c.toks[c.top()]
+			}
+			c.problems = append(c.problems, p)
+		}
+	}
+	for len(c.stack) > 0 {
+		c.unclosed(c.pop(), nil)
+	}
+}
+
+func (c *checker) top() int {
+	return c.stack[len(c.stack)-1]
+}
+
+func (c *checker) pop() int {
+	i := c.top()
+	c.stack = c.stack[:len(c.stack)-1]
+	return i
+}
+
+func (c *checker) pair(open, close int) {
+	c.pairs = append(c.pairs, Pair{Open: c.toks[open], Close: c.toks[close], Depth: len(c.stack)})
+}
+
+// startsLine reports whether tok is the first token on its line.
+func (c *checker) startsLine(tok *token.Token) bool {
+	return c.indents[tok.Loc.Start.Line] == tok.Loc.Start.Column
+}
+
+// indent returns the indentation of the line tok starts on.
+func (c *checker) indent(tok *token.Token) int {
+	return c.indents[tok.Loc.Start.Line]
+}
+
+// unclosed reports the delimiter toks[open] unclosed when close, which may
+// be nil, closes an enclosing delimiter or the input ends.
+func (c *checker) unclosed(open int, close *token.Token) {
+	end := len(c.toks)
+	if close != nil {
+		end = sort.Search(len(c.toks), func(i int) bool { return c.toks[i].Start >= close.Start })
+	}
+	culprit := c.culprit(open, end)
+	p := Problem{Kind: Unclosed, Open: &c.toks[open], Close: close, Culprit: &c.toks[culprit]}
+	p.Insert = c.insert(culprit, end)
+	c.problems = append(c.problems, p)
+}
+
+// culprit returns the opening delimiter among toks[open:end], which toks
+// [open] is unclosed in, that most likely misses its closing delimiter: the
+// first whose closing delimiter starts a line indented less than the line
+// it opens, as that closing delimiter was probably meant for an enclosing
+// delimiter. It is toks[open] if there is none.
+func (c *checker) culprit(open, end int) int {
+	best := open
+	for _, p := range c.pairs {
+		if p.Open.Start <= c.toks[open].Start || p.Close.Start >= c.toks[end-1].End {
+			continue
+		}
+		if c.startsLine(&p.Close) && c.indent(&p.Close) < c.indent(&p.Open) &&
+			(best == open || p.Open.Start < c.toks[best].Start) {
+			best = sort.Search(len(c.toks), func(i int) bool { return c.toks[i].Start >= p.Open.Start })
+		}
+	}
+	return best
+}
+
+// insert returns where the closing delimiter of toks[open] probably
+// belongs: after the last token before the first line in toks[open+1:end]
+// that is not indented more than the line of toks[open].
+func (c *checker) insert(open, end int) *token.Position {
+	indent := c.indent(&c.toks[open])
+	line := c.toks[open].Loc.Start.Line
+	last := open
+	for i := open + 1; i < end; i++ {
+		tok := &c.toks[i]
+		if tok.Loc.Start.Line != line && c.startsLine(tok) && c.indent(tok) <= indent {
+			break
+		}
+		last = i
+	}
+	pos := c.toks[last].Loc.End
+	return &pos
+}
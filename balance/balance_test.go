@@ -0,0 +1,93 @@
+package balance
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+)
+
+func TestCheckPairs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"f(a[0], {b: 1})", []string{"( 0:1 0:14 0", "[ 0:3 0:5 1", "{ 0:8 0:13 1"}},
+		{"`a${ {b: `c${d}`} }`", []string{"` 0:0 0:19 0", "${ 0:2 0:18 1", "{ 0:5 0:16 2", "` 0:9 0:15 3", "${ 0:11 0:14 4"}},
+		{"if (a) {\n  b()\n}", []string{"( 0:3 0:5 0", "{ 0:7 2:0 0", "( 1:3 1:4 1"}},
+	}
+
+	for i, tt := range tests {
+		r := CheckSource(tt.input)
+		if len(r.Problems) != 0 {
+			t.Fatalf("tests[%d] - unexpected problem: %s", i, r.Problems[0])
+		}
+		if len(r.Pairs) != len(tt.expected) {
+			t.Fatalf("tests[%d] - number of pairs wrong. expected=%d, got=%d", i, len(tt.expected), len(r.Pairs))
+		}
+		for j, p := range r.Pairs {
+			got := fmt.Sprintf("%s %d:%d %d:%d %d", text(&p.Open), p.Open.Loc.Start.Line, p.Open.Loc.Start.Column,
+				p.Close.Loc.Start.Line, p.Close.Loc.Start.Column, p.Depth)
+			if got != tt.expected[j] {
+				t.Fatalf("tests[%d] - pairs[%d] wrong. expected=%q, got=%q", i, j, tt.expected[j], got)
+			}
+		}
+	}
+}
+
+func TestCheckProblems(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Unclosed delimiters
+		{"f(a, [1, 2)", []string{"Unclosed '[' (0:5) closed by ')' at 0:10, insert at 0:10"}},
+		{"{\n  (a\n}", []string{"Unclosed '(' (1:2) closed by '}' at 2:0, insert at 1:4"}},
+		{"x = `a${(b}`", []string{"Unclosed '(' (0:8) closed by '}' at 0:10, insert at 0:10"}},
+		{"`a${b", []string{"Unclosed '`' (0:0), insert at 0:5", "Unclosed '${' (0:2), insert at 0:5"}},
+		// The indentation points at the delimiter missing its closing one
+		{"function f() {\n  if (a) {\n    b()\n\n  c()\n}\n", []string{"Unclosed '{' (0:13), culprit at 1:9, insert at 2:7"}},
+		{"[\n  {\n    a: 1,\n  {\n    b: 2\n  },\n]", []string{"Unclosed '{' (1:2) closed by ']' at 6:0, insert at 2:9"}},
+		// Unmatched delimiters
+		{"a }", []string{"Unmatched '}' (0:2)"}},
+		{"foo(a, 1]), b)", []string{"Unmatched ']' (0:8) in '(' at 0:3", "Unmatched ')' (0:13)"}},
+		// Mismatched delimiters
+		{"(a\n]", []string{"Mismatched ']' for '(' at 0:0 (1:0)"}},
+		{"[\n  1,\n  2\n)\n", []string{"Mismatched ')' for '[' at 0:0 (3:0)"}},
+	}
+
+	for i, tt := range tests {
+		r := CheckSource(tt.input)
+		if len(r.Problems) != len(tt.expected) {
+			t.Fatalf("tests[%d] - number of problems wrong. expected=%d, got=%d (%v)", i, len(tt.expected), len(r.Problems), r.Problems)
+		}
+		for j, p := range r.Problems {
+			got := p.String()
+			if p.Kind == Unclosed && p.Close != nil {
+				got += fmt.Sprintf(" closed by '%s' at %d:%d", text(p.Close), p.Close.Loc.Start.Line, p.Close.Loc.Start.Column)
+			}
+			if p.Kind == Unmatched && p.Open != nil {
+				got += fmt.Sprintf(" in '%s' at %d:%d", text(p.Open), p.Open.Loc.Start.Line, p.Open.Loc.Start.Column)
+			}
+			if p.Culprit != nil && p.Culprit != p.Open {
+				got += fmt.Sprintf(", culprit at %d:%d", p.Culprit.Loc.Start.Line, p.Culprit.Loc.Start.Column)
+			}
+			if p.Insert != nil {
+				got += fmt.Sprintf(", insert at %d:%d", p.Insert.Line, p.Insert.Column)
+			}
+			if got != tt.expected[j] {
+				t.Fatalf("tests[%d] - problems[%d] wrong. expected=%q, got=%q", i, j, tt.expected[j], got)
+			}
+		}
+	}
+}
+
+func TestCheckJSX(t *testing.T) {
+	r := CheckSource("const a = <div class={x}>{items.map(i => (<b>{i}</b>))}</div>", lexer.WithJSX())
+	if len(r.Problems) != 0 {
+		t.Fatalf("unexpected problem: %s", r.Problems[0])
+	}
+	if len(r.Pairs) != 5 {
+		t.Fatalf("number of pairs wrong. expected=5, got=%d", len(r.Pairs))
+	}
+}
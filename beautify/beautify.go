@@ -0,0 +1,423 @@
+// Package beautify reformats JavaScript code from its tokens, breaking
+// statements onto lines, indenting blocks, and spacing operators, so that
+// minified code can be read. The output lexes to the same tokens as the
+// input.
+package beautify
+
+import (
+	"strings"
+
+	"github.com/morinokami/js-lexer/internal/join"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// BraceStyle is where the opening braces of blocks go.
+type BraceStyle int
+
+const (
+	// Collapse puts opening braces at the end of the line that starts the
+	// block, and else, catch, and finally after closing braces.
+	Collapse BraceStyle = iota
+	// Expand puts the opening braces of blocks, and else, catch, and
+	// finally, on lines of their own.
+	Expand
+)
+
+// Options configures Beautify.
+type Options struct {
+	// Indent is the text of one level of indentation, two spaces if empty.
+	Indent     string
+	BraceStyle BraceStyle
+}
+
+// Beautify returns src reformatted as o specifies. Comments are kept, and
+// template literals, regular expressions, and JSX elements are copied
+// verbatim. The lexer options opts are used to read src, which must lex
+// without errors.
+func Beautify(src string, o Options, opts ...lexer.Option) (string, error) {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments()}, opts...)
+	toks, err := lexer.Tokenize(src, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	b := &beautifier{src: src, toks: toks, indent: o.Indent, style: o.BraceStyle}
+	if b.indent == "" {
+		b.indent = "  "
+	}
+	b.frames = []frame{{kind: blockFrame}}
+	b.beautify()
+	return b.out.String(), nil
+}
+
+type frameKind int
+
+const (
+	blockFrame frameKind = iota
+	objectFrame
+	switchFrame
+)
+
+// frame is an open brace, and the state of the enclosing code to restore
+// when it is closed.
+type frame struct {
+	kind    frameKind
+	parens  int
+	ternary int
+	// Whether the statements of a case clause are being written, indented
+	// one more level than the label
+	inCase bool
+}
+
+type beautifier struct {
+	src    string
+	toks   []token.Token
+	indent string
+	style  BraceStyle
+
+	out            strings.Builder
+	level          int
+	pendingNewline bool
+	// The last token written, which may be a comment, and the last token
+	// of code written
+	written *token.Token
+	code    *token.Token
+	// Whether code is a prefix operator
+	unary bool
+
+	frames []frame
+	// Open parentheses and brackets, and conditional operators waiting for
+	// their ':', in the innermost braces
+	parens  int
+	ternary int
+	// Labels of the tokens before the open parentheses, and before the
+	// parenthesis most recently closed
+	parenLabels []string
+	closedParen string
+	// Whether a case or default label is being written
+	caseLabel bool
+}
+
+func (b *beautifier) beautify() {
+	for i := 0; i < len(b.toks); i++ {
+		tok := &b.toks[i]
+		switch tok.Type.Label {
+		case token.LineComment, token.BlockComment:
+			b.comment(tok, i)
+			continue
+		case token.TemplateStart:
+			end := b.templateEnd(i)
+			b.atom(tok, &b.toks[end])
+			i = end
+			continue
+		case token.JSXTagStart:
+			end := b.elementEnd(i)
+			b.atom(tok, &b.toks[end])
+			i = end
+			continue
+		}
+		b.token(tok, i)
+	}
+}
+
+func (b *beautifier) top() *frame {
+	return &b.frames[len(b.frames)-1]
+}
+
+// newline makes the next token start a new line.
+func (b *beautifier) newline() {
+	if b.written != nil {
+		b.pendingNewline = true
+	}
+}
+
+// write writes the text of src from first to last, preceded by a line
+// break and indentation if one is pending, or else by a space if space is
+// true or the text would run into the last token written.
+func (b *beautifier) write(first, last *token.Token, space bool) {
+	if b.pendingNewline {
+		b.out.WriteByte('\n')
+		gap := b.src[b.written.End:first.Start]
+		if strings.Count(gap, "\n") >= 2 && b.written.Type.Label != token.LBrace {
+			// Keep a blank line
+			b.out.WriteByte('\n')
+		}
+		b.out.WriteString(strings.Repeat(b.indent, b.level))
+		b.pendingNewline = false
+	} else if b.written != nil && (space || join.NeedsSpace(b.src, b.written, first)) {
+		b.out.WriteByte(' ')
+	}
+	b.out.WriteString(b.src[first.Start:last.End])
+	b.written = last
+}
+
+// atom writes the tokens from first to last, a template literal or a JSX
+// element, as they are in the input.
+func (b *beautifier) atom(first, last *token.Token) {
+	b.breakBefore(first)
+	b.write(first, last, b.spaceBefore(first))
+	b.code = last
+	b.unary = false
+}
+
+func (b *beautifier) comment(tok *token.Token, i int) {
+	if b.written != nil && join.HasLineBreak(b.src[b.written.End:tok.Start]) {
+		b.newline()
+	} else if b.pendingNewline && b.written != nil {
+		// A comment at the end of a line
+		b.pendingNewline = false
+	}
+	space := true
+	if b.written != nil {
+		switch b.written.Type.Label {
+		case token.LParen, token.LBracket:
+			space = false
+		}
+	}
+	b.write(tok, tok, space)
+	if tok.Type.Label == token.LineComment ||
+		i+1 < len(b.toks) && join.HasLineBreak(b.src[tok.End:b.toks[i+1].Start]) {
+		b.newline()
+	}
+}
+
+// breakBefore starts a new line before tok if there is a line break before
+// it in the input at which a semicolon may have been inserted.
+func (b *beautifier) breakBefore(tok *token.Token) {
+	if b.code != nil && join.HasLineBreak(b.src[b.code.End:tok.Start]) && join.SemicolonMayBeInserted(b.code, tok) {
+		b.newline()
+	}
+}
+
+func (b *beautifier) token(tok *token.Token, i int) {
+	label := tok.Type.Label
+	b.breakBefore(tok)
+
+	kind := blockFrame
+	switch label {
+	case token.LBrace:
+		kind = b.braceKind()
+		if kind != objectFrame && b.style == Expand {
+			b.newline()
+		}
+	case token.RBrace:
+		if len(b.frames) > 1 {
+			f := b.frames[len(b.frames)-1]
+			b.frames = b.frames[:len(b.frames)-1]
+			b.parens, b.ternary = f.parens, f.ternary
+			b.level--
+			if f.inCase {
+				b.level--
+			}
+			kind = f.kind
+		}
+		if b.code == nil || b.code.Type.Label != token.LBrace || b.written != b.code {
+			b.newline()
+		}
+	case token.Case, token.Default:
+		if f := b.top(); f.kind == switchFrame && b.parens == 0 {
+			if f.inCase {
+				f.inCase = false
+				b.level--
+			}
+			b.caseLabel = true
+		}
+	case token.Else, token.Catch, token.Finally:
+		if b.code != nil && b.code.Type.Label == token.RBrace && b.style == Expand {
+			b.newline()
+		}
+	}
+
+	space := b.spaceBefore(tok)
+	b.write(tok, tok, space)
+	b.unary = b.isPrefixOperator(tok)
+	prev := b.code
+	b.code = tok
+
+	switch label {
+	case token.LBrace:
+		b.frames = append(b.frames, frame{kind: kind, parens: b.parens, ternary: b.ternary})
+		b.parens, b.ternary = 0, 0
+		b.level++
+		if i+1 >= len(b.toks) || b.toks[i+1].Type.Label != token.RBrace {
+			b.newline()
+		}
+	case token.RBrace:
+		if kind == objectFrame || i+1 >= len(b.toks) {
+			break
+		}
+		switch b.toks[i+1].Type.Label {
+		case token.RParen, token.RBracket, token.Comma, token.Semicolon, token.Dot,
+			token.OptionalChaining, token.LParen, token.LBracket:
+		case token.Else, token.Catch, token.Finally:
+		default:
+			b.newline()
+		}
+	case token.Semicolon:
+		if b.parens == 0 {
+			b.newline()
+		}
+	case token.Comma:
+		if b.top().kind == objectFrame && b.parens == 0 {
+			b.newline()
+		}
+	case token.Question:
+		b.ternary++
+	case token.Colon:
+		if b.ternary > 0 {
+			b.ternary--
+		} else if b.caseLabel {
+			b.caseLabel = false
+			b.top().inCase = true
+			b.level++
+			b.newline()
+		}
+	case token.LParen, token.LBracket:
+		b.parens++
+		if label == token.LParen {
+			prevLabel := ""
+			if prev != nil {
+				prevLabel = prev.Type.Label
+			}
+			b.parenLabels = append(b.parenLabels, prevLabel)
+		}
+	case token.RParen, token.RBracket:
+		if b.parens > 0 {
+			b.parens--
+		}
+		if label == token.RParen && len(b.parenLabels) > 0 {
+			b.closedParen = b.parenLabels[len(b.parenLabels)-1]
+			b.parenLabels = b.parenLabels[:len(b.parenLabels)-1]
+		}
+	}
+}
+
+// braceKind returns the kind of the brace about to be written.
+func (b *beautifier) braceKind() frameKind {
+	if b.code == nil {
+		return blockFrame
+	}
+	label := b.code.Type.Label
+	switch label {
+	case token.RParen:
+		if b.closedParen == token.Switch {
+			return switchFrame
+		}
+		return blockFrame
+	case token.Arrow, token.Semicolon, token.LBrace, token.RBrace, token.Else, token.Try,
+		token.Finally, token.Do, token.Class, token.Identifier:
+		return blockFrame
+	}
+	if token.IsContextualKeyword(label) {
+		return blockFrame
+	}
+	return objectFrame
+}
+
+// spaceBefore reports whether a space goes between the last token written
+// and tok, if they are on the same line.
+func (b *beautifier) spaceBefore(tok *token.Token) bool {
+	if b.code == nil {
+		return false
+	}
+	label := tok.Type.Label
+	prev := b.code.Type.Label
+	if b.written != b.code {
+		// After a comment
+		switch label {
+		case token.RParen, token.RBracket, token.Comma, token.Semicolon:
+			return false
+		}
+		return true
+	}
+	if b.unary {
+		return false
+	}
+	switch prev {
+	case token.LParen, token.LBracket, token.Dot, token.OptionalChaining, token.Ellipsis, token.At:
+		return false
+	}
+
+	switch label {
+	case token.RParen, token.RBracket, token.Comma, token.Semicolon, token.Dot,
+		token.OptionalChaining, token.NonNull:
+		return false
+	case token.RBrace:
+		return prev != token.LBrace
+	case token.Increment, token.Decrement:
+		// Postfix operators
+		return !join.EndsExpression(prev) || join.HasLineBreak(b.src[b.code.End:tok.Start])
+	case token.LParen:
+		// Calls
+		return !join.EndsExpression(prev) && prev != token.Function && prev != token.Import
+	case token.LBracket, token.TemplateStart:
+		// Member accesses and tagged templates
+		return !join.EndsExpression(prev)
+	case token.Colon:
+		return b.ternary > 0
+	}
+	return true
+}
+
+// isPrefixOperator reports whether tok, just written, is a prefix
+// operator that its operand follows without a space.
+func (b *beautifier) isPrefixOperator(tok *token.Token) bool {
+	switch tok.Type.Label {
+	case token.Increment, token.Decrement:
+		// No line break is allowed before a postfix operator
+		if b.code != nil && join.HasLineBreak(b.src[b.code.End:tok.Start]) {
+			return true
+		}
+		fallthrough
+	case token.Plus, token.Minus, token.Bang, token.Tilde:
+		return b.code == nil || !join.EndsExpression(b.code.Type.Label)
+	}
+	return false
+}
+
+// templateEnd returns the index of the token that ends the template
+// literal starting at toks[i].
+func (b *beautifier) templateEnd(i int) int {
+	depth := 0
+	for j := i; j < len(b.toks); j++ {
+		switch b.toks[j].Type.Label {
+		case token.TemplateStart:
+			depth++
+		case token.TemplateEnd:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(b.toks) - 1
+}
+
+// elementEnd returns the index of the token that ends the JSX element
+// starting at toks[i].
+func (b *beautifier) elementEnd(i int) int {
+	depth := 0
+	closing := false
+	for j := i; j < len(b.toks); j++ {
+		switch b.toks[j].Type.Label {
+		case token.JSXTagStart:
+			if j+1 < len(b.toks) && b.toks[j+1].Type.Label == token.Slash {
+				closing = true
+			} else {
+				depth++
+			}
+		case token.JSXTagEnd:
+			if closing {
+				depth--
+				closing = false
+			} else if b.toks[j-1].Type.Label == token.Slash {
+				depth--
+			}
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(b.toks) - 1
+}
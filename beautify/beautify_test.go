@@ -0,0 +1,154 @@
+package beautify
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/minify"
+)
+
+func TestBeautify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a=1;function f(x){return x*2}", "var a = 1;\nfunction f(x) {\n  return x * 2\n}"},
+		{"if(x){a()}else if(!x){b(-x)}else{c()}", "if (x) {\n  a()\n} else if (!x) {\n  b(-x)\n} else {\n  c()\n}"},
+		{"for(var i=0;i<n;i++){}", "for (var i = 0; i < n; i++) {}"},
+		{"const o={a:1,b:[1,2],c(){return{d:2}}};", "const o = {\n  a: 1,\n  b: [1, 2],\n  c() {\n    return {\n      d: 2\n    }\n  }\n};"},
+		{"switch(x){case 1:a();break;default:b()}", "switch (x) {\n  case 1:\n    a();\n    break;\n  default:\n    b()\n}"},
+		{"try{a()}catch(e){b(e)}finally{c()}", "try {\n  a()\n} catch (e) {\n  b(e)\n} finally {\n  c()\n}"},
+		{"x=a?b:c;y=a.b?.[c]", "x = a ? b : c;\ny = a.b?.[c]"},
+		{"(function(){a()})();f(x=>{y})", "(function() {\n  a()\n})();\nf(x => {\n  y\n})"},
+		{"a- -b;a+ +b;a- --b;a++ +b", "a - -b;\na + +b;\na - --b;\na++ + b"},
+		// Automatic semicolon insertion
+		{"a = 1\nb = 2", "a = 1\nb = 2"},
+		{"function f() { return\nx }", "function f() {\n  return\n  x\n}"},
+		{"a\n++b", "a\n++b"},
+		{"a = b\n(c)", "a = b(c)"},
+		// Blank lines
+		{"a();\n\n\n\nb();", "a();\n\nb();"},
+		// Templates and regular expressions
+		{"var s=`a ${ {b:1}.b }  c`", "var s = `a ${ {b:1}.b }  c`"},
+		{"tag`x`;r=/a+b/gi.test(s)", "tag`x`;\nr = /a+b/gi.test(s)"},
+		{"x = a / /re/g.exec(y)", "x = a / /re/g.exec(y)"},
+		// Comments
+		{"a(); // one\n/* two */\nb(/* three */ c)", "a(); // one\n/* two */\nb(/* three */ c)"},
+	}
+
+	for i, tt := range tests {
+		got, err := Beautify(tt.input, Options{})
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - output wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestBeautifyOptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected string
+	}{
+		{"if(a){b()}else{c()}", Options{Indent: "\t"}, "if (a) {\n\tb()\n} else {\n\tc()\n}"},
+		{"if(a){b()}else{c()}", Options{Indent: "    ", BraceStyle: Expand}, "if (a)\n{\n    b()\n}\nelse\n{\n    c()\n}"},
+		{"function f(){return{a:1}}", Options{BraceStyle: Expand}, "function f()\n{\n  return {\n    a: 1\n  }\n}"},
+	}
+
+	for i, tt := range tests {
+		got, err := Beautify(tt.input, tt.options)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - output wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestBeautifyJSX(t *testing.T) {
+	input := "const el=<div a={{b:1}}>hi {x}<br/></div>;f()"
+	expected := "const el = <div a={{b:1}}>hi {x}<br/></div>;\nf()"
+
+	got, err := Beautify(input, Options{}, lexer.WithJSX())
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if got != expected {
+		t.Fatalf("output wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestBeautifyError(t *testing.T) {
+	if _, err := Beautify("a = 'unterminated", Options{}); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+// TestBeautifyTokens checks that the output lexes to the tokens of the input,
+// for the input and its minified form.
+func TestBeautifyTokens(t *testing.T) {
+	inputs := []string{
+		"var  a = 1 ;\n\nfunction f ( x ) {\n  return x * 2 ;\n}",
+		"a - -b; a + +b; a - --b; a+ ++b; a - +b; a++ + b; a-- - b",
+		"x / /re/g.exec(y) / 2; x = /a/ in y",
+		"1 .toString(); 1.5 .toFixed(); a ? .5 : b",
+		"`a ${ b + `c ${ d }` } e` ; x",
+		"/*! license */\nvar a; /** @preserve */ b //! keep\nc",
+		"return\nx; a\nb; a\n++b; a = b\n(c)",
+		"if (a)\n  b\nelse\n  c\nlet x = 1\nlet y = [\n  1,\n  2\n]\n",
+		"async\nfunction f() {}",
+		"label: for (;;) { if (a) continue label; else break }",
+		"do { a() } while (b)\nc()",
+		"switch (a) { case 1: case 2: { b(); break } default: c() }",
+		"class A extends B { static #x = 1; get y() { return this.#x } static { init() } }",
+		"const {a, b: [c, d], ...e} = f, g = {...h, [i]: j, k() {}, async *l() {}}",
+		"x = a ? b ? c : d : e; y = {a: b ? c : d}",
+		"a?.b?.(c)?.[d]; a ?? b; a ||= b; a **= 2",
+		"(async () => { await x; for await (const y of z) {} })()",
+		"function* g() { yield* a; yield\nb }",
+		"a <!-- b\n--> c",
+		"if (a) {} else {}\n/re/.test(s)",
+	}
+
+	for i, input := range inputs {
+		minified, err := minify.Minify(input, lexer.WithSourceType(lexer.Script))
+		if err != nil {
+			t.Fatalf("inputs[%d] - unexpected error: %q", i, err.Error())
+		}
+		for _, src := range []string{input, minified} {
+			for _, style := range []BraceStyle{Collapse, Expand} {
+				got, err := Beautify(src, Options{BraceStyle: style}, lexer.WithSourceType(lexer.Script))
+				if err != nil {
+					t.Fatalf("inputs[%d] - unexpected error: %q", i, err.Error())
+				}
+				testSameTokens(t, i, src, got)
+			}
+		}
+	}
+}
+
+func testSameTokens(t *testing.T, i int, input, output string) {
+	t.Helper()
+
+	opts := []lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments(), lexer.WithSourceType(lexer.Script)}
+	expected, err := lexer.Tokenize(input, opts...)
+	if err != nil {
+		t.Fatalf("inputs[%d] - unexpected error: %q", i, err.Error())
+	}
+	got, err := lexer.Tokenize(output, opts...)
+	if err != nil {
+		t.Fatalf("inputs[%d] - output %q does not lex: %q", i, output, err.Error())
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("inputs[%d] - token count wrong for %q. expected=%d, got=%d", i, output, len(expected), len(got))
+	}
+	for j := range expected {
+		if got[j].Type != expected[j].Type || got[j].Literal != expected[j].Literal {
+			t.Fatalf("inputs[%d] - token %d wrong for %q. expected=%q, got=%q", i, j, output, expected[j].Literal, got[j].Literal)
+		}
+	}
+}
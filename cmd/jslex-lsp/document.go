@@ -0,0 +1,247 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/balance"
+	"github.com/morinokami/js-lexer/highlight"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// tokenTypes is the legend of the semantic tokens, indexed by highlight
+// category. Plain text and punctuation are left to the editor.
+var tokenTypes = []string{"keyword", "variable", "string", "number", "regexp", "comment", "operator", "type"}
+
+var categoryTypes = map[highlight.Category]uint32{
+	highlight.Keyword:    0,
+	highlight.Identifier: 1,
+	highlight.String:     2,
+	highlight.Number:     3,
+	highlight.RegExp:     4,
+	highlight.Comment:    5,
+	highlight.Operator:   6,
+	highlight.Tag:        7,
+}
+
+// document is an open text document.
+type document struct {
+	*lexer.Document
+	version int
+	utf8    bool
+
+	// The last semantic tokens sent, for computing deltas
+	resultID string
+	data     []uint32
+}
+
+func newDocument(item textDocumentItem, utf8 bool) *document {
+	opts := []lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments(), lexer.WithDetectedSourceType()}
+	switch item.LanguageID {
+	case "typescript":
+		opts = append(opts, lexer.WithDialect(lexer.TypeScript))
+	case "typescriptreact":
+		opts = append(opts, lexer.WithDialect(lexer.TypeScript), lexer.WithJSX())
+	case "javascriptreact":
+		opts = append(opts, lexer.WithJSX())
+	}
+	return &document{Document: lexer.NewDocument(item.Text, opts...), version: item.Version, utf8: utf8}
+}
+
+// lineStarts returns the byte offsets at which the lines of the document
+// start. Like the lexer, it ends lines at "\n" only, which also ends the
+// lines of "\r\n".
+func (d *document) lineStarts() []int {
+	src := d.Source()
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// characters returns the number of characters in text in the position
+// encoding of the document.
+func (d *document) characters(text string) int {
+	if d.utf8 {
+		return len(text)
+	}
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// position returns the position of a byte offset on the given line.
+func (d *document) position(starts []int, line, offset int) position {
+	return position{Line: line, Character: d.characters(d.Source()[starts[line]:offset])}
+}
+
+// offset returns the byte offset of p, clamped to the document.
+func (d *document) offset(starts []int, p position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(starts) {
+		return len(d.Source())
+	}
+	src := d.Source()
+	end := len(src)
+	if p.Line+1 < len(starts) {
+		end = starts[p.Line+1] - 1
+	}
+	offset := starts[p.Line]
+	for n := 0; n < p.Character && offset < end; {
+		r, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+		n += d.characters(string(r))
+	}
+	return offset
+}
+
+func (d *document) tokenRange(starts []int, tok token.Token) lspRange {
+	return lspRange{
+		Start: d.position(starts, tok.Loc.Start.Line, tok.Start),
+		End:   d.position(starts, tok.Loc.End.Line, tok.End),
+	}
+}
+
+// change applies a change event from the client.
+func (d *document) change(c textDocumentContentChangeEvent) error {
+	if c.Range == nil {
+		_, err := d.Apply(lexer.Edit{Start: 0, End: len(d.Source()), Text: c.Text})
+		return err
+	}
+	starts := d.lineStarts()
+	_, err := d.Apply(lexer.Edit{Start: d.offset(starts, c.Range.Start), End: d.offset(starts, c.Range.End), Text: c.Text})
+	return err
+}
+
+// semanticTokens returns the semantic tokens of the document, encoded
+// relative to each other as LSP requires. Tokens spanning lines, such as
+// block comments, are split into one token per line.
+func (d *document) semanticTokens() []uint32 {
+	src := d.Source()
+	starts := d.lineStarts()
+	data := []uint32{}
+	var line, char int
+	add := func(l, start, end int, typ uint32) {
+		c := d.characters(src[starts[l]:start])
+		length := d.characters(src[start:end])
+		if length == 0 {
+			return
+		}
+		if l != line {
+			char = 0
+		}
+		data = append(data, uint32(l-line), uint32(c-char), uint32(length), typ, 0)
+		line, char = l, c
+	}
+
+	for _, tok := range d.Tokens() {
+		typ, ok := categoryTypes[highlight.Classify(tok)]
+		if !ok {
+			continue
+		}
+		for l := tok.Loc.Start.Line; l <= tok.Loc.End.Line && l < len(starts); l++ {
+			start, end := max(tok.Start, starts[l]), tok.End
+			if l < tok.Loc.End.Line {
+				// Up to the end of the line
+				end = starts[l+1] - 1
+				if end > start && src[end-1] == '\r' {
+					end--
+				}
+			}
+			add(l, start, end, typ)
+		}
+	}
+	return data
+}
+
+// diagnostics returns the syntax errors of the document, and its
+// delimiters that do not match.
+func (d *document) diagnostics() []diagnostic {
+	starts := d.lineStarts()
+	diags := []diagnostic{}
+	for _, err := range d.Errors() {
+		line := min(err.Line, len(starts)-1)
+		offset := min(max(err.Offset, starts[line]), len(d.Source()))
+		start := d.position(starts, line, offset)
+		end := start
+		if offset < len(d.Source()) && d.Source()[offset] != '\n' {
+			_, size := utf8.DecodeRuneInString(d.Source()[offset:])
+			end = d.position(starts, line, offset+size)
+		}
+		diags = append(diags, diagnostic{
+			Range:    lspRange{Start: start, End: end},
+			Severity: severityError,
+			Source:   "jslex",
+			Message:  err.Message,
+		})
+	}
+	for _, p := range balance.Check(d.Tokens()).Problems {
+		tok := p.Close
+		if p.Kind == balance.Unclosed {
+			tok = p.Open
+		}
+		diags = append(diags, diagnostic{
+			Range:    d.tokenRange(starts, *tok),
+			Severity: severityWarning,
+			Source:   "jslex",
+			Message:  p.Message(),
+		})
+	}
+	return diags
+}
+
+// foldingRanges returns ranges for the brackets and block comments that
+// span lines. Ranges for brackets end on the line before the closing
+// bracket, which stays visible.
+func (d *document) foldingRanges() []foldingRange {
+	ranges := []foldingRange{}
+	for _, p := range balance.Check(d.Tokens()).Pairs {
+		start, end := p.Open.Loc.Start.Line, p.Close.Loc.Start.Line-1
+		if end > start {
+			ranges = append(ranges, foldingRange{StartLine: start, EndLine: end})
+		}
+	}
+	for _, tok := range d.Tokens() {
+		if tok.Type.Label == token.BlockComment && tok.Loc.End.Line > tok.Loc.Start.Line {
+			ranges = append(ranges, foldingRange{StartLine: tok.Loc.Start.Line, EndLine: tok.Loc.End.Line, Kind: "comment"})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].StartLine < ranges[j].StartLine })
+	return ranges
+}
+
+// matchingBrackets returns the ranges of the bracket at p, or just before
+// it, and of the bracket it matches.
+func (d *document) matchingBrackets(p position) []documentHighlight {
+	starts := d.lineStarts()
+	offset := d.offset(starts, p)
+
+	var match balance.Pair
+	found := false
+	for _, pair := range balance.Check(d.Tokens()).Pairs {
+		for _, tok := range []token.Token{pair.Open, pair.Close} {
+			if tok.Start == offset || !found && tok.End == offset {
+				match, found = pair, true
+			}
+		}
+	}
+	if !found {
+		return []documentHighlight{}
+	}
+	return []documentHighlight{
+		{Range: d.tokenRange(starts, match.Open), Kind: highlightText},
+		{Range: d.tokenRange(starts, match.Close), Kind: highlightText},
+	}
+}
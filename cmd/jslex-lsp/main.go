@@ -0,0 +1,47 @@
+// Command jslex-lsp is a language server for JavaScript that speaks the
+// Language Server Protocol over standard input and output. It highlights
+// documents with semantic tokens classified as by the highlight package,
+// reports syntax errors found by the lexer as diagnostics, provides folding
+// ranges for brackets and block comments, and highlights matching brackets.
+//
+// Usage:
+//
+//	jslex-lsp [-stdio]
+//
+// Documents are kept up to date as they are edited with a lexer.Document,
+// which lexes again only the tokens around each edit.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("jslex-lsp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	// Clients pass -stdio, which is the only transport
+	fs.Bool("stdio", true, "communicate over standard input and output")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	s := newServer(stdin, stdout, stderr)
+	err := s.serve()
+	if !errors.Is(err, errExit) {
+		fmt.Fprintf(stderr, "jslex-lsp: %v\n", err)
+		return 1
+	}
+	if !s.shutdown {
+		// Exit without shutdown
+		return 1
+	}
+	return 0
+}
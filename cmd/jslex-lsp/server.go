@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// errExit is returned by serve when the client sends the exit
+// notification.
+var errExit = errors.New("exit")
+
+type server struct {
+	conn     *conn
+	log      io.Writer
+	docs     map[string]*document
+	utf8     bool
+	shutdown bool
+	// Counter for the IDs of semantic token results
+	results int
+}
+
+func newServer(r io.Reader, w, log io.Writer) *server {
+	return &server{conn: newConn(r, w), log: log, docs: map[string]*document{}}
+}
+
+// serve handles messages until the client exits or the connection fails.
+func (s *server) serve() error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			var rerr *responseError
+			if errors.As(err, &rerr) {
+				s.conn.reply(json.RawMessage("null"), nil, rerr)
+				continue
+			}
+			return err
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(msg *message) error {
+	if msg.ID == nil {
+		return s.notification(msg.Method, msg.Params)
+	}
+	result, rerr := s.request(msg.Method, msg.Params)
+	return s.conn.reply(msg.ID, result, rerr)
+}
+
+func (s *server) request(method string, params json.RawMessage) (any, *responseError) {
+	switch method {
+	case "initialize":
+		var p initializeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.initialize(p), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/semanticTokens/full":
+		var p textDocumentParams
+		d, rerr := s.document(params, &p, &p.TextDocument)
+		if rerr != nil {
+			return nil, rerr
+		}
+		return s.semanticTokens(d), nil
+	case "textDocument/semanticTokens/full/delta":
+		var p semanticTokensDeltaParams
+		d, rerr := s.document(params, &p, &p.TextDocument)
+		if rerr != nil {
+			return nil, rerr
+		}
+		return s.semanticTokensDelta(d, p.PreviousResultID), nil
+	case "textDocument/foldingRange":
+		var p textDocumentParams
+		d, rerr := s.document(params, &p, &p.TextDocument)
+		if rerr != nil {
+			return nil, rerr
+		}
+		return d.foldingRanges(), nil
+	case "textDocument/documentHighlight":
+		var p textDocumentPositionParams
+		d, rerr := s.document(params, &p, &p.TextDocument)
+		if rerr != nil {
+			return nil, rerr
+		}
+		return d.matchingBrackets(p.Position), nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
+
+func (s *server) notification(method string, params json.RawMessage) error {
+	switch method {
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		var p didOpenTextDocumentParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			fmt.Fprintf(s.log, "jslex-lsp: %s: %v\n", method, err)
+			return nil
+		}
+		d := newDocument(p.TextDocument, s.utf8)
+		s.docs[p.TextDocument.URI] = d
+		return s.publishDiagnostics(p.TextDocument.URI, d)
+	case "textDocument/didChange":
+		var p didChangeTextDocumentParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			fmt.Fprintf(s.log, "jslex-lsp: %s: %v\n", method, err)
+			return nil
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			fmt.Fprintf(s.log, "jslex-lsp: %s: unknown document %s\n", method, p.TextDocument.URI)
+			return nil
+		}
+		for _, c := range p.ContentChanges {
+			if err := d.change(c); err != nil {
+				fmt.Fprintf(s.log, "jslex-lsp: %s: %v\n", method, err)
+			}
+		}
+		d.version = p.TextDocument.Version
+		return s.publishDiagnostics(p.TextDocument.URI, d)
+	case "textDocument/didClose":
+		var p didCloseTextDocumentParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			fmt.Fprintf(s.log, "jslex-lsp: %s: %v\n", method, err)
+			return nil
+		}
+		delete(s.docs, p.TextDocument.URI)
+		// Clear the diagnostics of the closed document
+		return s.conn.call(nil, "textDocument/publishDiagnostics", publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []diagnostic{}})
+	}
+	// Other notifications, such as initialized, need no action
+	return nil
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// document unmarshals params into p and returns the open document that
+// doc, a field of p, identifies.
+func (s *server) document(params json.RawMessage, p any, doc *textDocumentIdentifier) (*document, *responseError) {
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, invalidParams(err)
+	}
+	d, ok := s.docs[doc.URI]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document %s", doc.URI)}
+	}
+	return d, nil
+}
+
+func (s *server) initialize(p initializeParams) initializeResult {
+	encoding := "utf-16"
+	if slices.Contains(p.Capabilities.General.PositionEncodings, "utf-8") {
+		encoding = "utf-8"
+		s.utf8 = true
+	}
+
+	var caps serverCapabilities
+	caps.PositionEncoding = encoding
+	caps.TextDocumentSync = syncIncremental
+	caps.SemanticTokensProvider.Legend = semanticTokensLegend{TokenTypes: tokenTypes, TokenModifiers: []string{}}
+	caps.SemanticTokensProvider.Full.Delta = true
+	caps.FoldingRangeProvider = true
+	caps.DocumentHighlightProvider = true
+	return initializeResult{Capabilities: caps, ServerInfo: serverInfo{Name: "jslex-lsp"}}
+}
+
+func (s *server) publishDiagnostics(uri string, d *document) error {
+	return s.conn.call(nil, "textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Version:     d.version,
+		Diagnostics: d.diagnostics(),
+	})
+}
+
+func (s *server) semanticTokens(d *document) semanticTokens {
+	s.results++
+	d.resultID = strconv.Itoa(s.results)
+	d.data = d.semanticTokens()
+	return semanticTokens{ResultID: d.resultID, Data: d.data}
+}
+
+// semanticTokensDelta returns the edit from the semantic tokens of the
+// result previousID to the current ones, or all of the current ones if
+// that result is not the last one sent.
+func (s *server) semanticTokensDelta(d *document, previousID string) any {
+	if previousID != d.resultID || d.resultID == "" {
+		return s.semanticTokens(d)
+	}
+	old := d.data
+	full := s.semanticTokens(d)
+
+	// Replace what lies between the common prefix and suffix, in whole
+	// tokens of five integers
+	prefix := 0
+	for prefix < len(old) && prefix < len(full.Data) && old[prefix] == full.Data[prefix] {
+		prefix++
+	}
+	prefix -= prefix % 5
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(full.Data)-prefix &&
+		old[len(old)-1-suffix] == full.Data[len(full.Data)-1-suffix] {
+		suffix++
+	}
+	suffix -= suffix % 5
+
+	delta := semanticTokensDelta{ResultID: full.ResultID, Edits: []semanticTokensEdit{}}
+	if prefix+suffix < len(old) || prefix+suffix < len(full.Data) {
+		delta.Edits = append(delta.Edits, semanticTokensEdit{
+			Start:       prefix,
+			DeleteCount: len(old) - prefix - suffix,
+			Data:        full.Data[prefix : len(full.Data)-suffix],
+		})
+	}
+	return delta
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// client talks to a server running in the same process.
+type client struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	// Messages from the server, read as they come so that the server never
+	// waits for the client to read
+	msgs chan *message
+	// Notifications received while waiting for responses
+	notifications []*message
+	done          chan error
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	c := &client{t: t, conn: newConn(clientR, clientW), msgs: make(chan *message, 100), done: make(chan error, 1)}
+	go func() {
+		err := newServer(serverR, serverW, io.Discard).serve()
+		serverW.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.msgs)
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		clientW.Close()
+		<-c.done
+	})
+	return c
+}
+
+// call sends a request and unmarshals the result of its response into
+// result.
+func (c *client) call(method string, params, result any) *responseError {
+	c.t.Helper()
+
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	if err := c.conn.call(id, method, params); err != nil {
+		c.t.Fatalf("%s - unexpected error: %q", method, err.Error())
+	}
+	for {
+		msg, ok := <-c.msgs
+		if !ok {
+			c.t.Fatalf("%s - connection closed", method)
+		}
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if !bytes.Equal(msg.ID, id) {
+			c.t.Fatalf("%s - response id wrong. expected=%s, got=%s", method, id, msg.ID)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatalf("%s - unexpected error: %q", method, err.Error())
+		}
+		return nil
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+
+	if err := c.conn.call(nil, method, params); err != nil {
+		c.t.Fatalf("%s - unexpected error: %q", method, err.Error())
+	}
+}
+
+// diagnostics waits for the next diagnostics the server publishes.
+func (c *client) diagnostics() publishDiagnosticsParams {
+	c.t.Helper()
+
+	for {
+		var msg *message
+		if len(c.notifications) > 0 {
+			msg, c.notifications = c.notifications[0], c.notifications[1:]
+		} else if msg = <-c.msgs; msg == nil {
+			c.t.Fatalf("connection closed")
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p publishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			c.t.Fatalf("unexpected error: %q", err.Error())
+		}
+		return p
+	}
+}
+
+func (c *client) initialize(encodings ...string) initializeResult {
+	c.t.Helper()
+
+	var p initializeParams
+	p.Capabilities.General.PositionEncodings = encodings
+	var result initializeResult
+	if err := c.call("initialize", p, &result); err != nil {
+		c.t.Fatalf("initialize - unexpected error: %q", err.Error())
+	}
+	c.notify("initialized", struct{}{})
+	return result
+}
+
+func (c *client) open(uri, languageID, text string) {
+	c.t.Helper()
+
+	c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: uri, LanguageID: languageID, Version: 1, Text: text},
+	})
+}
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t)
+	result := c.initialize()
+	if result.Capabilities.PositionEncoding != "utf-16" {
+		t.Fatalf("position encoding wrong. got=%q", result.Capabilities.PositionEncoding)
+	}
+	if !reflect.DeepEqual(result.Capabilities.SemanticTokensProvider.Legend.TokenTypes, tokenTypes) {
+		t.Fatalf("token types wrong. got=%q", result.Capabilities.SemanticTokensProvider.Legend.TokenTypes)
+	}
+
+	c = newClient(t)
+	if result := c.initialize("utf-16", "utf-8"); result.Capabilities.PositionEncoding != "utf-8" {
+		t.Fatalf("position encoding wrong. got=%q", result.Capabilities.PositionEncoding)
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []uint32
+	}{
+		{"let x = 1;", []uint32{
+			0, 0, 3, 1, 0, // let
+			0, 4, 1, 1, 0, // x
+			0, 2, 1, 6, 0, // =
+			0, 2, 1, 3, 0, // 1
+		}},
+		{"if (a) /* b\n c */ 'ü𝒳'", []uint32{
+			0, 0, 2, 0, 0, // if
+			0, 4, 1, 1, 0, // a
+			0, 3, 4, 5, 0, // /* b
+			1, 0, 5, 5, 0, //  c */
+			0, 6, 5, 2, 0, // 'ü𝒳', with 𝒳 taking two UTF-16 code units
+		}},
+		{"`a\r\nb${c}`", []uint32{
+			0, 0, 1, 2, 0, // `
+			0, 1, 1, 2, 0, // a
+			1, 0, 1, 2, 0, // b
+			0, 3, 1, 1, 0, // c
+			0, 2, 1, 2, 0, // `
+		}},
+		{"a <!-- b", []uint32{
+			0, 0, 1, 1, 0, // a
+			0, 2, 6, 5, 0, // <!-- b, a comment as the input is not a module
+		}},
+	}
+
+	for i, tt := range tests {
+		c := newClient(t)
+		c.initialize()
+		c.open("file:///a.js", "javascript", tt.input)
+
+		var result semanticTokens
+		if err := c.call("textDocument/semanticTokens/full", textDocumentParams{TextDocument: textDocumentIdentifier{URI: "file:///a.js"}}, &result); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if !reflect.DeepEqual(result.Data, tt.expected) {
+			t.Fatalf("tests[%d] - data wrong. expected=%v, got=%v", i, tt.expected, result.Data)
+		}
+	}
+}
+
+func TestSemanticTokensDelta(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("file:///a.js", "javascript", "a;\nb;\nc;")
+	doc := textDocumentIdentifier{URI: "file:///a.js"}
+
+	var full semanticTokens
+	if err := c.call("textDocument/semanticTokens/full", textDocumentParams{TextDocument: doc}, &full); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	// Make b a keyword
+	c.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument: versionedTextDocumentIdentifier{URI: doc.URI, Version: 2},
+		ContentChanges: []textDocumentContentChangeEvent{
+			{Range: &lspRange{Start: position{Line: 1, Character: 0}, End: position{Line: 1, Character: 1}}, Text: "this"},
+		},
+	})
+
+	var delta semanticTokensDelta
+	if err := c.call("textDocument/semanticTokens/full/delta", semanticTokensDeltaParams{TextDocument: doc, PreviousResultID: full.ResultID}, &delta); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	expected := []semanticTokensEdit{{Start: 5, DeleteCount: 5, Data: []uint32{1, 0, 4, 0, 0}}}
+	if delta.ResultID == full.ResultID || !reflect.DeepEqual(delta.Edits, expected) {
+		t.Fatalf("delta wrong. expected=%+v, got=%+v", expected, delta)
+	}
+
+	// An unknown previous result gets all the tokens
+	var result semanticTokens
+	if err := c.call("textDocument/semanticTokens/full/delta", semanticTokensDeltaParams{TextDocument: doc, PreviousResultID: "x"}, &result); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if len(result.Data) != 15 {
+		t.Fatalf("data wrong. got=%v", result.Data)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("file:///a.js", "javascript", "let s = 'abc\nlet t = '\\x4';\n#\nf(a]")
+
+	expected := []diagnostic{
+		{Range: lspRange{Start: position{0, 8}, End: position{0, 9}}, Severity: severityError, Source: "jslex", Message: "Unterminated string constant"},
+		{Range: lspRange{Start: position{1, 9}, End: position{1, 10}}, Severity: severityError, Source: "jslex", Message: "Bad character escape sequence"},
+		{Range: lspRange{Start: position{2, 0}, End: position{2, 1}}, Severity: severityError, Source: "jslex", Message: "Unexpected character '#'"},
+		{Range: lspRange{Start: position{3, 1}, End: position{3, 2}}, Severity: severityWarning, Source: "jslex", Message: "Unclosed '('"},
+		{Range: lspRange{Start: position{3, 3}, End: position{3, 4}}, Severity: severityWarning, Source: "jslex", Message: "Unmatched ']'"},
+	}
+	p := c.diagnostics()
+	if p.URI != "file:///a.js" || p.Version != 1 || !reflect.DeepEqual(p.Diagnostics, expected) {
+		t.Fatalf("diagnostics wrong. expected=%+v, got=%+v", expected, p)
+	}
+
+	// Fixing the errors clears them
+	c.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: "file:///a.js", Version: 2},
+		ContentChanges: []textDocumentContentChangeEvent{{Text: "let s = 'abc';"}},
+	})
+	p = c.diagnostics()
+	if p.Version != 2 || len(p.Diagnostics) != 0 {
+		t.Fatalf("diagnostics wrong. got=%+v", p)
+	}
+}
+
+func TestFoldingRange(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("file:///a.js", "javascript", "/**\n * f\n */\nfunction f() {\n  g(() => {\n  });\n  return [\n    1,\n  ];\n}")
+
+	var ranges []foldingRange
+	if err := c.call("textDocument/foldingRange", textDocumentParams{TextDocument: textDocumentIdentifier{URI: "file:///a.js"}}, &ranges); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	expected := []foldingRange{
+		{StartLine: 0, EndLine: 2, Kind: "comment"},
+		{StartLine: 3, EndLine: 8},
+		{StartLine: 6, EndLine: 7},
+	}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Fatalf("ranges wrong. expected=%+v, got=%+v", expected, ranges)
+	}
+}
+
+func TestDocumentHighlight(t *testing.T) {
+	tests := []struct {
+		pos      position
+		expected []documentHighlight
+	}{
+		{position{0, 13}, []documentHighlight{
+			{Range: lspRange{Start: position{0, 13}, End: position{0, 14}}, Kind: highlightText},
+			{Range: lspRange{Start: position{2, 0}, End: position{2, 1}}, Kind: highlightText},
+		}},
+		{position{1, 6}, []documentHighlight{
+			{Range: lspRange{Start: position{1, 3}, End: position{1, 5}}, Kind: highlightText},
+			{Range: lspRange{Start: position{1, 6}, End: position{1, 7}}, Kind: highlightText},
+		}},
+		// Backticks, which win over the bracket just before
+		{position{1, 7}, []documentHighlight{
+			{Range: lspRange{Start: position{1, 2}, End: position{1, 3}}, Kind: highlightText},
+			{Range: lspRange{Start: position{1, 7}, End: position{1, 8}}, Kind: highlightText},
+		}},
+		// After a bracket
+		{position{2, 1}, []documentHighlight{
+			{Range: lspRange{Start: position{0, 13}, End: position{0, 14}}, Kind: highlightText},
+			{Range: lspRange{Start: position{2, 0}, End: position{2, 1}}, Kind: highlightText},
+		}},
+		{position{1, 0}, []documentHighlight{}},
+	}
+
+	c := newClient(t)
+	c.initialize()
+	c.open("file:///a.js", "javascript", "function f() {\n  `${a}`\n}")
+
+	for i, tt := range tests {
+		var highlights []documentHighlight
+		params := textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: "file:///a.js"}, Position: tt.pos}
+		if err := c.call("textDocument/documentHighlight", params, &highlights); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if !reflect.DeepEqual(highlights, tt.expected) {
+			t.Fatalf("tests[%d] - highlights wrong. expected=%+v, got=%+v", i, tt.expected, highlights)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+
+	var result any
+	if err := c.call("textDocument/hover", struct{}{}, &result); err == nil || err.Code != codeMethodNotFound {
+		t.Fatalf("expected method not found error, got %v", err)
+	}
+	if err := c.call("textDocument/foldingRange", textDocumentParams{TextDocument: textDocumentIdentifier{URI: "file:///none.js"}}, &result); err == nil || err.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error, got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		messages       []message
+		expectedStatus int
+	}{
+		{[]message{{ID: json.RawMessage("1"), Method: "shutdown"}, {Method: "exit"}}, 0},
+		{[]message{{Method: "exit"}}, 1},
+		{nil, 1},
+	}
+
+	for i, tt := range tests {
+		var stdin, stdout, stderr bytes.Buffer
+		conn := newConn(nil, &stdin)
+		for _, msg := range tt.messages {
+			conn.write(&msg)
+		}
+		if status := run(nil, &stdin, &stdout, &stderr); status != tt.expectedStatus {
+			t.Fatalf("tests[%d] - status wrong. expected=%d, got=%d", i, tt.expectedStatus, status)
+		}
+	}
+}
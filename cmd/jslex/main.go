@@ -0,0 +1,269 @@
+// Command jslex tokenizes JavaScript source files and prints their tokens.
+//
+// Usage:
+//
+//	jslex [flags] [file ...]
+//
+// With no files, or with "-" as a file name, jslex reads standard input.
+// Syntax errors are reported on standard error as file:line:col: message,
+// with one-based lines and columns, and make jslex exit with status 1. With
+// -frame, each error is followed by a code frame showing the code around it.
+//
+// With -r, the arguments may be directories, whose JavaScript and TypeScript
+// files jslex lexes concurrently, skipping the files .gitignore files ignore,
+// and prints in the order of their paths.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/morinokami/js-lexer/codeframe"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+	"github.com/morinokami/js-lexer/walk"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	status := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(status)
+}
+
+type config struct {
+	format     string
+	comments   bool
+	sourceType lexer.SourceType
+	detect     bool
+	dialect    lexer.Dialect
+	jsx        bool
+	recover    bool
+	frame      bool
+	color      bool
+	// Whether the dialect was given, rather than chosen by the extension
+	// of each file with -r
+	dialectSet bool
+}
+
+// options returns the options to lex the file at path with.
+func (cfg config) options(path string) []lexer.Option {
+	dialect, jsx := cfg.dialect, cfg.jsx
+	if !cfg.dialectSet {
+		switch filepath.Ext(path) {
+		case ".ts", ".mts", ".cts":
+			dialect = lexer.TypeScript
+		case ".tsx":
+			dialect, jsx = lexer.TypeScript, true
+		case ".jsx":
+			jsx = true
+		}
+	}
+	opts := []lexer.Option{
+		lexer.WithRegExpHeuristic(),
+		lexer.WithSourceType(cfg.sourceType),
+		lexer.WithDialect(dialect),
+	}
+	if cfg.comments {
+		opts = append(opts, lexer.WithComments())
+	}
+	if jsx {
+		opts = append(opts, lexer.WithJSX())
+	}
+	if cfg.detect {
+		opts = append(opts, lexer.WithDetectedSourceType())
+	}
+	return opts
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("jslex", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: jslex [flags] [file ...]\n")
+		fs.PrintDefaults()
+	}
+
+	format := fs.String("format", "table", "output format: table, jsonl, or acorn")
+	comments := fs.Bool("comments", false, "include comments in the output")
+	sourceType := fs.String("source-type", "module", "source type: module, script, or auto")
+	dialect := fs.String("dialect", "js", "language: js, ts, or flow")
+	jsx := fs.Bool("jsx", false, "read JSX elements")
+	recover := fs.Bool("recover", false, "keep tokenizing after syntax errors")
+	frame := fs.Bool("frame", false, "show the code around syntax errors")
+	color := fs.Bool("color", false, "color the code frames with ANSI escape sequences")
+	recursive := fs.Bool("r", false, "lex the files in the directories given, concurrently")
+	include := fs.String("include", "", "comma-separated glob patterns of the files to lex with -r")
+	exclude := fs.String("exclude", "", "comma-separated glob patterns of the files and directories to skip with -r")
+	workers := fs.Int("j", 0, "number of files lexed at once with -r (default the number of CPUs)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg := config{format: *format, comments: *comments, jsx: *jsx, recover: *recover, frame: *frame, color: *color, dialectSet: true}
+	if *recursive {
+		cfg.dialectSet = false
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "dialect" {
+				cfg.dialectSet = true
+			}
+		})
+	}
+	switch *sourceType {
+	case "module":
+		cfg.sourceType = lexer.Module
+	case "script":
+		cfg.sourceType = lexer.Script
+	case "auto":
+		cfg.detect = true
+	default:
+		fmt.Fprintf(stderr, "jslex: unknown source type %q\n", *sourceType)
+		return 2
+	}
+	switch *dialect {
+	case "js":
+		cfg.dialect = lexer.JavaScript
+	case "ts":
+		cfg.dialect = lexer.TypeScript
+	case "flow":
+		cfg.dialect = lexer.Flow
+	default:
+		fmt.Fprintf(stderr, "jslex: unknown dialect %q\n", *dialect)
+		return 2
+	}
+
+	p, ok := printers[cfg.format]
+	if !ok {
+		fmt.Fprintf(stderr, "jslex: unknown format %q\n", cfg.format)
+		return 2
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	// The files share a file set, which gives the positions of the errors
+	fset := token.NewFileSet()
+	status := 0
+	for _, name := range files {
+		if *recursive && name != "-" {
+			o := walk.Options{
+				Include: splitList(*include),
+				Exclude: splitList(*exclude),
+				Workers: *workers,
+				Recover: cfg.recover,
+				Lexer:   cfg.options,
+				FileSet: fset,
+			}
+			for r, err := range walk.Lex(ctx, name, o) {
+				if err != nil {
+					fmt.Fprintf(stderr, "jslex: %v\n", err)
+					return 1
+				}
+				if !report(r, cfg, p(stdout), stderr) {
+					status = 1
+				}
+			}
+			continue
+		}
+
+		src, err := readFile(name, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "jslex: %v\n", err)
+			status = 1
+			continue
+		}
+		if name == "-" {
+			name = "<stdin>"
+		}
+		if !tokenize(fset.AddFile(name, string(src)), cfg, p(stdout), stderr) {
+			status = 1
+		}
+	}
+	return status
+}
+
+func readFile(name string, stdin io.Reader) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// tokenize prints the tokens of the file f and reports whether it was free of
+// syntax errors.
+func tokenize(f *token.File, cfg config, p printer, stderr io.Writer) bool {
+	opts := cfg.options(f.Name())
+	l := lexer.New(f.Source(), append(opts, lexer.WithFile(f))...)
+
+	ok := true
+	p.begin(f.Name())
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			ok = false
+			printError(f, err, cfg, stderr)
+			if !cfg.recover {
+				break
+			}
+			if tok == nil {
+				continue
+			}
+		}
+		p.token(tok)
+		if tok.Type.Label == token.EOF {
+			break
+		}
+	}
+	p.end()
+	return ok
+}
+
+// report prints the tokens of a file lexed with -r, and its errors, and
+// reports whether it was free of them.
+func report(r walk.Result, cfg config, p printer, stderr io.Writer) bool {
+	if r.File == nil {
+		for _, err := range r.Errors {
+			fmt.Fprintf(stderr, "jslex: %v\n", err)
+		}
+		return len(r.Errors) == 0
+	}
+	p.begin(r.Path)
+	for i := range r.Tokens {
+		p.token(&r.Tokens[i])
+	}
+	p.end()
+	for _, err := range r.Errors {
+		printError(r.File, err, cfg, stderr)
+	}
+	return len(r.Errors) == 0
+}
+
+// printError prints an error in the file f, followed by a code frame with
+// -frame if it is a syntax error.
+func printError(f *token.File, err error, cfg config, stderr io.Writer) {
+	serr, ok := err.(*lexer.SyntaxError)
+	if !ok {
+		fmt.Fprintf(stderr, "%s: %v\n", f.Name(), err)
+		return
+	}
+	fmt.Fprintf(stderr, "%s: %s\n", f.Position(serr.Pos), serr.Message)
+	if cfg.frame {
+		fmt.Fprint(stderr, codeframe.ErrorFrame(f.Source(), serr, codeframe.Options{Color: cfg.color}, cfg.options(f.Name())...))
+	}
+}
+
+// splitList splits a comma-separated list.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args           []string
+		input          string
+		expectedStatus int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			[]string{},
+			"let x",
+			0,
+			"<stdin>:\n  0:0-0:3  identifier  \"let\"\n  0:4-0:5  identifier  \"x\"\n  0:5-0:5  eof         \"\"\n",
+			"",
+		},
+		{
+			[]string{"-format", "jsonl", "-comments"},
+			"//c\n1",
+			0,
+			`{"file":"<stdin>","type":"line-comment","literal":"c","start":0,"end":3,"loc":{"start":{"line":0,"column":0},"end":{"line":0,"column":3}}}
+{"file":"<stdin>","type":"numeric","literal":"1","start":4,"end":5,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}}}
+{"file":"<stdin>","type":"eof","literal":"","start":5,"end":5,"loc":{"start":{"line":1,"column":1},"end":{"line":1,"column":1}}}
+`,
+			"",
+		},
+		{
+			[]string{"-format", "acorn", "-source-type", "script"},
+			"await 0x10",
+			0,
+			`[
+  {"type":{"label":"name"},"value":"await","start":0,"end":5,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":5}}},
+  {"type":{"label":"num"},"value":16,"start":6,"end":10,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":10}}},
+  {"type":{"label":"eof"},"start":10,"end":10,"loc":{"start":{"line":1,"column":10},"end":{"line":1,"column":10}}}
+]
+`,
+			"",
+		},
+		{
+			[]string{},
+			"x = /a/",
+			0,
+			"<stdin>:\n  0:0-0:1  identifier  \"x\"\n  0:2-0:3  =           \"=\"\n  0:4-0:7  regexp      \"/a/\"\n  0:7-0:7  eof         \"\"\n",
+			"",
+		},
+		{
+			[]string{"-format", "acorn"},
+			`x = /"/`,
+			0,
+			`[
+  {"type":{"label":"name"},"value":"x","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}}},
+  {"type":{"label":"="},"value":"=","start":2,"end":3,"loc":{"start":{"line":1,"column":2},"end":{"line":1,"column":3}}},
+  {"type":{"label":"regexp"},"value":"/\"/","start":4,"end":7,"loc":{"start":{"line":1,"column":4},"end":{"line":1,"column":7}}},
+  {"type":{"label":"eof"},"start":7,"end":7,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":7}}}
+]
+`,
+			"",
+		},
+		{
+			[]string{"-source-type", "auto"},
+			"<!-- x\nawait",
+			0,
+			"<stdin>:\n  1:0-1:5  identifier  \"await\"\n  1:5-1:5  eof         \"\"\n",
+			"",
+		},
+		{
+			[]string{"-jsx"},
+			"<a>b</a>",
+			0,
+			"<stdin>:\n  0:0-0:1  jsx-tag-start   \"<\"\n  0:1-0:2  jsx-identifier  \"a\"\n  0:2-0:3  jsx-tag-end     \">\"\n  0:3-0:4  jsx-text        \"b\"\n" +
+				"  0:4-0:5  jsx-tag-start   \"<\"\n  0:5-0:6  /               \"/\"\n  0:6-0:7  jsx-identifier  \"a\"\n  0:7-0:8  jsx-tag-end     \">\"\n  0:8-0:8  eof             \"\"\n",
+			"",
+		},
+		{
+			[]string{"-format", "jsonl"},
+			"a\n #",
+			1,
+			`{"file":"<stdin>","type":"identifier","literal":"a","start":0,"end":1,"loc":{"start":{"line":0,"column":0},"end":{"line":0,"column":1}}}
+`,
+			"<stdin>:2:2: Unexpected character '#'\n",
+		},
+		{
+			[]string{"-format", "jsonl", "-recover"},
+			"# a",
+			1,
+			`{"file":"<stdin>","type":"identifier","literal":"a","start":2,"end":3,"loc":{"start":{"line":0,"column":2},"end":{"line":0,"column":3}}}
+{"file":"<stdin>","type":"eof","literal":"","start":3,"end":3,"loc":{"start":{"line":0,"column":3},"end":{"line":0,"column":3}}}
+`,
+			"<stdin>:1:1: Unexpected character '#'\n",
+		},
+		{
+			[]string{"-format", "jsonl", "-recover"},
+			"\x00a",
+			1,
+			`{"file":"<stdin>","type":"identifier","literal":"a","start":1,"end":2,"loc":{"start":{"line":0,"column":1},"end":{"line":0,"column":2}}}
+{"file":"<stdin>","type":"eof","literal":"","start":2,"end":2,"loc":{"start":{"line":0,"column":2},"end":{"line":0,"column":2}}}
+`,
+			"<stdin>:1:1: Unexpected character '\\x00'\n",
+		},
+		{
+			// The octal literal is printed along with its error
+			[]string{"-format", "jsonl", "-recover"},
+			`"use strict"; 010`,
+			1,
+			`{"file":"<stdin>","type":"string","literal":"use strict","start":0,"end":12,"loc":{"start":{"line":0,"column":0},"end":{"line":0,"column":12}}}
+{"file":"<stdin>","type":";","literal":";","start":12,"end":13,"loc":{"start":{"line":0,"column":12},"end":{"line":0,"column":13}}}
+{"file":"<stdin>","type":"numeric","literal":"010","start":14,"end":17,"loc":{"start":{"line":0,"column":14},"end":{"line":0,"column":17}}}
+{"file":"<stdin>","type":"eof","literal":"","start":17,"end":17,"loc":{"start":{"line":0,"column":17},"end":{"line":0,"column":17}}}
+`,
+			"<stdin>:1:15: Octal literal in strict mode\n",
+		},
+		{
+			// The unterminated element is reported once, before the end
+			[]string{"-format", "jsonl", "-jsx", "-recover"},
+			"<a>",
+			1,
+			`{"file":"<stdin>","type":"jsx-tag-start","literal":"<","start":0,"end":1,"loc":{"start":{"line":0,"column":0},"end":{"line":0,"column":1}}}
+{"file":"<stdin>","type":"jsx-identifier","literal":"a","start":1,"end":2,"loc":{"start":{"line":0,"column":1},"end":{"line":0,"column":2}}}
+{"file":"<stdin>","type":"jsx-tag-end","literal":">","start":2,"end":3,"loc":{"start":{"line":0,"column":2},"end":{"line":0,"column":3}}}
+{"file":"<stdin>","type":"eof","literal":"","start":3,"end":3,"loc":{"start":{"line":0,"column":3},"end":{"line":0,"column":3}}}
+`,
+			"<stdin>:1:4: Unterminated JSX contents\n",
+		},
+		{
+			[]string{"-format", "jsonl", "-frame"},
+			"a\n #",
+			1,
+			`{"file":"<stdin>","type":"identifier","literal":"a","start":0,"end":1,"loc":{"start":{"line":0,"column":0},"end":{"line":0,"column":1}}}
+`,
+			"<stdin>:2:2: Unexpected character '#'\n  1 | a\n> 2 |  #\n    |  ^ Unexpected character '#'\n",
+		},
+		{
+			[]string{"-format", "xml"},
+			"",
+			2,
+			"",
+			"jslex: unknown format \"xml\"\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(context.Background(), tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+
+		if status != tt.expectedStatus {
+			t.Fatalf("tests[%d] - status wrong. expected=%d, got=%d",
+				i, tt.expectedStatus, status)
+		}
+
+		if stdout.String() != tt.expectedStdout {
+			t.Fatalf("tests[%d] - stdout wrong. expected=%q, got=%q",
+				i, tt.expectedStdout, stdout.String())
+		}
+
+		if stderr.String() != tt.expectedStderr {
+			t.Fatalf("tests[%d] - stderr wrong. expected=%q, got=%q",
+				i, tt.expectedStderr, stderr.String())
+		}
+	}
+}
+
+func TestRunRecursive(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"b.js":               "b #",
+		"a.ts":               "x!",
+		"lib/c.js":           "c",
+		"lib/c.test.js":      "t",
+		"node_modules/m.js":  "m",
+		".gitignore":         "node_modules\n",
+		"lib/notes.markdown": "#",
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	status := run(context.Background(), []string{"-r", "-format", "jsonl", "-exclude", "*.test.js", "-j", "2", root},
+		strings.NewReader(""), &stdout, &stderr)
+	if status != 1 {
+		t.Fatalf("status wrong. expected=1, got=%d", status)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var tok struct {
+			File string `json:"file"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(line), &tok); err != nil {
+			t.Fatal(err)
+		}
+		rel, _ := filepath.Rel(root, tok.File)
+		got = append(got, filepath.ToSlash(rel)+" "+tok.Type)
+	}
+	expected := "a.ts identifier,a.ts non-null,a.ts eof,b.js identifier,lib/c.js identifier,lib/c.js eof"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("stdout wrong. expected=%q, got=%q", expected, strings.Join(got, ","))
+	}
+
+	expectedStderr := filepath.Join(root, "b.js") + ":1:3: Unexpected character '#'\n"
+	if stderr.String() != expectedStderr {
+		t.Fatalf("stderr wrong. expected=%q, got=%q", expectedStderr, stderr.String())
+	}
+}
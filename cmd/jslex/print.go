@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+type printer interface {
+	begin(name string)
+	token(tok *token.Token)
+	end()
+}
+
+var printers = map[string]func(w io.Writer) printer{
+	"table": newTablePrinter,
+	"jsonl": newJSONLinesPrinter,
+	"acorn": newAcornPrinter,
+}
+
+// tablePrinter prints one token per line as aligned columns of location,
+// type, and literal.
+type tablePrinter struct {
+	w  io.Writer
+	tw *tabwriter.Writer
+}
+
+func newTablePrinter(w io.Writer) printer {
+	return &tablePrinter{w: w}
+}
+
+func (p *tablePrinter) begin(name string) {
+	fmt.Fprintf(p.w, "%s:\n", name)
+	p.tw = tabwriter.NewWriter(p.w, 0, 8, 2, ' ', 0)
+}
+
+func (p *tablePrinter) token(tok *token.Token) {
+	loc := tok.Loc
+	fmt.Fprintf(p.tw, "  %d:%d-%d:%d\t%s\t%q\n",
+		loc.Start.Line, loc.Start.Column, loc.End.Line, loc.End.Column, tok.Type.Label, tok.Literal)
+}
+
+func (p *tablePrinter) end() {
+	p.tw.Flush()
+}
+
+// jsonLinesPrinter prints one JSON object per token.
+type jsonLinesPrinter struct {
+	enc  *json.Encoder
+	name string
+}
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonLocation struct {
+	Start jsonPosition `json:"start"`
+	End   jsonPosition `json:"end"`
+}
+
+type jsonToken struct {
+	File    string       `json:"file"`
+	Type    string       `json:"type"`
+	Literal string       `json:"literal"`
+	Start   int          `json:"start"`
+	End     int          `json:"end"`
+	Loc     jsonLocation `json:"loc"`
+}
+
+func newJSONLinesPrinter(w io.Writer) printer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonLinesPrinter{enc: enc}
+}
+
+func (p *jsonLinesPrinter) begin(name string) {
+	p.name = name
+}
+
+func (p *jsonLinesPrinter) token(tok *token.Token) {
+	p.enc.Encode(jsonToken{
+		File:    p.name,
+		Type:    tok.Type.Label,
+		Literal: tok.Literal,
+		Start:   tok.Start,
+		End:     tok.End,
+		Loc:     makeJSONLocation(tok.Loc, 0),
+	})
+}
+
+func (p *jsonLinesPrinter) end() {}
+
+func makeJSONLocation(loc token.SourceLocation, lineOffset int) jsonLocation {
+	return jsonLocation{
+		Start: jsonPosition{Line: loc.Start.Line + lineOffset, Column: loc.Start.Column},
+		End:   jsonPosition{Line: loc.End.Line + lineOffset, Column: loc.End.Column},
+	}
+}
+
+// acornPrinter prints a JSON array per file in the shape of the tokens and
+// comments produced by Acorn's tokenizer. As in Acorn, lines are one-based
+// and columns zero-based; offsets are in bytes.
+type acornPrinter struct {
+	w          io.Writer
+	n          int
+	inTemplate bool
+}
+
+type acornType struct {
+	Label   string `json:"label"`
+	Keyword string `json:"keyword,omitempty"`
+}
+
+type acornToken struct {
+	Type  acornType    `json:"type"`
+	Value interface{}  `json:"value,omitempty"`
+	Start int          `json:"start"`
+	End   int          `json:"end"`
+	Loc   jsonLocation `json:"loc"`
+}
+
+type acornComment struct {
+	Type  string       `json:"type"`
+	Value string       `json:"value"`
+	Start int          `json:"start"`
+	End   int          `json:"end"`
+	Loc   jsonLocation `json:"loc"`
+}
+
+func newAcornPrinter(w io.Writer) printer {
+	return &acornPrinter{w: w}
+}
+
+func (p *acornPrinter) begin(name string) {
+	p.n = 0
+	p.inTemplate = false
+	fmt.Fprint(p.w, "[")
+}
+
+func (p *acornPrinter) token(tok *token.Token) {
+	var v interface{}
+	switch tok.Type.Label {
+	case token.LineComment, token.BlockComment:
+		typ := "Line"
+		if tok.Type.Label == token.BlockComment {
+			typ = "Block"
+		}
+		v = acornComment{
+			Type:  typ,
+			Value: tok.Literal,
+			Start: tok.Start,
+			End:   tok.End,
+			Loc:   makeJSONLocation(tok.Loc, 1),
+		}
+	default:
+		typ, value := p.acornType(tok)
+		v = acornToken{
+			Type:  typ,
+			Value: value,
+			Start: tok.Start,
+			End:   tok.End,
+			Loc:   makeJSONLocation(tok.Loc, 1),
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if p.n > 0 {
+		fmt.Fprint(p.w, ",")
+	}
+	fmt.Fprintf(p.w, "\n  %s", b)
+	p.n++
+}
+
+func (p *acornPrinter) end() {
+	if p.n > 0 {
+		fmt.Fprint(p.w, "\n")
+	}
+	fmt.Fprint(p.w, "]\n")
+}
+
+// acornLabels maps token labels to Acorn's, where they differ.
+var acornLabels = map[string]string{
+	token.Identifier:         "name",
+	token.Await:              "name",
+	token.Enum:               "name",
+	token.Yield:              "name",
+	token.Numeric:            "num",
+	token.RegExp:             "regexp",
+	token.TemplateStart:      "`",
+	token.TemplateEnd:        "`",
+	token.SubstitutionStart:  "${",
+	token.SubstitutionEnd:    "}",
+	token.Assignment:         "=",
+	token.LT:                 "</>/<=/>=",
+	token.GT:                 "</>/<=/>=",
+	token.LTEq:               "</>/<=/>=",
+	token.GTEq:               "</>/<=/>=",
+	token.Equality:           "==/!=/===/!==",
+	token.Inequality:         "==/!=/===/!==",
+	token.Identity:           "==/!=/===/!==",
+	token.Nonidentity:        "==/!=/===/!==",
+	token.Plus:               "+/-",
+	token.Minus:              "+/-",
+	token.Increment:          "++/--",
+	token.Decrement:          "++/--",
+	token.Bang:               "!/~",
+	token.Tilde:              "!/~",
+	token.LeftShift:          "<</>>/>>>",
+	token.RightShift:         "<</>>/>>>",
+	token.UnsignedRightShift: "<</>>/>>>",
+
+	token.AdditionAssignment:           "_=",
+	token.SubtractionAssignment:        "_=",
+	token.MultiplicationAssignment:     "_=",
+	token.DivisionAssignment:           "_=",
+	token.RemainderAssignment:          "_=",
+	token.ExponentiationAssignment:     "_=",
+	token.LeftShiftAssignment:          "_=",
+	token.RightShiftAssignment:         "_=",
+	token.UnsignedRightShiftAssignment: "_=",
+	token.BitwiseAndAssignment:         "_=",
+	token.BitwiseOrAssignment:          "_=",
+	token.BitwiseXorAssignment:         "_=",
+	token.LogicalAndAssignment:         "_=",
+	token.LogicalOrAssignment:          "_=",
+	token.NullishCoalescingAssignment:  "_=",
+	token.PrivateName:                  "privateId",
+	token.At:                           "@",
+	token.NonNull:                      "!/~",
+
+	token.JSXIdentifier: "jsxName",
+	token.JSXString:     "string",
+	token.JSXText:       "jsxText",
+	token.JSXTagStart:   "jsxTagStart",
+	token.JSXTagEnd:     "jsxTagEnd",
+}
+
+func (p *acornPrinter) acornType(tok *token.Token) (acornType, interface{}) {
+	label := tok.Type.Label
+
+	if label == token.String && p.inTemplate {
+		return acornType{Label: "template"}, tok.Literal
+	}
+	switch label {
+	case token.TemplateStart, token.SubstitutionEnd:
+		p.inTemplate = true
+	case token.TemplateEnd, token.SubstitutionStart:
+		p.inTemplate = false
+	}
+
+	if acornLabel, ok := acornLabels[label]; ok {
+		if acornLabel == "num" {
+			return acornType{Label: acornLabel}, numericValue(tok.Literal)
+		}
+		return acornType{Label: acornLabel}, tok.Literal
+	}
+	if token.IsContextualKeyword(label) {
+		return acornType{Label: "name"}, tok.Literal
+	}
+	if isKeyword(tok) {
+		return acornType{Label: label, Keyword: label}, tok.Literal
+	}
+	switch label {
+	case token.String:
+		return acornType{Label: label}, tok.Literal
+	case token.EOF, token.LParen, token.RParen, token.LBrace, token.RBrace,
+		token.LBracket, token.RBracket, token.Dot, token.Ellipsis, token.Semicolon,
+		token.Colon, token.Comma, token.Question, token.OptionalChaining, token.Arrow:
+		return acornType{Label: label}, nil
+	}
+	return acornType{Label: label}, tok.Literal
+}
+
+func isKeyword(tok *token.Token) bool {
+	return tok.Type == token.LookupIdent(tok.Literal) && tok.Type.Label != token.Identifier
+}
+
+// numericValue returns the value of a numeric literal, falling back to its
+// text if it cannot be represented as a JSON number.
+func numericValue(literal string) interface{} {
+	if len(literal) > 2 && literal[0] == '0' {
+		base := 0
+		switch literal[1] {
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		case 'x', 'X':
+			base = 16
+		}
+		if base != 0 {
+			if n, err := strconv.ParseUint(literal[2:], base, 64); err == nil {
+				return n
+			}
+			return literal
+		}
+	}
+	if len(literal) > 1 && literal[0] == '0' && isLegacyOctal(literal) {
+		if n, err := strconv.ParseUint(literal[1:], 8, 64); err == nil {
+			return n
+		}
+	}
+	if f, err := strconv.ParseFloat(literal, 64); err == nil {
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return literal
+}
+
+func isLegacyOctal(literal string) bool {
+	for i := 1; i < len(literal); i++ {
+		if literal[i] < '0' || literal[i] > '7' {
+			return false
+		}
+	}
+	return true
+}
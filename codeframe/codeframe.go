@@ -0,0 +1,198 @@
+// Package codeframe renders the code around a location in JavaScript
+// source, with line numbers and a marker under the location, the way Babel
+// shows syntax errors:
+//
+//	  1 | let a = 1
+//	> 2 | let s = 'abc
+//	    |         ^ Unterminated string constant
+//	  3 | let t = 2
+package codeframe
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/highlight"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Options configures a code frame.
+type Options struct {
+	// Context is the number of lines shown above and below the marked
+	// lines. Zero means 2, and a negative number none.
+	Context int
+	// TabWidth is the distance between the tab stops tabs are expanded to,
+	// so that the marker lines up with the code. Zero means 4.
+	TabWidth int
+	// Color highlights the code with the highlight.Dark theme and colors
+	// the gutter, the marker, and the message with ANSI escape sequences.
+	Color bool
+}
+
+const (
+	gutterColor = "90"
+	markerColor = "31;1"
+)
+
+// Frame returns a code frame for the span loc of src, which is marked with
+// carets on each of its lines, followed by message on the last one. An
+// empty span is marked with a single caret. Lines and columns are
+// zero-based, and columns count bytes, as in tokens. The options are passed
+// to the lexer to highlight the code.
+func Frame(src string, loc token.SourceLocation, message string, o Options, opts ...lexer.Option) string {
+	if o.Context == 0 {
+		o.Context = 2
+	} else if o.Context < 0 {
+		o.Context = 0
+	}
+	if o.TabWidth <= 0 {
+		o.TabWidth = 4
+	}
+
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	code := lines
+	if o.Color {
+		code = colored(src, opts)
+	}
+
+	start, end := clamp(lines, loc.Start), clamp(lines, loc.End)
+	if end.Line < start.Line || end.Line == start.Line && end.Column < start.Column {
+		end = start
+	}
+	first := max(start.Line-o.Context, 0)
+	last := min(end.Line+o.Context, len(lines)-1)
+	width := len(strconv.Itoa(last + 1))
+
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		marked := i >= start.Line && i <= end.Line
+		if marked {
+			b.WriteString(paint("> ", markerColor, o.Color))
+		} else {
+			b.WriteString("  ")
+		}
+		number := strconv.Itoa(i + 1)
+		b.WriteString(paint(strings.Repeat(" ", width-len(number))+number+" |", gutterColor, o.Color))
+		if lines[i] != "" {
+			b.WriteString(" ")
+			b.WriteString(expand(code[i], o.TabWidth))
+		}
+		b.WriteString("\n")
+		if !marked {
+			continue
+		}
+
+		// The marker, from the start of the span or of the line to the end
+		// of the span or of the line
+		from, to := 0, len(lines[i])
+		if i == start.Line {
+			from = start.Column
+		}
+		if i == end.Line {
+			to = end.Column
+		}
+		if from == to && i != start.Line && i != end.Line {
+			continue
+		}
+		left := column(lines[i], from, o.TabWidth)
+		carets := max(column(lines[i], to, o.TabWidth)-left, 1)
+		b.WriteString(paint("  "+strings.Repeat(" ", width)+" |", gutterColor, o.Color))
+		b.WriteString(" " + strings.Repeat(" ", left))
+		b.WriteString(paint(strings.Repeat("^", carets), markerColor, o.Color))
+		if i == end.Line && message != "" {
+			b.WriteString(" " + paint(message, markerColor, o.Color))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ErrorFrame returns a code frame for err, a syntax error in src, marking
+// the character at the error.
+func ErrorFrame(src string, err *lexer.SyntaxError, o Options, opts ...lexer.Option) string {
+	pos := token.Position{Line: err.Line, Column: err.Column}
+	return Frame(src, token.SourceLocation{Start: pos, End: pos}, err.Message, o, opts...)
+}
+
+// clamp returns p moved into lines, at most just past the end of a line.
+func clamp(lines []string, p token.Position) token.Position {
+	p.Line = min(max(p.Line, 0), len(lines)-1)
+	p.Column = min(max(p.Column, 0), len(lines[p.Line]))
+	return p
+}
+
+// column returns the column at which the byte at offset in line is shown,
+// counting one column per character and expanding tabs.
+func column(line string, offset, tabWidth int) int {
+	col := 0
+	for _, r := range line[:offset] {
+		if r == '\t' {
+			col += tabWidth - col%tabWidth
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// expand replaces the tabs in line with spaces up to the next tab stop,
+// skipping the ANSI escape sequences it may contain.
+func expand(line string, tabWidth int) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			if end := strings.IndexByte(line[i:], 'm'); end >= 0 {
+				b.WriteString(line[i : i+end+1])
+				i += end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		} else {
+			b.WriteString(line[i : i+size])
+			col++
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// colored returns the lines of src highlighted with the Dark theme. The
+// tokens are read past syntax errors, which a code frame usually shows.
+func colored(src string, opts []lexer.Option) []string {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments()}, opts...)
+	var b strings.Builder
+	_ = highlight.ANSI(&b, src, lexer.NewDocument(src, opts...).Tokens(), highlight.Dark)
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		// The carriage return of a colored line is before the reset
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasSuffix(line, "\r\x1b[0m") {
+			line = strings.TrimSuffix(line, "\r\x1b[0m") + "\x1b[0m"
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// paint wraps text in an ANSI escape sequence with the SGR parameters sgr
+// if color is set.
+func paint(text, sgr string, color bool) string {
+	if !color || text == "" {
+		return text
+	}
+	return "\x1b[" + sgr + "m" + text + "\x1b[0m"
+}
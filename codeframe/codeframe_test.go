@@ -0,0 +1,76 @@
+package codeframe
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+func makeLoc(startLine, startColumn, endLine, endColumn int) token.SourceLocation {
+	return token.SourceLocation{
+		Start: token.Position{Line: startLine, Column: startColumn},
+		End:   token.Position{Line: endLine, Column: endColumn},
+	}
+}
+
+func TestFrame(t *testing.T) {
+	src := "let a = 1\nlet s = 'abc\nlet t = 2\n\tif (x) {\n\t\ty()\n\t}\n"
+	tests := []struct {
+		loc      token.SourceLocation
+		message  string
+		options  Options
+		expected string
+	}{
+		{makeLoc(1, 8, 1, 8), "Unterminated string constant", Options{},
+			"  1 | let a = 1\n> 2 | let s = 'abc\n    |         ^ Unterminated string constant\n  3 | let t = 2\n  4 |     if (x) {\n"},
+		{makeLoc(0, 4, 0, 5), "", Options{Context: -1}, "> 1 | let a = 1\n    |     ^\n"},
+		// Spans over lines
+		{makeLoc(3, 5, 5, 2), "here", Options{Context: -1},
+			"> 4 |     if (x) {\n    |         ^^^^\n> 5 |         y()\n    | ^^^^^^^^^^^\n> 6 |     }\n    | ^^^^^ here\n"},
+		// Tabs
+		{makeLoc(4, 2, 4, 5), "call", Options{Context: 1, TabWidth: 2},
+			"  4 |   if (x) {\n> 5 |     y()\n    |     ^^^ call\n  6 |   }\n"},
+		// Positions out of the source are moved into it
+		{makeLoc(9, 0, 9, 3), "end", Options{Context: 1}, "  6 |     }\n> 7 |\n    | ^ end\n"},
+		{makeLoc(0, 20, 0, 30), "", Options{Context: -1}, "> 1 | let a = 1\n    |          ^\n"},
+	}
+
+	for i, tt := range tests {
+		got := Frame(src, tt.loc, tt.message, tt.options)
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - frame wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestFrameGutter(t *testing.T) {
+	src := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	expected := "   8 | h\n   9 | i\n> 10 | j\n     | ^ x\n  11 | k\n"
+	if got := Frame(src, makeLoc(9, 0, 9, 1), "x", Options{}); got != expected {
+		t.Fatalf("frame wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestFrameColor(t *testing.T) {
+	src := "if (a)\r\n\tb = 'c\r\n"
+	expected := "\x1b[31;1m> \x1b[0m\x1b[90m1 |\x1b[0m \x1b[35mif\x1b[0m (a)\n" +
+		"\x1b[90m    |\x1b[0m \x1b[31;1m^^\x1b[0m\n" +
+		"  \x1b[90m2 |\x1b[0m     b \x1b[36m=\x1b[0m 'c\n"
+	if got := Frame(src, makeLoc(0, 0, 0, 2), "", Options{Context: 1, Color: true})[:len(expected)]; got != expected {
+		t.Fatalf("frame wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestErrorFrame(t *testing.T) {
+	src := "let a = 1;\nlet s = 'abc;"
+	_, err := lexer.Tokenize(src)
+	serr, ok := err.(*lexer.SyntaxError)
+	if !ok {
+		t.Fatalf("expected a syntax error, got=%v", err)
+	}
+	expected := "  1 | let a = 1;\n> 2 | let s = 'abc;\n    |         ^ Unterminated string constant\n"
+	if got := ErrorFrame(src, serr, Options{}); got != expected {
+		t.Fatalf("frame wrong. expected=%q, got=%q", expected, got)
+	}
+}
@@ -0,0 +1,286 @@
+// Package deps lists the modules a JavaScript file depends on, and the names
+// it exports, by scanning its tokens without parsing it, in the manner of
+// es-module-lexer.
+package deps
+
+import (
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Kind is the way a module is depended on.
+type Kind int
+
+const (
+	// Import is an import declaration: import x from "m" or import "m".
+	Import Kind = iota
+	// Export is a re-export: export { x } from "m" or export * from "m".
+	Export
+	// DynamicImport is an import("m") call.
+	DynamicImport
+	// Require is a CommonJS require("m") call.
+	Require
+	// ImportMeta is a use of import.meta, which depends on the importing
+	// module itself rather than on a specifier.
+	ImportMeta
+)
+
+var kindNames = [...]string{
+	Import:        "import",
+	Export:        "export",
+	DynamicImport: "dynamic-import",
+	Require:       "require",
+	ImportMeta:    "import-meta",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Attribute is an import attribute, as in with { type: "json" }.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Dependency is a module specifier found in the input.
+type Dependency struct {
+	Kind Kind
+	// Specifier is the text between the quotes of the specifier. It is
+	// empty for ImportMeta, and for a DynamicImport of an expression other
+	// than a string literal.
+	Specifier string
+	// Loc, Start, and End locate the specifier string, quotes included. For
+	// ImportMeta, they locate the import.meta expression along with the
+	// property accessed on it, and for a DynamicImport of an expression,
+	// the import keyword.
+	Loc        token.SourceLocation
+	Start, End int
+	// Property is the property of import.meta accessed, such as "url".
+	Property   string
+	Attributes []Attribute
+}
+
+// Extract returns the dependencies of src in the order they appear. It
+// skips over anything it does not recognize and keeps going after syntax
+// errors, which it returns along with the dependencies. The options are
+// passed to the lexer.
+func Extract(src string, opts ...lexer.Option) ([]Dependency, []error) {
+	toks, errs := tokenize(src, opts)
+	s := &scanner{toks: toks}
+	return s.scan(), errs
+}
+
+// tokenize returns the tokens of src, skipping over syntax errors.
+func tokenize(src string, opts []lexer.Option) ([]token.Token, []error) {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic()}, opts...)
+	l := lexer.New(src, opts...)
+
+	var toks []token.Token
+	var errs []error
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			errs = append(errs, err)
+			if tok == nil {
+				continue
+			}
+		}
+		if tok.Type.Label == token.EOF {
+			return toks, errs
+		}
+		toks = append(toks, *tok)
+	}
+}
+
+// scanner looks for dependencies in a slice of tokens.
+type scanner struct {
+	toks []token.Token
+	pos  int
+	deps []Dependency
+}
+
+// at returns the token n positions after the current one, or an EOF token
+// past the end of the input.
+func (s *scanner) at(n int) *token.Token {
+	if s.pos+n >= len(s.toks) {
+		return &token.Token{Type: token.TokenType{Label: token.EOF}}
+	}
+	return &s.toks[s.pos+n]
+}
+
+func (s *scanner) is(n int, label string) bool {
+	return s.at(n).Type.Label == label
+}
+
+// isName reports whether the token n positions ahead is the identifier or
+// contextual keyword name.
+func (s *scanner) isName(n int, name string) bool {
+	tok := s.at(n)
+	return tok.Literal == name &&
+		(tok.Type.Label == token.Identifier || token.IsContextualKeyword(tok.Type.Label))
+}
+
+func (s *scanner) scan() []Dependency {
+	for ; s.pos < len(s.toks); s.pos++ {
+		if s.pos > 0 && isMemberAccess(&s.toks[s.pos-1]) {
+			// A property name
+			continue
+		}
+		switch {
+		case s.is(0, token.Import):
+			s.scanImport()
+		case s.is(0, token.Export):
+			s.scanExport()
+		case s.isName(0, "require") && s.is(1, token.LParen) && s.is(2, token.String) && s.is(3, token.RParen):
+			s.deps = append(s.deps, makeDependency(Require, s.at(2)))
+		}
+	}
+	return s.deps
+}
+
+func (s *scanner) scanImport() {
+	switch {
+	case s.is(1, token.LParen):
+		s.scanDynamicImport()
+	case s.is(1, token.Dot):
+		if !s.isName(2, "meta") {
+			return
+		}
+		start, end := s.at(0), s.at(2)
+		var property string
+		if s.is(3, token.Dot) && s.is(4, token.Identifier) {
+			end = s.at(4)
+			property = end.Literal
+		}
+		s.deps = append(s.deps, Dependency{
+			Kind:     ImportMeta,
+			Loc:      token.SourceLocation{Start: start.Loc.Start, End: end.Loc.End},
+			Start:    start.Start,
+			End:      end.End,
+			Property: property,
+		})
+	case s.is(1, token.String):
+		// import "m"
+		s.pos++
+		s.addStatic(Import)
+	default:
+		s.pos++
+		if s.skipToFrom() {
+			s.addStatic(Import)
+		}
+	}
+}
+
+func (s *scanner) scanExport() {
+	switch {
+	case s.is(1, token.Star):
+		// export * from "m" or export * as ns from "m"
+		s.pos++
+		if s.isName(1, "as") {
+			s.pos += 2
+		}
+	case s.is(1, token.LBrace) || s.isName(1, "type") && s.is(2, token.LBrace):
+		// export { x } from "m"
+		for !s.is(0, token.RBrace) {
+			if s.pos >= len(s.toks) {
+				return
+			}
+			s.pos++
+		}
+	default:
+		return
+	}
+	if s.isName(1, "from") && s.is(2, token.String) {
+		s.pos += 2
+		s.addStatic(Export)
+	}
+}
+
+// skipToFrom skips the import clause of an import declaration, and
+// reports whether it ends with from followed by a string, which is then
+// the current token.
+func (s *scanner) skipToFrom() bool {
+	for ; s.pos < len(s.toks); s.pos++ {
+		switch s.at(0).Type.Label {
+		case token.Semicolon, token.Import, token.Export:
+			// Not an import declaration after all
+			s.pos--
+			return false
+		}
+		if s.isName(0, "from") && s.is(1, token.String) {
+			s.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// addStatic adds the dependency on the specifier at the current position,
+// along with the import attributes following it.
+func (s *scanner) addStatic(kind Kind) {
+	dep := makeDependency(kind, s.at(0))
+	if s.is(1, token.With) || s.isName(1, "assert") {
+		s.pos++
+		dep.Attributes = s.scanAttributes()
+	}
+	s.deps = append(s.deps, dep)
+}
+
+func (s *scanner) scanDynamicImport() {
+	dep := Dependency{Kind: DynamicImport}
+	s.pos += 2
+	if s.is(0, token.String) && (s.is(1, token.RParen) || s.is(1, token.Comma)) {
+		dep = makeDependency(DynamicImport, s.at(0))
+		// import("m", { with: { type: "json" } })
+		if s.is(1, token.Comma) && s.is(2, token.LBrace) &&
+			(s.is(3, token.With) || s.isName(3, "assert")) && s.is(4, token.Colon) {
+			s.pos += 4
+			dep.Attributes = s.scanAttributes()
+		}
+	} else {
+		keyword := s.at(-2)
+		dep.Loc, dep.Start, dep.End = keyword.Loc, keyword.Start, keyword.End
+		// Look for dependencies in the argument too
+		s.pos--
+	}
+	s.deps = append(s.deps, dep)
+}
+
+// scanAttributes reads the attributes in the braces following the current
+// token, and leaves the closing brace as the current token.
+func (s *scanner) scanAttributes() []Attribute {
+	if !s.is(1, token.LBrace) {
+		return nil
+	}
+	s.pos += 2
+	var attrs []Attribute
+	for ; s.pos < len(s.toks) && !s.is(0, token.RBrace); s.pos++ {
+		key := s.at(0)
+		if s.is(1, token.Colon) && s.is(2, token.String) &&
+			(key.Type.Label == token.String || isWord(key)) {
+			attrs = append(attrs, Attribute{Key: key.Literal, Value: s.at(2).Literal})
+			s.pos += 2
+		}
+	}
+	return attrs
+}
+
+// isWord reports whether tok is an identifier or a keyword, which can both
+// be used as attribute keys.
+func isWord(tok *token.Token) bool {
+	return tok.Type == token.LookupIdent(tok.Literal) || token.IsContextualKeyword(tok.Type.Label)
+}
+
+func makeDependency(kind Kind, tok *token.Token) Dependency {
+	return Dependency{
+		Kind:      kind,
+		Specifier: tok.Literal,
+		Loc:       tok.Loc,
+		Start:     tok.Start,
+		End:       tok.End,
+	}
+}
@@ -0,0 +1,166 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+func makeLoc(line0, col0, line1, col1 int) token.SourceLocation {
+	return token.SourceLocation{
+		Start: token.Position{
+			Line:   line0,
+			Column: col0,
+		},
+		End: token.Position{
+			Line:   line1,
+			Column: col1,
+		},
+	}
+}
+
+func TestExtract(t *testing.T) {
+	input := `import a, { b as c } from "./a.js";
+import "./side-effect.js";
+import * as ns from 'ns';
+import data from "./data.json" with { type: "json" };
+export { x } from "./x.js";
+export * from "./all.js";
+export * as y from "./y.js";
+export const z = 1;
+const m = await import("./lazy.js");
+const n = import(name);
+const fs = require("fs");
+const u = new URL("./file", import.meta.url);
+obj.import("no"); obj.require("no");
+const s = "import x from 'no'";
+const r = /import("no")/;`
+
+	tests := []struct {
+		expectedKind      Kind
+		expectedSpecifier string
+		expectedLocation  token.SourceLocation
+	}{
+		{Import, "./a.js", makeLoc(0, 26, 0, 34)},
+		{Import, "./side-effect.js", makeLoc(1, 7, 1, 25)},
+		{Import, "ns", makeLoc(2, 20, 2, 24)},
+		{Import, "./data.json", makeLoc(3, 17, 3, 30)},
+		{Export, "./x.js", makeLoc(4, 18, 4, 26)},
+		{Export, "./all.js", makeLoc(5, 14, 5, 24)},
+		{Export, "./y.js", makeLoc(6, 19, 6, 27)},
+		{DynamicImport, "./lazy.js", makeLoc(8, 23, 8, 34)},
+		{DynamicImport, "", makeLoc(9, 10, 9, 16)},
+		{Require, "fs", makeLoc(10, 19, 10, 23)},
+		{ImportMeta, "", makeLoc(11, 28, 11, 43)},
+	}
+
+	deps, errs := Extract(input)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(deps) != len(tests) {
+		t.Fatalf("wrong number of dependencies. expected=%d, got=%d (%+v)",
+			len(tests), len(deps), deps)
+	}
+
+	for i, tt := range tests {
+		dep := deps[i]
+		if dep.Kind != tt.expectedKind {
+			t.Fatalf("tests[%d] - kind wrong. expected=%s, got=%s",
+				i, tt.expectedKind, dep.Kind)
+		}
+
+		if dep.Specifier != tt.expectedSpecifier {
+			t.Fatalf("tests[%d] - specifier wrong. expected=%q, got=%q",
+				i, tt.expectedSpecifier, dep.Specifier)
+		}
+
+		if dep.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, dep.Loc)
+		}
+	}
+
+	if deps[10].Property != "url" {
+		t.Fatalf("import.meta property wrong. got=%q", deps[10].Property)
+	}
+	if input[deps[0].Start:deps[0].End] != `"./a.js"` {
+		t.Fatalf("offsets wrong. got=%q", input[deps[0].Start:deps[0].End])
+	}
+}
+
+func TestExtractAttributes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Attribute
+	}{
+		{`import a from "a.json" with { type: "json" }`, []Attribute{{"type", "json"}}},
+		{`import a from "a.json" assert { type: 'json' }`, []Attribute{{"type", "json"}}},
+		{`export { a } from "a" with { "type": "css", if: "x", }`, []Attribute{{"type", "css"}, {"if", "x"}}},
+		{`import("a.json", { with: { type: "json" } })`, []Attribute{{"type", "json"}}},
+		{`import "a"; with (o) {}`, nil},
+	}
+
+	for i, tt := range tests {
+		deps, errs := Extract(tt.input)
+		if len(errs) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, errs)
+		}
+		if len(deps) != 1 {
+			t.Fatalf("tests[%d] - expected one dependency, got=%+v", i, deps)
+		}
+		if !reflect.DeepEqual(deps[0].Attributes, tt.expected) {
+			t.Fatalf("tests[%d] - attributes wrong. expected=%+v, got=%+v",
+				i, tt.expected, deps[0].Attributes)
+		}
+	}
+}
+
+func TestExtractTolerance(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedSpecifiers []string
+		expectedErrors     int
+	}{
+		{"import {\n  a,\n  b,\n} from 'multi-line'", []string{"multi-line"}, 0},
+		{"import x from; import 'next'", []string{"next"}, 0},
+		{"export { a }; export { b } from 'b'", []string{"b"}, 0},
+		{"a # b; import 'after-error'", []string{"after-error"}, 1},
+		{"import 'a'\x00\x00 import 'after-nul'", []string{"a", "after-nul"}, 2},
+		{"import 'a'; /* unterminated", []string{"a"}, 1},
+		{"import type { T } from 'types'", []string{"types"}, 0},
+		{"import(`template`)", []string{""}, 0},
+		{"const { import: i } = o; x.import('no')", nil, 0},
+		{"import", nil, 0},
+		{"export {", nil, 0},
+	}
+
+	for i, tt := range tests {
+		deps, errs := Extract(tt.input)
+		if len(errs) != tt.expectedErrors {
+			t.Fatalf("tests[%d] - wrong number of errors. expected=%d, got=%v",
+				i, tt.expectedErrors, errs)
+		}
+		var specifiers []string
+		for _, dep := range deps {
+			specifiers = append(specifiers, dep.Specifier)
+		}
+		if !reflect.DeepEqual(specifiers, tt.expectedSpecifiers) {
+			t.Fatalf("tests[%d] - specifiers wrong. expected=%q, got=%q",
+				i, tt.expectedSpecifiers, specifiers)
+		}
+	}
+}
+
+func TestExtractUnterminatedJSX(t *testing.T) {
+	// An unterminated JSX element at the end of the input is reported once
+	deps, errs := Extract("import 'a'; <div>", lexer.WithJSX())
+	if len(errs) != 1 {
+		t.Fatalf("wrong number of errors. expected=1, got=%v", errs)
+	}
+	if len(deps) != 1 || deps[0].Specifier != "a" {
+		t.Fatalf("dependencies wrong. got=%+v", deps)
+	}
+}
@@ -0,0 +1,256 @@
+package deps
+
+import (
+	"github.com/morinokami/js-lexer/internal/join"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// ExportName is a name exported by a module.
+type ExportName struct {
+	// Name is the exported name, which is "default" for export default and
+	// empty for export * from "m", which exports the names of "m" other
+	// than default.
+	Name string
+	// Local is the binding exported, or for a re-export the name exported
+	// by Source, which is "*" for export * from "m" and export * as ns from
+	// "m". It is empty for export default of an anonymous expression.
+	Local string
+	// Source is the specifier of the module re-exported from, if any.
+	Source string
+	// Loc, Start, and End locate the exported name, or the default or *
+	// token for exports without a name of their own.
+	Loc        token.SourceLocation
+	Start, End int
+}
+
+// Exports returns the names exported by src in the order they appear. Like
+// Extract, it skips what it does not recognize and keeps going after syntax
+// errors, which it returns along with the exports.
+func Exports(src string, opts ...lexer.Option) ([]ExportName, []error) {
+	toks, errs := tokenize(src, opts)
+	s := &scanner{toks: toks}
+	return s.scanExports(), errs
+}
+
+func (s *scanner) scanExports() []ExportName {
+	var exports []ExportName
+	for ; s.pos < len(s.toks); s.pos++ {
+		if !s.is(0, token.Export) || s.pos > 0 && isMemberAccess(&s.toks[s.pos-1]) {
+			continue
+		}
+		s.pos++
+		exports = append(exports, s.scanExportDeclaration()...)
+	}
+	return exports
+}
+
+// scanExportDeclaration reads the export declaration after the export
+// keyword.
+func (s *scanner) scanExportDeclaration() []ExportName {
+	switch tok := s.at(0); {
+	case tok.Type.Label == token.Default:
+		export := makeExport(tok, "default", "")
+		n := 1
+		if s.isName(n, "async") && s.is(n+1, token.Function) {
+			n++
+		}
+		switch s.at(n).Type.Label {
+		case token.Function:
+			n++
+			if s.is(n, token.Star) {
+				n++
+			}
+		case token.Class:
+			n++
+		default:
+			return []ExportName{export}
+		}
+		if name := s.at(n); isBindingName(name) {
+			export.Local = name.Literal
+			s.pos += n
+		}
+		return []ExportName{export}
+	case tok.Type.Label == token.Var || tok.Type.Label == token.Const || s.isName(0, "let"):
+		return s.scanVariableExports()
+	case tok.Type.Label == token.Function || s.isName(0, "async") && s.is(1, token.Function):
+		for !s.is(0, token.Function) {
+			s.pos++
+		}
+		if s.is(1, token.Star) {
+			s.pos++
+		}
+		return s.exportBindingName(1)
+	case tok.Type.Label == token.Class, tok.Type.Label == token.Enum:
+		return s.exportBindingName(1)
+	case tok.Type.Label == token.Star:
+		export := makeExport(tok, "", "*")
+		if s.isName(1, "as") {
+			s.pos += 2
+			export = makeExport(s.at(0), s.at(0).Literal, "*")
+		}
+		if !s.isName(1, "from") || !s.is(2, token.String) {
+			return nil
+		}
+		export.Source = s.at(2).Literal
+		s.pos += 2
+		return []ExportName{export}
+	case tok.Type.Label == token.LBrace:
+		return s.scanExportSpecifiers()
+	}
+	return nil
+}
+
+// exportBindingName exports the binding named by the token n positions
+// ahead, if it is a name.
+func (s *scanner) exportBindingName(n int) []ExportName {
+	name := s.at(n)
+	if !isBindingName(name) {
+		return nil
+	}
+	s.pos += n
+	return []ExportName{makeExport(name, name.Literal, name.Literal)}
+}
+
+// scanExportSpecifiers reads export { x as y, z } and its optional from
+// clause, the current token being the opening brace.
+func (s *scanner) scanExportSpecifiers() []ExportName {
+	var exports []ExportName
+	for s.pos++; s.pos < len(s.toks) && !s.is(0, token.RBrace); s.pos++ {
+		local := s.at(0)
+		if local.Type.Label == token.Comma || !isWord(local) && local.Type.Label != token.String {
+			continue
+		}
+		name := local
+		if s.isName(1, "as") {
+			name = s.at(2)
+			s.pos += 2
+		}
+		exports = append(exports, makeExport(name, name.Literal, local.Literal))
+	}
+	if s.isName(1, "from") && s.is(2, token.String) {
+		for i := range exports {
+			exports[i].Source = s.at(2).Literal
+		}
+		s.pos += 2
+	}
+	return exports
+}
+
+// scanVariableExports reads the declarators of an exported variable
+// declaration, the current token being var, let, or const.
+func (s *scanner) scanVariableExports() []ExportName {
+	var exports []ExportName
+	for {
+		s.pos++
+		exports = s.scanBindingPattern(exports)
+		if s.is(1, token.Assignment) {
+			s.pos++
+			s.skipExpression()
+		}
+		if !s.is(1, token.Comma) {
+			return exports
+		}
+		s.pos++
+	}
+}
+
+// scanBindingPattern appends the names bound by the binding identifier or
+// pattern at the current position to exports, and leaves the last token of
+// the pattern as the current token.
+func (s *scanner) scanBindingPattern(exports []ExportName) []ExportName {
+	tok := s.at(0)
+	switch tok.Type.Label {
+	case token.LBrace, token.LBracket:
+		closing := token.RBrace
+		if tok.Type.Label == token.LBracket {
+			closing = token.RBracket
+		}
+		for s.pos++; s.pos < len(s.toks) && !s.is(0, closing); s.pos++ {
+			switch {
+			case s.is(0, token.Comma):
+				continue
+			case s.is(0, token.Ellipsis):
+				s.pos++
+			case closing == token.RBrace && s.is(0, token.LBracket):
+				// Computed property name
+				s.skipBalanced()
+				s.pos += 2
+			case closing == token.RBrace && s.is(1, token.Colon):
+				s.pos += 2
+			}
+			exports = s.scanBindingPattern(exports)
+			if s.is(1, token.Assignment) {
+				s.pos++
+				s.skipExpression()
+			}
+		}
+	default:
+		if isBindingName(tok) {
+			exports = append(exports, makeExport(tok, tok.Literal, tok.Literal))
+		}
+	}
+	return exports
+}
+
+// skipExpression skips the expression after the current token, up to a
+// comma, semicolon, or closing bracket outside of it, or a line break where
+// a semicolon is inserted, leaving its last token as the current token.
+func (s *scanner) skipExpression() {
+	for s.pos+1 < len(s.toks) {
+		next := s.at(1)
+		switch next.Type.Label {
+		case token.Comma, token.Semicolon, token.RParen, token.RBracket, token.RBrace:
+			return
+		case token.LParen, token.LBracket, token.LBrace, token.SubstitutionStart:
+			s.pos++
+			s.skipBalanced()
+			continue
+		}
+		if next.Loc.Start.Line > s.at(0).Loc.End.Line && join.SemicolonMayBeInserted(s.at(0), next) {
+			return
+		}
+		s.pos++
+	}
+}
+
+// skipBalanced moves from an opening bracket to its closing bracket, or to
+// the last token.
+func (s *scanner) skipBalanced() {
+	depth := 0
+	for ; s.pos < len(s.toks); s.pos++ {
+		switch s.at(0).Type.Label {
+		case token.LParen, token.LBracket, token.LBrace, token.SubstitutionStart:
+			depth++
+		case token.RParen, token.RBracket, token.RBrace, token.SubstitutionEnd:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+	s.pos = len(s.toks) - 1
+}
+
+func isMemberAccess(tok *token.Token) bool {
+	return tok.Type.Label == token.Dot || tok.Type.Label == token.OptionalChaining
+}
+
+// isBindingName reports whether tok can name a binding.
+func isBindingName(tok *token.Token) bool {
+	switch tok.Type.Label {
+	case token.Identifier, token.Await, token.Yield:
+		return true
+	}
+	return token.IsContextualKeyword(tok.Type.Label)
+}
+
+func makeExport(tok *token.Token, name, local string) ExportName {
+	return ExportName{
+		Name:  name,
+		Local: local,
+		Loc:   tok.Loc,
+		Start: tok.Start,
+		End:   tok.End,
+	}
+}
@@ -0,0 +1,143 @@
+package deps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestExports(t *testing.T) {
+	input := `export const a = 1, b = f(1, 2)
+export let { c, d: e, f = 1, ...g } = obj, [h, , [i], j = [1, 2]] = arr;
+export var k = { l: 1 };
+export function m() {}
+export async function* n() {}
+export class O extends P {}
+export { q, r as s, t as default, u as "string name" };
+export { v as w } from "./v.js";
+export { default, default as x } from "./d.js";
+export * from "./all.js";
+export * as ns from "./ns.js";
+export default function def() {}
+const z = obj.export;`
+
+	tests := []struct {
+		expectedName     string
+		expectedLocal    string
+		expectedSource   string
+		expectedLocation token.SourceLocation
+	}{
+		{"a", "a", "", makeLoc(0, 13, 0, 14)},
+		{"b", "b", "", makeLoc(0, 20, 0, 21)},
+		{"c", "c", "", makeLoc(1, 13, 1, 14)},
+		{"e", "e", "", makeLoc(1, 19, 1, 20)},
+		{"f", "f", "", makeLoc(1, 22, 1, 23)},
+		{"g", "g", "", makeLoc(1, 32, 1, 33)},
+		{"h", "h", "", makeLoc(1, 44, 1, 45)},
+		{"i", "i", "", makeLoc(1, 50, 1, 51)},
+		{"j", "j", "", makeLoc(1, 54, 1, 55)},
+		{"k", "k", "", makeLoc(2, 11, 2, 12)},
+		{"m", "m", "", makeLoc(3, 16, 3, 17)},
+		{"n", "n", "", makeLoc(4, 23, 4, 24)},
+		{"O", "O", "", makeLoc(5, 13, 5, 14)},
+		{"q", "q", "", makeLoc(6, 9, 6, 10)},
+		{"s", "r", "", makeLoc(6, 17, 6, 18)},
+		{"default", "t", "", makeLoc(6, 25, 6, 32)},
+		{"string name", "u", "", makeLoc(6, 39, 6, 52)},
+		{"w", "v", "./v.js", makeLoc(7, 14, 7, 15)},
+		{"default", "default", "./d.js", makeLoc(8, 9, 8, 16)},
+		{"x", "default", "./d.js", makeLoc(8, 29, 8, 30)},
+		{"", "*", "./all.js", makeLoc(9, 7, 9, 8)},
+		{"ns", "*", "./ns.js", makeLoc(10, 12, 10, 14)},
+		{"default", "def", "", makeLoc(11, 7, 11, 14)},
+	}
+
+	exports, errs := Exports(input)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(exports) != len(tests) {
+		t.Fatalf("wrong number of exports. expected=%d, got=%d (%+v)",
+			len(tests), len(exports), exports)
+	}
+
+	for i, tt := range tests {
+		export := exports[i]
+		if export.Name != tt.expectedName {
+			t.Fatalf("tests[%d] - name wrong. expected=%q, got=%q",
+				i, tt.expectedName, export.Name)
+		}
+
+		if export.Local != tt.expectedLocal {
+			t.Fatalf("tests[%d] - local wrong. expected=%q, got=%q",
+				i, tt.expectedLocal, export.Local)
+		}
+
+		if export.Source != tt.expectedSource {
+			t.Fatalf("tests[%d] - source wrong. expected=%q, got=%q",
+				i, tt.expectedSource, export.Source)
+		}
+
+		if export.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, export.Loc)
+		}
+	}
+}
+
+func TestExportsDefault(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedLocal string
+	}{
+		{"export default 42", ""},
+		{"export default function () {}", ""},
+		{"export default class extends A {}", ""},
+		{"export default class A {}", "A"},
+		{"export default async function f() {}", "f"},
+		{"export default function* g() {}", "g"},
+	}
+
+	for i, tt := range tests {
+		exports, errs := Exports(tt.input)
+		if len(errs) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, errs)
+		}
+		if len(exports) != 1 || exports[0].Name != "default" {
+			t.Fatalf("tests[%d] - expected a default export, got=%+v", i, exports)
+		}
+		if exports[0].Local != tt.expectedLocal {
+			t.Fatalf("tests[%d] - local wrong. expected=%q, got=%q",
+				i, tt.expectedLocal, exports[0].Local)
+		}
+	}
+}
+
+func TestExportsInitializers(t *testing.T) {
+	// The names after an initializer are exported only if the declaration
+	// goes on.
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"export const a = 1\nlet b = 2, c = 3", []string{"a"}},
+		{"export const a = b\n(c), d = 1", []string{"a", "d"}},
+		{"export let a = `${b, c}`, d", []string{"a", "d"}},
+		{"export let a = `${`${b, c}`}` + f(d, e), g", []string{"a", "g"}},
+	}
+
+	for i, tt := range tests {
+		exports, errs := Exports(tt.input)
+		if len(errs) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, errs)
+		}
+		var names []string
+		for _, export := range exports {
+			names = append(names, export.Name)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+			t.Fatalf("tests[%d] - names wrong. expected=%q, got=%q", i, tt.expected, names)
+		}
+	}
+}
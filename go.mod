@@ -1,3 +1,3 @@
 module github.com/morinokami/js-lexer
 
-go 1.15
+go 1.23
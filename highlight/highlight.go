@@ -0,0 +1,191 @@
+// Package highlight renders JavaScript source as HTML or as text colored
+// with ANSI escape sequences, classifying its tokens with the lexer.
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Category is the kind of a token for highlighting.
+type Category int
+
+const (
+	// Plain is the category of whitespace, JSX text, and input the lexer
+	// could not tokenize.
+	Plain Category = iota
+	Keyword
+	Identifier
+	String
+	Number
+	RegExp
+	Comment
+	Punctuation
+	Operator
+	// Tag is the category of the names of JSX elements and attributes.
+	Tag
+)
+
+var categoryNames = [...]string{
+	Plain:       "plain",
+	Keyword:     "keyword",
+	Identifier:  "identifier",
+	String:      "string",
+	Number:      "number",
+	RegExp:      "regexp",
+	Comment:     "comment",
+	Punctuation: "punctuation",
+	Operator:    "operator",
+	Tag:         "tag",
+}
+
+// String returns the name of c, which is also its CSS class in HTML.
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+	return "unknown"
+}
+
+// Classify returns the category of tok.
+func Classify(tok token.Token) Category {
+	label := tok.Type.Label
+	switch label {
+	case token.Identifier, token.PrivateName:
+		return Identifier
+	case token.String, token.TemplateStart, token.TemplateEnd, token.JSXString:
+		return String
+	case token.Numeric:
+		return Number
+	case token.RegExp:
+		return RegExp
+	case token.LineComment, token.BlockComment:
+		return Comment
+	case token.JSXIdentifier:
+		return Tag
+	case token.JSXText, token.EOF:
+		return Plain
+	case token.LParen, token.RParen, token.LBrace, token.RBrace, token.LBracket,
+		token.RBracket, token.Dot, token.Ellipsis, token.Semicolon, token.Colon,
+		token.Comma, token.Question, token.OptionalChaining, token.At, token.Arrow,
+		token.SubstitutionStart, token.SubstitutionEnd, token.JSXTagStart, token.JSXTagEnd:
+		return Punctuation
+	}
+	if token.IsKeyword(label) {
+		return Keyword
+	}
+	if token.IsContextualKeyword(label) {
+		return Identifier
+	}
+	return Operator
+}
+
+// Tokenize returns the tokens of src to highlight, comments included. It
+// keeps going after syntax errors, leaving the text the lexer skips to be
+// rendered as plain text.
+func Tokenize(src string, opts ...lexer.Option) []token.Token {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments()}, opts...)
+	return lexer.NewDocument(src, opts...).Tokens()
+}
+
+// render calls span for each token of src in toks and for the text between
+// them, which together make up src. Tokens overlapping the previous one
+// are skipped.
+func render(src string, toks []token.Token, span func(text string, c Category) error) error {
+	pos := 0
+	for _, tok := range toks {
+		if tok.Start < pos || tok.End > len(src) || tok.Start == tok.End {
+			continue
+		}
+		if tok.Start > pos {
+			if err := span(src[pos:tok.Start], Plain); err != nil {
+				return err
+			}
+		}
+		if err := span(src[tok.Start:tok.End], Classify(tok)); err != nil {
+			return err
+		}
+		pos = tok.End
+	}
+	if pos < len(src) {
+		return span(src[pos:], Plain)
+	}
+	return nil
+}
+
+// HTML writes src to w as HTML, with the text of each token in toks
+// wrapped in a span whose class is prefix followed by the name of its
+// category, as in <span class="js-keyword">. Plain text is only escaped.
+// The output is meant to be placed in a <pre> element.
+func HTML(w io.Writer, src string, toks []token.Token, prefix string) error {
+	return render(src, toks, func(text string, c Category) error {
+		var err error
+		if c == Plain {
+			_, err = io.WriteString(w, html.EscapeString(text))
+		} else {
+			_, err = fmt.Fprintf(w, `<span class="%s%s">%s</span>`, prefix, c, html.EscapeString(text))
+		}
+		return err
+	})
+}
+
+// Theme maps categories to the parameters of the ANSI SGR escape sequences
+// that color them, such as "1;34" for bold blue. Categories without an
+// entry are not colored.
+type Theme map[Category]string
+
+var (
+	// Dark is a theme for terminals with a dark background.
+	Dark = Theme{
+		Keyword:  "35",
+		String:   "32",
+		Number:   "33",
+		RegExp:   "31",
+		Comment:  "90",
+		Operator: "36",
+		Tag:      "34",
+	}
+	// Light is a theme for terminals with a light background.
+	Light = Theme{
+		Keyword:  "1;35",
+		String:   "32",
+		Number:   "34",
+		RegExp:   "31",
+		Comment:  "2;3",
+		Operator: "36",
+		Tag:      "1;34",
+	}
+)
+
+// ANSI writes src to w with the text of each token in toks colored as
+// theme specifies. Colors are reset at the end of each line, so that the
+// output can be cut into lines.
+func ANSI(w io.Writer, src string, toks []token.Token, theme Theme) error {
+	return render(src, toks, func(text string, c Category) error {
+		sgr, ok := theme[c]
+		if !ok {
+			_, err := io.WriteString(w, text)
+			return err
+		}
+		lines := strings.SplitAfter(text, "\n")
+		for _, line := range lines {
+			content := strings.TrimSuffix(line, "\n")
+			if content != "" {
+				if _, err := fmt.Fprintf(w, "\x1b[%sm%s\x1b[0m", sgr, content); err != nil {
+					return err
+				}
+			}
+			if len(content) < len(line) {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
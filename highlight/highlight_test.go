@@ -0,0 +1,102 @@
+package highlight
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"if (a < 1) {\n\treturn 'x&y'; // done\n}",
+			`<span class="js-keyword">if</span> <span class="js-punctuation">(</span>` +
+				`<span class="js-identifier">a</span> <span class="js-operator">&lt;</span> ` +
+				`<span class="js-number">1</span><span class="js-punctuation">)</span> ` +
+				`<span class="js-punctuation">{</span>` + "\n\t" +
+				`<span class="js-keyword">return</span> <span class="js-string">&#39;x&amp;y&#39;</span>` +
+				`<span class="js-punctuation">;</span> <span class="js-comment">// done</span>` + "\n" +
+				`<span class="js-punctuation">}</span>`,
+		},
+		{
+			"`a${b}`, /re/g",
+			`<span class="js-string">` + "`" + `</span><span class="js-string">a</span>` +
+				`<span class="js-punctuation">${</span><span class="js-identifier">b</span>` +
+				`<span class="js-punctuation">}</span><span class="js-string">` + "`" + `</span>` +
+				`<span class="js-punctuation">,</span> ` +
+				`<span class="js-regexp">/re/g</span>`,
+		},
+		{
+			// What the lexer cannot read is plain text, and the rest of the
+			// input is highlighted
+			"x = #<y>",
+			`<span class="js-identifier">x</span> <span class="js-operator">=</span> #` +
+				`<span class="js-operator">&lt;</span><span class="js-identifier">y</span>` +
+				`<span class="js-operator">&gt;</span>`,
+		},
+		{
+			"'a\nb",
+			"&#39;a\n" + `<span class="js-identifier">b</span>`,
+		},
+	}
+
+	for i, tt := range tests {
+		var b strings.Builder
+		if err := HTML(&b, tt.input, Tokenize(tt.input), "js-"); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if b.String() != tt.expected {
+			t.Fatalf("tests[%d] - output wrong. expected=%q, got=%q", i, tt.expected, b.String())
+		}
+	}
+}
+
+func TestANSI(t *testing.T) {
+	input := "const s = `a\nb` /* c\r\nd */"
+	expected := "\x1b[35mconst\x1b[0m s \x1b[36m=\x1b[0m \x1b[32m`\x1b[0m\x1b[32ma\x1b[0m\n\x1b[32mb\x1b[0m\x1b[32m`\x1b[0m " +
+		"\x1b[90m/* c\r\x1b[0m\n\x1b[90md */\x1b[0m"
+
+	var b strings.Builder
+	if err := ANSI(&b, input, Tokenize(input), Dark); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if b.String() != expected {
+		t.Fatalf("output wrong. expected=%q, got=%q", expected, b.String())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		"\ufeff#!/usr/bin/env node\r\nconst a = <div class=\"x\">{b} &amp; c</div>;\n",
+		"function f(a, b) {\n  return a ** b; /* unterminated",
+		"  \t\v\f x y  ",
+	}
+	tags := regexp.MustCompile(`<[^>]*>`)
+	escapes := regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	for i, input := range inputs {
+		toks := Tokenize(input, lexer.WithJSX())
+
+		var h strings.Builder
+		if err := HTML(&h, input, toks, ""); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got := html.UnescapeString(tags.ReplaceAllString(h.String(), "")); got != input {
+			t.Fatalf("tests[%d] - HTML text wrong. expected=%q, got=%q", i, input, got)
+		}
+
+		var a strings.Builder
+		if err := ANSI(&a, input, toks, Light); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got := escapes.ReplaceAllString(a.String(), ""); got != input {
+			t.Fatalf("tests[%d] - ANSI text wrong. expected=%q, got=%q", i, input, got)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package join decides how tokens can be written next to each other, or on
+// separate lines, without changing how they are read.
+package join
+
+import (
+	"strings"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// HasLineBreak reports whether text, such as the text between two tokens,
+// contains a line terminator.
+func HasLineBreak(text string) bool {
+	return strings.ContainsAny(text, "\n\r\u2028\u2029")
+}
+
+// SemicolonMayBeInserted reports whether a semicolon is inserted, or may
+// be, at a line break between prev and next, so that the line break has to
+// be kept.
+func SemicolonMayBeInserted(prev, next *token.Token) bool {
+	if isRestricted(prev) {
+		// No line break is allowed after return and the like
+		return next.Type.Label != token.Semicolon && next.Type.Label != token.RBrace
+	}
+	return EndsExpression(prev.Type.Label) && !continuesExpression(next.Type.Label)
+}
+
+// isRestricted reports whether tok is followed by a semicolon when a line
+// break follows it.
+func isRestricted(tok *token.Token) bool {
+	switch tok.Type.Label {
+	case token.Return, token.Break, token.Continue, token.Throw, token.Yield:
+		return true
+	case token.Identifier:
+		// async followed by a line break does not start an async function
+		return tok.Literal == "async" || tok.Literal == "yield"
+	}
+	return false
+}
+
+// EndsExpression reports whether a token labeled label can be the last
+// token of a statement without a semicolon.
+func EndsExpression(label string) bool {
+	switch label {
+	case token.Identifier, token.Numeric, token.String, token.RegExp, token.TemplateEnd,
+		token.RParen, token.RBracket, token.RBrace, token.Increment, token.Decrement,
+		token.NonNull, token.JSXTagEnd, token.This, token.Super, token.Null,
+		token.True, token.False, token.Debugger:
+		return true
+	}
+	return token.IsContextualKeyword(label)
+}
+
+// continuesExpression reports whether a token labeled label, following a
+// line break after the end of an expression, continues the expression
+// rather than starting a statement, so that no semicolon is inserted
+// before it.
+func continuesExpression(label string) bool {
+	switch label {
+	case token.RParen, token.RBracket, token.RBrace, token.Comma, token.Semicolon,
+		token.Dot, token.OptionalChaining, token.Colon, token.Question, token.Arrow,
+		token.LParen, token.LBracket, token.TemplateStart, token.In, token.Instanceof:
+		return true
+	case token.Increment, token.Decrement, token.Bang, token.Tilde, token.At,
+		token.Ellipsis, token.LBrace, token.NonNull, token.PrivateName, token.EOF:
+		return false
+	}
+	return IsOperator(label)
+}
+
+// IsOperator reports whether label is the label of a punctuator other than
+// a keyword, a literal, or a name.
+func IsOperator(label string) bool {
+	switch label {
+	case token.Identifier, token.PrivateName, token.Numeric, token.String, token.RegExp,
+		token.TemplateStart, token.TemplateEnd, token.SubstitutionStart, token.SubstitutionEnd,
+		token.LineComment, token.BlockComment, token.EOF, token.JSXIdentifier, token.JSXString,
+		token.JSXText, token.JSXTagStart, token.JSXTagEnd:
+		return false
+	}
+	return !token.IsKeyword(label) && !token.IsContextualKeyword(label)
+}
+
+// NeedsSpace reports whether prev and next, two tokens of src, would be
+// read differently if written next to each other.
+func NeedsSpace(src string, prev, next *token.Token) bool {
+	a := src[prev.End-1]
+	b := src[next.Start]
+	switch {
+	case isIdentChar(a) && isIdentChar(b):
+		return true
+	case prev.Type.Label == token.RegExp && isIdentChar(b):
+		// The flags of the regular expression would go on
+		return true
+	case a == '+' && b == '+', a == '-' && b == '-':
+		// a + +b, a - --b
+		return true
+	case a == '/' && (b == '/' || b == '*'):
+		// A comment would start
+		return true
+	case a == '<' && b == '!', prev.Type.Label == token.Decrement && b == '>':
+		// HTML-like comments
+		return true
+	case a == '?' && b == '.':
+		// a ? .5 : b
+		return true
+	case prev.Type.Label == token.Numeric && b == '.' && isDecimalInteger(src[prev.Start:prev.End]):
+		// 1 .toString()
+		return true
+	case prev.Type.Label == token.JSXString && next.Type.Label == token.JSXIdentifier:
+		return true
+	}
+	return false
+}
+
+func isIdentChar(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' ||
+		ch == '_' || ch == '$' || ch == '\\' || ch >= 0x80
+}
+
+// isDecimalInteger reports whether the numeric literal text is an integer
+// that a following '.' would become the decimal point of.
+func isDecimalInteger(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if (text[i] < '0' || text[i] > '9') && text[i] != '_' {
+			return false
+		}
+	}
+	return true
+}
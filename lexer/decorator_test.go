@@ -0,0 +1,102 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestDecorator(t *testing.T) {
+	input := `@Component({ selector: "app" })
+export @a.b class C {
+  @observable accessor count = 0;
+  @(memo()) static #cache;
+  @bound method(@Inject(TOKEN) param) {}
+}
+let accessor = 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		// Class, with a call
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "Component"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.Identifier), "selector"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.String), "app"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.RParen), ")"},
+		// Class after export, with a member expression
+		{makeTT(token.Export), "export"},
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.Dot), "."},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.Class), "class"},
+		{makeTT(token.Identifier), "C"},
+		{makeTT(token.LBrace), "{"},
+		// Auto-accessor field
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "observable"},
+		{makeTT(token.Accessor), "accessor"},
+		{makeTT(token.Identifier), "count"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Numeric), "0"},
+		{makeTT(token.Semicolon), ";"},
+		// Private field, with a parenthesized expression
+		{makeTT(token.At), "@"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.Identifier), "memo"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.Identifier), "static"},
+		{makeTT(token.PrivateName), "cache"},
+		{makeTT(token.Semicolon), ";"},
+		// Method and parameter
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "bound"},
+		{makeTT(token.Identifier), "method"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "Inject"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.Identifier), "TOKEN"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.Identifier), "param"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.RBrace), "}"},
+		// accessor is a contextual keyword
+		{makeTT(token.Identifier), "let"},
+		{makeTT(token.Accessor), "accessor"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Numeric), "1"},
+		{makeTT(token.EOF), ""},
+	}
+
+	for _, dialect := range []Dialect{JavaScript, TypeScript} {
+		l := New(input, WithDialect(dialect))
+
+		for i, tt := range tests {
+			tok, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+			}
+
+			if tok.Type != tt.expectedType {
+				t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+					i, tt.expectedType, tok.Type)
+			}
+
+			if tok.Literal != tt.expectedLiteral {
+				t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+					i, tt.expectedLiteral, tok.Literal)
+			}
+		}
+	}
+}
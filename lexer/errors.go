@@ -0,0 +1,47 @@
+package lexer
+
+import (
+	"fmt"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// SyntaxError describes input that could not be tokenized. Line and Column
+// are zero-based, like the positions in token.SourceLocation, and Offset is
+// the byte offset of that position in the input. Pos is the position in a
+// FileSet, if the lexer was given the file of the input.
+type SyntaxError struct {
+	Message string
+	Line    int
+	Column  int
+	Offset  int
+	Pos     token.Pos
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("SyntaxError: %s (%d:%d)", e.Message, e.Line, e.Column)
+}
+
+func (l *Lexer) errorf(line, column int, format string, a ...interface{}) error {
+	offset := 0
+	for ln := 0; ln < line && offset < len(l.input); offset++ {
+		if l.input[offset] == '\n' {
+			ln++
+		}
+	}
+	offset += column
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+
+	err := &SyntaxError{
+		Message: fmt.Sprintf(format, a...),
+		Line:    line,
+		Column:  column,
+		Offset:  offset,
+	}
+	if l.file != nil {
+		err.Pos = l.file.Pos(offset)
+	}
+	return err
+}
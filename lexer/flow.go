@@ -0,0 +1,75 @@
+package lexer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// skipFlowCommentDelimiter skips the delimiters of Flow comment types,
+// inside which the lexer reads tokens as if there were no comment, and
+// reports whether there was one at the current position. /*:: and
+// /*flow-include open a comment containing any code, /*: one starting with
+// a type annotation whose ':' is a token, and */ closes either. Spaces and
+// tabs may come after the /*, as in /* : number */.
+func (l *Lexer) skipFlowCommentDelimiter() bool {
+	if l.dialect != Flow {
+		return false
+	}
+
+	if l.flowComment {
+		if l.ch != '*' || l.peekChar(0) != '/' {
+			return false
+		}
+		l.readChar()
+		l.readChar()
+		l.flowComment = false
+		return true
+	}
+
+	if l.ch != '/' || l.peekChar(0) != '*' {
+		return false
+	}
+	rest := strings.TrimLeft(l.input[l.position+2:], " \t")
+	n := len(l.input) - len(rest) - l.position
+	switch {
+	case strings.HasPrefix(rest, "::"):
+		n += len("::")
+	case strings.HasPrefix(rest, "flow-include"):
+		n += len("flow-include")
+	case strings.HasPrefix(rest, ":"):
+		// The ':' is read as a token
+	default:
+		return false
+	}
+	l.flowComment = true
+	l.flowCommentPos = token.Position{Line: l.line, Column: l.column - 1}
+	for i := 0; i < n; i++ {
+		l.readChar()
+	}
+	return true
+}
+
+var flowPragma = regexp.MustCompile(`@flow\b`)
+
+// HasFlowPragma reports whether src is marked as a Flow file by a @flow
+// pragma, as in // @flow or /* @flow strict */, in one of the comments
+// before its first token.
+func HasFlowPragma(src string) bool {
+	l := New(src, WithComments())
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return false
+		}
+		switch tok.Type.Label {
+		case token.LineComment, token.BlockComment:
+			if flowPragma.MatchString(tok.Literal) {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
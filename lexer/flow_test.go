@@ -0,0 +1,152 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestFlowComment(t *testing.T) {
+	input := `/*:: type T = { a: string }; */
+function f(x /*: T */) /*: number */ { return 1 } /* plain */
+/*flow-include import type U from "u" */`
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLocation token.SourceLocation
+	}{
+		{makeTT(token.Identifier), "type", makeLoc(0, 5, 0, 9)},
+		{makeTT(token.Identifier), "T", makeLoc(0, 10, 0, 11)},
+		{makeTT(token.Assignment), "=", makeLoc(0, 12, 0, 13)},
+		{makeTT(token.LBrace), "{", makeLoc(0, 14, 0, 15)},
+		{makeTT(token.Identifier), "a", makeLoc(0, 16, 0, 17)},
+		{makeTT(token.Colon), ":", makeLoc(0, 17, 0, 18)},
+		{makeTT(token.Identifier), "string", makeLoc(0, 19, 0, 25)},
+		{makeTT(token.RBrace), "}", makeLoc(0, 26, 0, 27)},
+		{makeTT(token.Semicolon), ";", makeLoc(0, 27, 0, 28)},
+		{makeTT(token.Function), "function", makeLoc(1, 0, 1, 8)},
+		{makeTT(token.Identifier), "f", makeLoc(1, 9, 1, 10)},
+		{makeTT(token.LParen), "(", makeLoc(1, 10, 1, 11)},
+		{makeTT(token.Identifier), "x", makeLoc(1, 11, 1, 12)},
+		{makeTT(token.Colon), ":", makeLoc(1, 15, 1, 16)},
+		{makeTT(token.Identifier), "T", makeLoc(1, 17, 1, 18)},
+		{makeTT(token.RParen), ")", makeLoc(1, 21, 1, 22)},
+		{makeTT(token.Colon), ":", makeLoc(1, 25, 1, 26)},
+		{makeTT(token.Identifier), "number", makeLoc(1, 27, 1, 33)},
+		{makeTT(token.LBrace), "{", makeLoc(1, 37, 1, 38)},
+		{makeTT(token.Return), "return", makeLoc(1, 39, 1, 45)},
+		{makeTT(token.Numeric), "1", makeLoc(1, 46, 1, 47)},
+		{makeTT(token.RBrace), "}", makeLoc(1, 48, 1, 49)},
+		{makeTT(token.Import), "import", makeLoc(2, 15, 2, 21)},
+		{makeTT(token.Identifier), "type", makeLoc(2, 22, 2, 26)},
+		{makeTT(token.Identifier), "U", makeLoc(2, 27, 2, 28)},
+		{makeTT(token.Identifier), "from", makeLoc(2, 29, 2, 33)},
+		{makeTT(token.String), "u", makeLoc(2, 34, 2, 37)},
+		{makeTT(token.EOF), "", makeLoc(2, 40, 2, 40)},
+	}
+
+	l := New(input, WithDialect(Flow))
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, tok.Loc)
+		}
+	}
+
+	// Spaces and tabs may follow the /*.
+	toks, err := Tokenize("/* :: type A = B; */ x /*\t: number */", WithDialect(Flow))
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	var literals []string
+	for _, tok := range toks {
+		literals = append(literals, tok.Literal)
+	}
+	if got := strings.Join(literals, " "); got != "type A = B ; x : number" {
+		t.Fatalf("tokens wrong. expected=%q, got=%q", "type A = B ; x : number", got)
+	}
+
+	// Other dialects skip them as comments.
+	toks, err = Tokenize("/*: T */ x")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if len(toks) != 1 || toks[0].Literal != "x" {
+		t.Fatalf("tokens wrong. got=%+v", toks)
+	}
+
+	_, err = Tokenize("a /*:: b", WithDialect(Flow))
+	if err == nil || err.Error() != "SyntaxError: Unterminated flow-comment (0:2)" {
+		t.Fatalf("error wrong. got=%v", err)
+	}
+
+	// The error is reported once, and then the input ends
+	l = New("a /*:: b", WithDialect(Flow))
+	var labels []string
+	for range 5 {
+		tok, err := l.NextToken()
+		if err != nil {
+			labels = append(labels, "error")
+			continue
+		}
+		labels = append(labels, tok.Type.Label)
+		if tok.Type.Label == token.EOF {
+			break
+		}
+	}
+	if got := strings.Join(labels, " "); got != "identifier identifier error eof" {
+		t.Fatalf("recovery wrong. expected=%q, got=%q", "identifier identifier error eof", got)
+	}
+
+	// Restoring a snapshot inside a comment keeps its position
+	l = New("a\n  /*:: b", WithDialect(Flow))
+	l.NextToken()
+	l.NextToken()
+	s := l.Snapshot()
+	l = New("a\n  /*:: b", WithDialect(Flow))
+	l.Restore(s)
+	_, err = l.NextToken()
+	if err == nil || err.Error() != "SyntaxError: Unterminated flow-comment (1:2)" {
+		t.Fatalf("error after restore wrong. got=%v", err)
+	}
+}
+
+func TestHasFlowPragma(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"// @flow\nlet a", true},
+		{"/**\n * @flow strict\n */\nlet a", true},
+		{"#!/usr/bin/env node\n// @flow", true},
+		{"// @noflow\nlet a", false},
+		{"// @flowtype\nlet a", false},
+		{"let a // @flow", false},
+		{"", false},
+	}
+
+	for i, tt := range tests {
+		if got := HasFlowPragma(tt.input); got != tt.expected {
+			t.Fatalf("tests[%d] - HasFlowPragma(%q) wrong. expected=%t, got=%t",
+				i, tt.input, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package lexer
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// checkpointInterval is the number of tokens between the lexer states a
+// Document keeps to restart lexing from.
+const checkpointInterval = 32
+
+// Edit replaces the bytes of the input from Start to End with Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Change describes the tokens an edit changed: the tokens from Start to
+// OldEnd of the old token list were replaced by those from Start to NewEnd
+// of the new one. The tokens after them are the same, moved by the edit.
+type Change struct {
+	Start  int
+	OldEnd int
+	NewEnd int
+}
+
+// checkpoint is the state of the lexer before it read the token at index.
+type checkpoint struct {
+	index int
+	state Snapshot
+}
+
+// Document holds the tokens of an input, not including the EOF token, and
+// updates them as the input is edited, lexing again only the tokens around
+// each edit. Like jslex -recover, it keeps lexing after syntax errors,
+// which it collects.
+type Document struct {
+	src        string
+	opts       []Option
+	sourceType SourceType
+	toks       []token.Token
+	errs       []*SyntaxError
+	// Number of tokens read before each error was reported. An error may
+	// be reported after the tokens at its offset, as that of an octal
+	// escape sequence in a directive prologue is at "use strict".
+	errsAt      []int
+	checkpoints []checkpoint
+}
+
+// NewDocument lexes src with the given options.
+func NewDocument(src string, opts ...Option) *Document {
+	d := &Document{opts: opts}
+	d.reset(src)
+	return d
+}
+
+// Source returns the current input.
+func (d *Document) Source() string {
+	return d.src
+}
+
+// Tokens returns the tokens of the current input, which must not be
+// modified.
+func (d *Document) Tokens() []token.Token {
+	return d.toks
+}
+
+// Errors returns the syntax errors in the current input, in order.
+func (d *Document) Errors() []*SyntaxError {
+	return d.errs
+}
+
+func (d *Document) reset(src string) {
+	l := New(src, d.opts...)
+	d.src = src
+	d.sourceType = l.sourceType
+	d.toks, d.errs, d.errsAt, d.checkpoints = nil, nil, nil, nil
+	d.lex(l, nil)
+}
+
+// lex appends the tokens read by l to d.toks, until EOF or until resync
+// reports that the rest of the tokens are known.
+func (d *Document) lex(l *Lexer, resync func(l *Lexer, tok *token.Token) bool) {
+	for n := 0; ; n++ {
+		if n%checkpointInterval == 0 {
+			d.checkpoints = append(d.checkpoints, checkpoint{index: len(d.toks), state: l.Snapshot()})
+		}
+
+		var tok *token.Token
+		for {
+			var err error
+			tok, err = l.NextToken()
+			if err == nil {
+				break
+			}
+			var serr *SyntaxError
+			if errors.As(err, &serr) {
+				d.errs = append(d.errs, serr)
+				d.errsAt = append(d.errsAt, len(d.toks))
+			}
+			if tok != nil {
+				// The token breaks a rule of strict mode
+				break
+			}
+		}
+		if tok.Type.Label == token.EOF {
+			return
+		}
+		d.toks = append(d.toks, *tok)
+		if resync != nil && resync(l, tok) {
+			return
+		}
+	}
+}
+
+// Apply edits the input and lexes it again from the last checkpoint before
+// the edit until the tokens are the same as before, returning the tokens
+// that changed.
+func (d *Document) Apply(e Edit) (Change, error) {
+	if e.Start < 0 || e.End < e.Start || e.End > len(d.src) {
+		return Change{}, errors.New("lexer: edit out of range")
+	}
+	src := d.src[:e.Start] + e.Text + d.src[e.End:]
+
+	l := New(src, d.opts...)
+	if l.sourceType != d.sourceType {
+		// The edit changed the detected source type
+		oldEnd := len(d.toks)
+		d.reset(src)
+		return Change{Start: 0, OldEnd: oldEnd, NewEnd: len(d.toks)}, nil
+	}
+
+	// Restart before the token before the first token the edit touches, as
+	// the lexer may have looked ahead past the end of that one
+	first := sort.Search(len(d.toks), func(i int) bool { return d.toks[i].End >= e.Start })
+	c := sort.Search(len(d.checkpoints), func(i int) bool { return d.checkpoints[i].index >= first }) - 1
+	if c < 0 {
+		c = 0
+	}
+	restart := d.checkpoints[c]
+	l.Restore(restart.state)
+	if l.position == e.Start {
+		// The edit starts right at the restart point, which the first
+		// checkpoint is at
+		l.ch = 0
+		if l.position < len(src) {
+			l.ch = src[l.position]
+		}
+	}
+
+	s := newShift(d.src, e)
+	oldToks, oldErrs, oldErrsAt, oldCheckpoints := d.toks, d.errs, d.errsAt, d.checkpoints
+	d.src = src
+	d.toks = slices.Clip(oldToks[:restart.index])
+	d.checkpoints = slices.Clip(oldCheckpoints[:c])
+	// Keep the errors reported before the restart point, whatever their
+	// offsets
+	kept := sort.SearchInts(oldErrsAt, restart.index)
+	d.errs, d.errsAt = slices.Clip(oldErrs[:kept]), slices.Clip(oldErrsAt[:kept])
+
+	// The old token the lexer is back in step with, and its checkpoint
+	resynced, next := len(oldToks), len(oldCheckpoints)
+	d.lex(l, func(l *Lexer, tok *token.Token) bool {
+		if tok.Start < e.Start+len(e.Text) {
+			return false
+		}
+		m := sort.Search(len(oldToks), func(i int) bool { return oldToks[i].Start >= tok.Start-s.delta })
+		if m == len(oldToks) || oldToks[m].Start != tok.Start-s.delta {
+			return false
+		}
+		n := sort.Search(len(oldCheckpoints), func(i int) bool { return oldCheckpoints[i].index >= m+1 })
+		if n == len(oldCheckpoints) || oldCheckpoints[n].index != m+1 ||
+			!sameState(l.Snapshot(), s.snapshot(oldCheckpoints[n].state)) {
+			return false
+		}
+		resynced, next = m, n
+		return true
+	})
+
+	// Move the tokens, checkpoints, and errors after the resynchronized
+	// token into place
+	newEnd := len(d.toks)
+	if resynced < len(oldToks) {
+		for _, tok := range oldToks[resynced+1:] {
+			d.toks = append(d.toks, s.token(tok))
+		}
+		for _, cp := range oldCheckpoints[next:] {
+			d.checkpoints = append(d.checkpoints, checkpoint{index: cp.index - resynced - 1 + newEnd, state: s.snapshot(cp.state)})
+		}
+		for i := sort.SearchInts(oldErrsAt, resynced+1); i < len(oldErrs); i++ {
+			d.errs = append(d.errs, s.error(oldErrs[i]))
+			d.errsAt = append(d.errsAt, oldErrsAt[i]-resynced-1+newEnd)
+		}
+	}
+
+	// Leave out the tokens at both ends of the span that did not change
+	ch := Change{Start: restart.index, OldEnd: resynced + 1, NewEnd: newEnd}
+	if resynced == len(oldToks) {
+		ch.OldEnd = len(oldToks)
+	}
+	for ch.Start < ch.OldEnd && ch.Start < ch.NewEnd && oldToks[ch.Start] == d.toks[ch.Start] {
+		ch.Start++
+	}
+	for ch.OldEnd > ch.Start && ch.NewEnd > ch.Start && s.token(oldToks[ch.OldEnd-1]) == d.toks[ch.NewEnd-1] &&
+		oldToks[ch.OldEnd-1].Start >= e.End {
+		ch.OldEnd--
+		ch.NewEnd--
+	}
+	return ch, nil
+}
+
+// sameState reports whether the lexer states a and b read the rest of the
+// input alike.
+func sameState(a, b Snapshot) bool {
+	if a.position != b.position || a.line != b.line || a.column != b.column ||
+		!slices.Equal(a.stack, b.stack) || a.last != b.last || a.flowComment != b.flowComment || a.flowPos != b.flowPos ||
+		a.strict != b.strict || a.strictScope != b.strictScope || a.mappingURL != b.mappingURL {
+		return false
+	}
+	da, db := a.directives, b.directives
+	return da.prologue == db.prologue && da.scope == db.scope &&
+		(da.candidate == nil) == (db.candidate == nil) &&
+		(da.candidate == nil || *da.candidate == *db.candidate) &&
+		(da.octal == nil) == (db.octal == nil) &&
+		slices.Equal(da.parens, db.parens) && da.closedParen == db.closedParen &&
+		da.class == db.class && slices.Equal(da.classBodies, db.classBodies) &&
+		da.clauseNext == db.clauseNext && da.clause == db.clause
+}
+
+// shift moves offsets and positions after an edit by the length of the
+// text it inserted and removed.
+type shift struct {
+	// End of the edit in the old input, and the line it is on
+	end     int
+	endLine int
+	delta   int
+	lines   int
+	columns int
+}
+
+func newShift(src string, e Edit) shift {
+	s := shift{end: e.End, delta: len(e.Text) - (e.End - e.Start)}
+	s.endLine = strings.Count(src[:e.End], "\n")
+	s.lines = strings.Count(e.Text, "\n") - strings.Count(src[e.Start:e.End], "\n")
+	// The column of the end of the edit moves to the column after the
+	// inserted text
+	oldColumn := e.End - (strings.LastIndexByte(src[:e.End], '\n') + 1)
+	newText := src[:e.Start] + e.Text
+	newColumn := len(newText) - (strings.LastIndexByte(newText, '\n') + 1)
+	s.columns = newColumn - oldColumn
+	return s
+}
+
+func (s shift) offset(offset int) int {
+	if offset < s.end {
+		return offset
+	}
+	return offset + s.delta
+}
+
+func (s shift) line(line, column int) (int, int) {
+	switch {
+	case line > s.endLine:
+		return line + s.lines, column
+	case line == s.endLine:
+		return line + s.lines, column + s.columns
+	}
+	return line, column
+}
+
+func (s shift) position(p token.Position) token.Position {
+	p.Line, p.Column = s.line(p.Line, p.Column)
+	return p
+}
+
+func (s shift) token(tok token.Token) token.Token {
+	tok.Start, tok.End = s.offset(tok.Start), s.offset(tok.End)
+	tok.Loc.Start, tok.Loc.End = s.position(tok.Loc.Start), s.position(tok.Loc.End)
+	return tok
+}
+
+func (s shift) error(err *SyntaxError) *SyntaxError {
+	moved := *err
+	moved.Offset = s.offset(err.Offset)
+	moved.Line, moved.Column = s.line(err.Line, err.Column)
+	return &moved
+}
+
+func (s shift) snapshot(state Snapshot) Snapshot {
+	state.position = s.offset(state.position)
+	state.readPosition = s.offset(state.readPosition)
+	state.line, state.column = s.line(state.line, state.column)
+	state.last.start = s.offset(state.last.start)
+	state.last.pos = s.position(state.last.pos)
+	if state.flowComment {
+		state.flowPos = s.position(state.flowPos)
+	}
+	if c := state.directives.candidate; c != nil {
+		moved := s.token(*c)
+		state.directives.candidate = &moved
+	}
+	return state
+}
@@ -0,0 +1,138 @@
+package lexer
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDocumentApply(t *testing.T) {
+	// 38 bytes and 18 tokens per repetition
+	input := strings.Repeat("let a = `x ${b} y`; // c\nf(/re/g, 1);\n", 40)
+
+	tests := []struct {
+		edit     Edit
+		expected Change
+		literals []string
+	}{
+		// Renaming an identifier in the middle of the input
+		{Edit{Start: 4 + 38*20, End: 5 + 38*20, Text: "abc"}, Change{Start: 1 + 18*20, OldEnd: 2 + 18*20, NewEnd: 2 + 18*20}, []string{"abc"}},
+		// Inserting tokens
+		{Edit{Start: 0, End: 0, Text: "x = 1;\n"}, Change{Start: 0, OldEnd: 0, NewEnd: 4}, []string{"x", "=", "1", ";"}},
+		// Starting a comment that never ends
+		{Edit{Start: 38 * 39, End: 38 * 39, Text: "/*"}, Change{Start: 18 * 39, OldEnd: 18 * 40, NewEnd: 18 * 39}, nil},
+	}
+
+	for i, tt := range tests {
+		d := NewDocument(input, WithRegExpHeuristic())
+		ch, err := d.Apply(tt.edit)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if ch != tt.expected {
+			t.Fatalf("tests[%d] - change wrong. expected=%+v, got=%+v", i, tt.expected, ch)
+		}
+		var literals []string
+		for _, tok := range d.Tokens()[ch.Start:ch.NewEnd] {
+			literals = append(literals, tok.Literal)
+		}
+		if !slices.Equal(literals, tt.literals) {
+			t.Fatalf("tests[%d] - changed tokens wrong. expected=%q, got=%q", i, tt.literals, literals)
+		}
+		testSameDocument(t, i, d, NewDocument(d.Source(), WithRegExpHeuristic()))
+	}
+}
+
+func TestDocumentApplyReportedError(t *testing.T) {
+	// The octal escape sequence is an error reported at "use strict", more
+	// than a checkpoint interval of tokens after it
+	input := `'\07';` + strings.Repeat(" 'a';", 40) + " 'use strict';"
+	opts := []Option{WithSourceType(Script)}
+	d := NewDocument(input, opts...)
+	if len(d.Errors()) != 1 {
+		t.Fatalf("error count wrong. expected=1, got=%d", len(d.Errors()))
+	}
+
+	e := Edit{Start: len(input) - len("strict';"), End: len(input) - len("';"), Text: "strikt"}
+	if _, err := d.Apply(e); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	testSameDocument(t, 0, d, NewDocument(d.Source(), opts...))
+}
+
+func TestDocumentApplyError(t *testing.T) {
+	d := NewDocument("a")
+	if _, err := d.Apply(Edit{Start: 0, End: 2}); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+// TestDocumentApplyRandom checks that random edits leave a Document with the
+// tokens and errors of its input lexed from scratch.
+func TestDocumentApplyRandom(t *testing.T) {
+	input := strings.Repeat("function f(a) {\n  return `x ${a + {b: 1}.b} y` /* c */ + /re/g.test(a);\n}\n"+
+		"const el = <div a={1}>text {x}</div>;\n'use strict'; 'a\\08';\n", 10)
+	fragments := []string{"`", "${", "}", "{", "/*", "*/", "//", "\n", "'", "\"", "/", "<div>", "</div>", "a", " ", "'use strict';", "\\"}
+
+	optionSets := [][]Option{
+		{WithRegExpHeuristic(), WithJSX(), WithSourceType(Script)},
+		{WithRegExpHeuristic(), WithJSX()},
+		{WithComments(), WithSourceType(Script)},
+		{WithRegExpHeuristic(), WithDialect(TypeScript), WithDetectedSourceType()},
+		{WithDialect(Flow), WithJSX(), WithSourceType(Script)},
+	}
+
+	for _, opts := range optionSets {
+		for seed := int64(1); seed <= 20; seed++ {
+			r := rand.New(rand.NewSource(seed))
+			d := NewDocument(input, opts...)
+			for i := 0; i < 200; i++ {
+				start := r.Intn(len(d.Source()) + 1)
+				end := start + r.Intn(min(8, len(d.Source())-start)+1)
+				e := Edit{Start: start, End: end, Text: fragments[r.Intn(len(fragments))]}
+
+				old := slices.Clone(d.Tokens())
+				s := newShift(d.Source(), e)
+				ch, err := d.Apply(e)
+				if err != nil {
+					t.Fatalf("edits[%d] - unexpected error: %q", i, err.Error())
+				}
+				testSameDocument(t, i, d, NewDocument(d.Source(), opts...))
+
+				toks := d.Tokens()
+				if !slices.Equal(old[:ch.Start], toks[:ch.Start]) {
+					t.Fatalf("edits[%d] - tokens before change %+v changed", i, ch)
+				}
+				for j, tok := range old[ch.OldEnd:] {
+					if s.token(tok) != toks[ch.NewEnd+j] {
+						t.Fatalf("edits[%d] - tokens after change %+v changed", i, ch)
+					}
+				}
+			}
+		}
+	}
+}
+
+func testSameDocument(t *testing.T, i int, got, expected *Document) {
+	t.Helper()
+
+	if len(got.Tokens()) != len(expected.Tokens()) {
+		t.Fatalf("tests[%d] - token count wrong for %q. expected=%d, got=%d",
+			i, got.Source(), len(expected.Tokens()), len(got.Tokens()))
+	}
+	for j, tok := range expected.Tokens() {
+		if got.Tokens()[j] != tok {
+			t.Fatalf("tests[%d] - token %d wrong. expected=%+v, got=%+v", i, j, tok, got.Tokens()[j])
+		}
+	}
+	if len(got.Errors()) != len(expected.Errors()) {
+		t.Fatalf("tests[%d] - error count wrong for %q. expected=%v, got=%v",
+			i, got.Source(), expected.Errors(), got.Errors())
+	}
+	for j, err := range expected.Errors() {
+		if *got.Errors()[j] != *err {
+			t.Fatalf("tests[%d] - error %d wrong. expected=%+v, got=%+v", i, j, err, got.Errors()[j])
+		}
+	}
+}
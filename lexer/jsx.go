@@ -0,0 +1,208 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// WithJSX makes the lexer read JSX elements where an expression may start,
+// which implies the guess of WithRegExpHeuristic for NextToken. Inside an
+// element, tag names and attribute names are JSXIdentifier tokens,
+// attribute values JSXString tokens, and the text between tags JSXText
+// tokens, whose literals have their character references decoded. The
+// '{' and '}' around embedded expressions switch between JSX and
+// JavaScript, as "${" and '}' do in template literals.
+func WithJSX() Option {
+	return func(l *Lexer) {
+		l.jsx = true
+	}
+}
+
+// scanJSXChildren reads the next token between the tags of an element: a
+// tag, a '{' starting an embedded expression, or text.
+func (l *Lexer) scanJSXChildren() (*token.Token, error) {
+	var tok token.Token
+	lineStart := l.line
+	colStart := l.column - 1
+	start := l.position
+
+	switch l.ch {
+	case '<':
+		l.pushContext(jsxChildrenContext)
+		l.pushContext(jsxOpenTagContext)
+		tok = newToken(token.JSXTagStart, l.ch)
+	case '{':
+		l.pushContext(braceContext)
+		tok = newToken(token.LBrace, l.ch)
+	case 0:
+		if l.atEOF() {
+			return nil, l.unterminatedJSX(lineStart, colStart)
+		}
+		fallthrough
+	default:
+		for l.ch != '<' && l.ch != '{' {
+			if l.atEOF() {
+				return nil, l.unterminatedJSX(lineStart, colStart)
+			}
+			l.readChar()
+		}
+		tok.Type = token.TokenType{Label: token.JSXText}
+		tok.Literal = decodeEntities(l.input[start:l.position])
+		tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+		tok.Start, tok.End = start, l.position
+		return &tok, nil
+	}
+
+	tok.Loc = l.makeSourceLocation(lineStart, colStart, 0)
+	l.readChar()
+	tok.Start, tok.End = start, l.position
+	return &tok, nil
+}
+
+// unterminatedJSX reports the elements left open at the end of the input.
+// Their contexts are dropped, so that the error is reported once, after
+// which the input ends.
+func (l *Lexer) unterminatedJSX(line, column int) error {
+	l.stack = l.stack[:0]
+	return l.errorf(line, column, "Unterminated JSX contents")
+}
+
+// scanJSXTag reads the next token inside a tag if it is one that is read
+// differently there, and returns nil otherwise.
+func (l *Lexer) scanJSXTag() (*token.Token, error) {
+	var tok token.Token
+	lineStart := l.line
+	colStart := l.column - 1
+	start := l.position
+
+	switch {
+	case l.ch == '>':
+		if l.popContext() == jsxOpenTagContext && l.last.label == token.Slash {
+			// Self-closing tag, which has no children
+			l.popContext()
+		}
+		tok = newToken(token.JSXTagEnd, l.ch)
+	case l.ch == '/' && l.peekChar(0) != '/' && l.peekChar(0) != '*':
+		if l.currentContext() == jsxOpenTagContext && l.last.label == token.JSXTagStart {
+			// Closing tag, which ends the children of the element it
+			// closes rather than starting new ones
+			l.popContext()
+			l.popContext()
+			l.popContext()
+			l.pushContext(jsxCloseTagContext)
+		}
+		tok = newToken(token.Slash, l.ch)
+	case l.ch == '"' || l.ch == '\'':
+		tok.Type = token.TokenType{Label: token.JSXString}
+		var err error
+		tok.Literal, err = l.readJSXString(l.ch)
+		if err != nil {
+			return nil, err
+		}
+	case isLetter(l.ch) || l.unicodeChar(unicode.IsLetter) > 0:
+		tok.Type = token.TokenType{Label: token.JSXIdentifier}
+		tok.Literal = l.readJSXIdentifier()
+		tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+		tok.Start, tok.End = start, l.position
+		return &tok, nil
+	default:
+		return nil, nil
+	}
+
+	tok.Loc = l.makeSourceLocation(lineStart, colStart, 0)
+	l.readChar()
+	tok.Start, tok.End = start, l.position
+	return &tok, nil
+}
+
+// readJSXIdentifier reads a tag or attribute name, which unlike a
+// JavaScript identifier may contain '-'.
+func (l *Lexer) readJSXIdentifier() string {
+	position := l.position
+	for {
+		if isLetter(l.ch) || isDigit(l.ch) || l.ch == '-' {
+			l.readChar()
+		} else if size := l.unicodeChar(unicode.IsLetter, isIDContinue); size > 0 {
+			for i := 0; i < size; i++ {
+				l.readChar()
+			}
+		} else {
+			break
+		}
+	}
+	return l.input[position:l.position]
+}
+
+// readJSXString reads an attribute value, which has no escape sequences and
+// may span lines.
+func (l *Lexer) readJSXString(quote byte) (string, error) {
+	lineStart := l.line
+	stringStart := l.column - 1
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == quote {
+			break
+		} else if l.ch == 0 {
+			return "", l.errorf(lineStart, stringStart, "Unterminated string constant")
+		}
+	}
+	return decodeEntities(l.input[position:l.position]), nil
+}
+
+// decodeEntities replaces the character references in JSX text, such as
+// &amp;, &#65;, and &#x41;, with the characters they stand for. Anything
+// that is not a well-formed reference is left as it is.
+func decodeEntities(s string) string {
+	if !strings.ContainsRune(s, '&') {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '&')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		// References are short, so a distant ';' belongs to the text.
+		end := strings.IndexByte(s, ';')
+		if end < 0 || end > 10 {
+			b.WriteByte('&')
+			s = s[1:]
+			continue
+		}
+		if r, ok := entityValue(s[1:end]); ok {
+			b.WriteRune(r)
+			s = s[end+1:]
+		} else {
+			b.WriteByte('&')
+			s = s[1:]
+		}
+	}
+}
+
+// entityValue returns the character a reference named name stands for.
+func entityValue(name string) (rune, bool) {
+	if strings.HasPrefix(name, "#") {
+		digits, base := name[1:], 10
+		if strings.HasPrefix(digits, "x") || strings.HasPrefix(digits, "X") {
+			digits, base = digits[1:], 16
+		}
+		if digits == "" || digits[0] == '+' || digits[0] == '-' {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(digits, base, 32)
+		if err != nil || !utf8.ValidRune(rune(n)) {
+			return 0, false
+		}
+		return rune(n), true
+	}
+	r, ok := xhtmlEntities[name]
+	return r, ok
+}
@@ -0,0 +1,245 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestJSX(t *testing.T) {
+	input := `const el = <div className="x" data-id='a&amp;b&#x41;&#66;'>
+  Hello {name}!&nbsp;<br/><a:b c.d={[1]} {...rest}>&unknown; &#xZZ;</a:b>
+</div>;
+x < y > z`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.Const), "const"},
+		{makeTT(token.Identifier), "el"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.JSXTagStart), "<"},
+		{makeTT(token.JSXIdentifier), "div"},
+		{makeTT(token.JSXIdentifier), "className"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.JSXString), "x"},
+		{makeTT(token.JSXIdentifier), "data-id"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.JSXString), "a&bAB"},
+		{makeTT(token.JSXTagEnd), ">"},
+		{makeTT(token.JSXText), "\n  Hello "},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.Identifier), "name"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.JSXText), "!\u00a0"},
+		// Self-closing element
+		{makeTT(token.JSXTagStart), "<"},
+		{makeTT(token.JSXIdentifier), "br"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.JSXTagEnd), ">"},
+		// Namespaced and member names, expression attributes
+		{makeTT(token.JSXTagStart), "<"},
+		{makeTT(token.JSXIdentifier), "a"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.JSXIdentifier), "b"},
+		{makeTT(token.JSXIdentifier), "c"},
+		{makeTT(token.Dot), "."},
+		{makeTT(token.JSXIdentifier), "d"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.LBracket), "["},
+		{makeTT(token.Numeric), "1"},
+		{makeTT(token.RBracket), "]"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.Ellipsis), "..."},
+		{makeTT(token.Identifier), "rest"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.JSXTagEnd), ">"},
+		{makeTT(token.JSXText), "&unknown; &#xZZ;"},
+		{makeTT(token.JSXTagStart), "<"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.JSXIdentifier), "a"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.JSXIdentifier), "b"},
+		{makeTT(token.JSXTagEnd), ">"},
+		{makeTT(token.JSXText), "\n"},
+		// Closing tag
+		{makeTT(token.JSXTagStart), "<"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.JSXIdentifier), "div"},
+		{makeTT(token.JSXTagEnd), ">"},
+		{makeTT(token.Semicolon), ";"},
+		// Relational operators after an expression
+		{makeTT(token.Identifier), "x"},
+		{makeTT(token.LT), "<"},
+		{makeTT(token.Identifier), "y"},
+		{makeTT(token.GT), ">"},
+		{makeTT(token.Identifier), "z"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input, WithJSX())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestJSXFragment(t *testing.T) {
+	input := "<>\n  <p>a</p>\n</>"
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLocation token.SourceLocation
+	}{
+		{makeTT(token.JSXTagStart), "<", makeLoc(0, 0, 0, 1)},
+		{makeTT(token.JSXTagEnd), ">", makeLoc(0, 1, 0, 2)},
+		{makeTT(token.JSXText), "\n  ", makeLoc(0, 2, 1, 2)},
+		{makeTT(token.JSXTagStart), "<", makeLoc(1, 2, 1, 3)},
+		{makeTT(token.JSXIdentifier), "p", makeLoc(1, 3, 1, 4)},
+		{makeTT(token.JSXTagEnd), ">", makeLoc(1, 4, 1, 5)},
+		{makeTT(token.JSXText), "a", makeLoc(1, 5, 1, 6)},
+		{makeTT(token.JSXTagStart), "<", makeLoc(1, 6, 1, 7)},
+		{makeTT(token.Slash), "/", makeLoc(1, 7, 1, 8)},
+		{makeTT(token.JSXIdentifier), "p", makeLoc(1, 8, 1, 9)},
+		{makeTT(token.JSXTagEnd), ">", makeLoc(1, 9, 1, 10)},
+		{makeTT(token.JSXText), "\n", makeLoc(1, 10, 2, 0)},
+		{makeTT(token.JSXTagStart), "<", makeLoc(2, 0, 2, 1)},
+		{makeTT(token.Slash), "/", makeLoc(2, 1, 2, 2)},
+		{makeTT(token.JSXTagEnd), ">", makeLoc(2, 2, 2, 3)},
+		{makeTT(token.EOF), "", makeLoc(2, 3, 2, 3)},
+	}
+
+	l := New(input, WithJSX())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, tok.Loc)
+		}
+	}
+}
+
+func TestJSXError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<a>text", "SyntaxError: Unterminated JSX contents (0:3)"},
+		{"<a>", "SyntaxError: Unterminated JSX contents (0:3)"},
+		{"<a b='c>", "SyntaxError: Unterminated string constant (0:5)"},
+	}
+
+	for i, tt := range tests {
+		_, err := Tokenize(tt.input, WithJSX())
+		if err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+		if err.Error() != tt.expected {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expected, err.Error())
+		}
+	}
+
+	// An unterminated element is reported once, and then the input ends
+	recovery := []struct {
+		input    string
+		expected string
+	}{
+		{"<a>text", "jsx-tag-start jsx-identifier jsx-tag-end error eof"},
+		{"<a><b>{c}", "jsx-tag-start jsx-identifier jsx-tag-end jsx-tag-start jsx-identifier jsx-tag-end { identifier } error eof"},
+		{"<a>\x00b</a>", "jsx-tag-start jsx-identifier jsx-tag-end jsx-text jsx-tag-start / jsx-identifier jsx-tag-end eof"},
+	}
+
+	for i, tt := range recovery {
+		l := New(tt.input, WithJSX())
+		var labels []string
+		for range 20 {
+			tok, err := l.NextToken()
+			if err != nil {
+				labels = append(labels, "error")
+				continue
+			}
+			labels = append(labels, tok.Type.Label)
+			if tok.Type.Label == token.EOF {
+				break
+			}
+		}
+		if got := strings.Join(labels, " "); got != tt.expected {
+			t.Fatalf("recovery[%d] - labels wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestReScanLessThanToken(t *testing.T) {
+	input := "return <a>{b}</a>"
+
+	l := New(input, WithJSX())
+	if _, err := l.NextTokenWithGoal(GoalDiv); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	tok, err := l.NextTokenWithGoal(GoalDiv)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.LT) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.LT), tok.Type)
+	}
+
+	tok, err = l.ReScanLessThanToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.JSXTagStart) || tok.Start != 7 || tok.End != 8 {
+		t.Fatalf("rescanned token wrong. got=%+v", tok)
+	}
+
+	expected := []string{
+		token.JSXIdentifier, token.JSXTagEnd, token.LBrace, token.Identifier, token.RBrace,
+		token.JSXTagStart, token.Slash, token.JSXIdentifier, token.JSXTagEnd, token.EOF,
+	}
+	for i, label := range expected {
+		tok, err := l.NextTokenWithGoal(GoalDiv)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if tok.Type != makeTT(label) {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, label, tok.Type.Label)
+		}
+	}
+
+	if _, err := New("a < b").ReScanLessThanToken(); err == nil {
+		t.Fatalf("expected error without WithJSX")
+	}
+}
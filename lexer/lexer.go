@@ -1,31 +1,70 @@
 package lexer
 
 import (
-	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/morinokami/js-lexer/token"
 )
 
 type Lexer struct {
-	input             string
-	position          int
-	readPosition      int
-	ch                byte
-	line              int
-	column            int
-	templateDepth     int
-	substitutionDepth int
-}
-
-func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	input        string
+	position     int
+	readPosition int
+	ch           byte
+	line         int
+	column       int
+	stack        []context
+	last         lastToken
+
+	comments        bool
+	sourceType      SourceType
+	dialect         Dialect
+	regExpHeuristic bool
+	jsx             bool
+	autoSourceType  bool
+
+	// Whether the lexer is in strict mode code, which ends when the
+	// context stack gets shorter than strictScope, unless it is 0
+	strict      bool
+	strictScope int
+	directives  directives
+
+	// Whether the lexer is inside a Flow comment type, which started at
+	// flowCommentPos
+	flowComment    bool
+	flowCommentPos token.Position
+
+	sourceMappingURL string
+
+	// The file of the input, which gives the positions of the tokens
+	file *token.File
+}
+
+func New(input string, opts ...Option) *Lexer {
+	l := &Lexer{input: input, directives: directives{prologue: true}}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.autoSourceType {
+		l.sourceType = l.detectSourceType()
+	}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.readPosition > len(l.input) {
+		// Already at EOF
+		return
+	}
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
 	if l.readPosition >= len(l.input) {
 		// EOF
 		l.ch = 0
@@ -37,6 +76,12 @@ func (l *Lexer) readChar() {
 	l.column += 1
 }
 
+// atEOF reports whether the whole input has been read, as opposed to the
+// current character being a NUL.
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
 func (l *Lexer) peekChar(n int) byte {
 	if l.readPosition+n >= len(l.input) {
 		return 0
@@ -47,12 +92,64 @@ func (l *Lexer) peekChar(n int) byte {
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
-		l.readChar()
+	for {
+		if isLetter(l.ch) || isDigit(l.ch) {
+			l.readChar()
+		} else if l.ch == '\\' && l.peekChar(0) == 'u' {
+			l.skipUnicodeEscape()
+		} else if size := l.unicodeChar(unicode.IsLetter, isIDContinue); size > 0 {
+			for i := 0; i < size; i++ {
+				l.readChar()
+			}
+		} else {
+			break
+		}
 	}
 	return l.input[position:l.position]
 }
 
+// skipUnicodeEscape skips a \uXXXX or \u{X...} escape sequence in an
+// identifier.
+func (l *Lexer) skipUnicodeEscape() {
+	l.readChar() // skip '\'
+	l.readChar() // skip 'u'
+	if l.ch == '{' {
+		for l.ch != '}' && l.ch != 0 {
+			l.readChar()
+		}
+		l.readChar()
+		return
+	}
+	for i := 0; i < 4 && isHexChar(l.ch); i++ {
+		l.readChar()
+	}
+}
+
+// unicodeChar returns the size of the non-ASCII character at the
+// current position if it satisfies one of the given predicates, and zero
+// otherwise.
+func (l *Lexer) unicodeChar(preds ...func(rune) bool) int {
+	if l.ch < utf8.RuneSelf {
+		return 0
+	}
+	r, size := utf8.DecodeRuneInString(l.input[l.position:])
+	for _, pred := range preds {
+		if pred(r) {
+			return size
+		}
+	}
+	return 0
+}
+
+func isBOM(r rune) bool {
+	return r == '\ufeff'
+}
+
+func isIDContinue(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) ||
+		r == '\u200c' || r == '\u200d'
+}
+
 func (l *Lexer) readNumber() (string, error) {
 	if l.ch == '0' {
 		next := l.peekChar(0)
@@ -67,25 +164,51 @@ func (l *Lexer) readNumber() (string, error) {
 
 	position := l.position
 	readDecimalPoint := false
-	for isDigit(l.ch) || (l.ch == '.' && !readDecimalPoint) {
+	for isDigit(l.ch) || l.isNumericSeparator(isDigit) || (l.ch == '.' && !readDecimalPoint) {
 		if l.ch == '.' {
 			readDecimalPoint = true
 		}
 		l.readChar()
 	}
+
+	next := l.peekChar(0)
+	if (l.ch == 'e' || l.ch == 'E') &&
+		(isDigit(next) || (next == '+' || next == '-') && isDigit(l.peekChar(1))) {
+		// Exponent
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		for isDigit(l.ch) || l.isNumericSeparator(isDigit) {
+			l.readChar()
+		}
+	} else if l.ch == 'n' && !readDecimalPoint {
+		// BigInt
+		l.readChar()
+	}
 	return l.input[position:l.position], nil
 }
 
+// isNumericSeparator reports whether the current character is a '_'
+// between two digits.
+func (l *Lexer) isNumericSeparator(isDigit func(ch byte) bool) bool {
+	return l.ch == '_' && l.position > 0 && isDigit(l.input[l.position-1]) && isDigit(l.peekChar(0))
+}
+
 func (l *Lexer) readBaseNNumber(base int, isBaseNNumber func(ch byte) bool) (string, error) {
 	position := l.position
 	l.readChar() // skip '0'
 	l.readChar() // skip letter
 
 	if !isBaseNNumber(l.ch) {
-		return "", errors.New(fmt.Sprintf("SyntaxError: Expected number in radix %d (%d:%d)", base, l.line, l.column-1))
+		return "", l.errorf(l.line, l.column-1, "Expected number in radix %d", base)
 	}
 
-	for isBaseNNumber(l.ch) {
+	for isBaseNNumber(l.ch) || l.isNumericSeparator(isBaseNNumber) {
+		l.readChar()
+	}
+	if l.ch == 'n' {
+		// BigInt
 		l.readChar()
 	}
 	return l.input[position:l.position], nil
@@ -104,31 +227,115 @@ func (l *Lexer) readHexadecimalNumber() (string, error) {
 }
 
 func (l *Lexer) readString(quote byte) (string, error) {
+	lineStart := l.line
 	stringStart := l.column - 1
 	position := l.position + 1
+	// The first invalid escape sequence, reported once the string ends
+	var escapeErr error
 	for {
 		l.readChar()
 		if l.ch == quote {
 			break
-		} else if l.ch == '\\' && l.peekChar(0) == quote {
+		} else if l.ch == '\\' && l.peekChar(0) != 0 {
+			if escapeErr == nil {
+				escapeErr = l.checkEscape()
+			}
+			// Skip the escaped character, which may be a line terminator
 			l.readChar()
 		} else if l.ch == 0 || l.ch == '\n' {
-			return "", fmt.Errorf("SyntaxError: Unterminated string constant (%d:%d)", l.line, stringStart)
+			return "", l.errorf(lineStart, stringStart, "Unterminated string constant")
 		}
 	}
+	if escapeErr != nil {
+		l.readChar()
+		return "", escapeErr
+	}
 	escapedQuote := fmt.Sprintf("\\%s", string(quote))
 	return strings.ReplaceAll(l.input[position:l.position], escapedQuote, string(quote)), nil
 }
 
+// checkEscape checks the \x and \u escape sequence at the current '\\'
+// of a string literal, without consuming it.
+func (l *Lexer) checkEscape() error {
+	line, column := l.line, l.column-1
+	rest := l.input[l.readPosition:]
+	switch {
+	case strings.HasPrefix(rest, "x"):
+		if len(rest) < 3 || !isHexChar(rest[1]) || !isHexChar(rest[2]) {
+			return l.errorf(line, column, "Bad character escape sequence")
+		}
+	case strings.HasPrefix(rest, "u{"):
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return l.errorf(line, column, "Bad character escape sequence")
+		}
+		if code, err := strconv.ParseUint(rest[2:end], 16, 32); err != nil || code > unicode.MaxRune {
+			return l.errorf(line, column, "Bad character escape sequence")
+		}
+	case strings.HasPrefix(rest, "u"):
+		if len(rest) < 5 || strings.IndexFunc(rest[1:5], func(r rune) bool { return r > 0x7f || !isHexChar(byte(r)) }) >= 0 {
+			return l.errorf(line, column, "Bad character escape sequence")
+		}
+	}
+	return nil
+}
+
+func (l *Lexer) readRegExp() (string, error) {
+	lineStart := l.line
+	regExpStart := l.column - 1
+	position := l.position
+	inClass := false
+	for {
+		l.readChar()
+		if l.ch == '\\' {
+			l.readChar()
+		} else if l.ch == '[' {
+			inClass = true
+		} else if l.ch == ']' {
+			inClass = false
+		} else if l.ch == '/' && !inClass {
+			break
+		}
+		if l.ch == 0 || l.ch == '\n' || l.ch == '\r' {
+			return "", l.errorf(lineStart, regExpStart, "Unterminated regular expression")
+		}
+	}
+	l.readChar()
+	// Flags
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[position:l.position], nil
+}
+
 func (l *Lexer) readTemplateString() (string, error) {
 	position := l.position
 	for !l.isTemplateContextChanger() && l.ch != 0 {
+		if l.ch == '\\' {
+			l.readChar()
+		}
 		l.readChar()
-		// TODO: if l.ch == 0 { eof error ? }
 	}
 	return l.input[position:l.position], nil
 }
 
+// isIdentifierStart reports whether an identifier starts n bytes after the
+// current position.
+func (l *Lexer) isIdentifierStart(n int) bool {
+	if l.position+n >= len(l.input) {
+		return false
+	}
+	ch := l.input[l.position+n]
+	if isLetter(ch) || ch == '\\' && l.peekChar(n) == 'u' {
+		return true
+	}
+	if ch < utf8.RuneSelf {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.position+n:])
+	return unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch == '$'
 }
@@ -149,58 +356,126 @@ func isOctalChar(ch byte) bool {
 	return '0' <= ch && ch <= '7'
 }
 
-func (l *Lexer) skipWhitespace() {
+func (l *Lexer) skipWhitespace() error {
 	for {
-		if l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-			if l.ch == '\n' {
-				l.line += 1
-				l.column = 0
-			}
+		if l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || l.ch == '\v' || l.ch == '\f' {
 			l.readChar()
 			continue
-		} else if l.ch == '/' && l.peekChar(0) == '/' {
+		} else if size := l.unicodeChar(unicode.IsSpace, isBOM); size > 0 {
+			for i := 0; i < size; i++ {
+				l.readChar()
+			}
+			continue
+		} else if l.skipFlowCommentDelimiter() {
+			continue
+		} else if !l.comments && l.isHTMLComment() {
+			l.skipHTMLComment()
+			continue
+		} else if !l.comments && l.ch == '/' && l.peekChar(0) == '/' {
 			l.skipSingleLineComment()
 			continue
-		} else if l.ch == '/' && l.peekChar(0) == '*' {
-			l.skipMultiLineComment()
+		} else if !l.comments && l.ch == '/' && l.peekChar(0) == '*' {
+			if _, err := l.skipMultiLineComment(); err != nil {
+				return err
+			}
 			continue
 		}
 		break
 	}
+	return nil
 }
 
-func (l *Lexer) skipSingleLineComment() {
+func (l *Lexer) skipSingleLineComment() string {
+	l.readChar()
+	l.readChar()
+	position := l.position
 	for {
-		if l.ch == '\n' || l.ch == 0 {
+		if l.ch == '\n' || l.ch == '\r' || l.atEOF() {
 			break
 		}
 		l.readChar()
 	}
+	comment := l.input[position:l.position]
+	l.readSourceMappingURL(comment)
+	return comment
 }
 
-func (l *Lexer) skipMultiLineComment() {
+func (l *Lexer) skipMultiLineComment() (string, error) {
+	lineStart := l.line
+	commentStart := l.column - 1
 	l.readChar()
 	l.readChar()
+	position := l.position
 	for {
-		if l.ch == '*' && l.peekChar(0) == '/' || l.ch == 0 {
+		if l.atEOF() {
+			return "", l.errorf(lineStart, commentStart, "Unterminated comment")
+		}
+		if l.ch == '*' && l.peekChar(0) == '/' {
+			comment := l.input[position:l.position]
 			l.readChar()
 			l.readChar()
-			break
+			l.readSourceMappingURL(comment)
+			return comment, nil
 		}
 		l.readChar()
 	}
 }
 
+// context is an entry of the stack of open braces, template literals,
+// template substitutions, and JSX elements, which determines how '}' and the
+// text following it are tokenized.
+type context byte
+
+const (
+	noContext context = iota
+	braceContext
+	templateContext
+	substitutionContext
+	jsxChildrenContext
+	jsxOpenTagContext
+	jsxCloseTagContext
+)
+
+func (l *Lexer) pushContext(c context) {
+	l.stack = append(l.stack, c)
+}
+
+func (l *Lexer) popContext() context {
+	if len(l.stack) == 0 {
+		return noContext
+	}
+	c := l.stack[len(l.stack)-1]
+	l.stack = l.stack[:len(l.stack)-1]
+	l.last.popped = c
+	return c
+}
+
+func (l *Lexer) currentContext() context {
+	if len(l.stack) == 0 {
+		return noContext
+	}
+	return l.stack[len(l.stack)-1]
+}
+
 func (l *Lexer) isInTemplateString() bool {
-	return l.templateDepth > l.substitutionDepth
+	return l.currentContext() == templateContext
 }
 
 func (l *Lexer) isTemplateContextChanger() bool {
 	return l.ch == '`' || l.ch == '$' && l.peekChar(0) == '{'
 }
 
-func (l *Lexer) isInSubstitutionContext() bool {
-	return l.substitutionDepth > 0
+// isNonNullAssertion reports whether the '!' at the current position is a
+// TypeScript non-null assertion, which directly follows an expression.
+func (l *Lexer) isNonNullAssertion() bool {
+	if l.dialect != TypeScript || l.position == 0 || l.peekChar(0) == '=' {
+		return false
+	}
+	prev := l.input[l.position-1]
+	if prev == ' ' || prev == '\t' || prev == '\n' || prev == '\r' {
+		return false
+	}
+	return !regExpAllowedAfter(l.last.label)
 }
 
 func newToken(label string, ch byte) token.Token {
@@ -234,23 +509,92 @@ func (l *Lexer) makeSourceLocation(lineStart, colStart, adjustment int) token.So
 	}
 }
 
+// NextToken returns the next token of the input. Once the input is
+// exhausted it returns a token of type token.EOF, and keeps returning one on
+// every later call. Check for the end of input by type: template strings and
+// other tokens may also have an empty literal.
+//
+// A token that lexes fine but breaks a rule of strict mode, such as the
+// legacy octal literal 010, is returned along with the error, so that
+// callers that keep going after errors do not lose it.
 func (l *Lexer) NextToken() (*token.Token, error) {
-	var tok token.Token
+	goal := GoalDiv
+	if (l.regExpHeuristic || l.jsx) && regExpAllowedAfter(l.last.label) {
+		goal = GoalRegExp
+	}
+	return l.NextTokenWithGoal(goal)
+}
+
+// NextTokenWithGoal is like NextToken, but reads the next token for the
+// given goal instead of guessing it.
+func (l *Lexer) NextTokenWithGoal(goal Goal) (*token.Token, error) {
+	popped := l.last.popped
+	l.last.popped = noContext
+	tok, err := l.scan(goal)
+	if err != nil {
+		return nil, err
+	}
+	if l.file != nil {
+		tok.Pos = l.file.Pos(tok.Start)
+	}
+	switch tok.Type.Label {
+	case token.LineComment, token.BlockComment:
+		l.last.popped = popped
+	default:
+		err = l.trackDirectives(tok)
+		l.last.label = tok.Type.Label
+		l.last.start = tok.Start
+		l.last.pos = tok.Loc.Start
+	}
+	return tok, err
+}
 
-	l.skipWhitespace()
+func (l *Lexer) scan(goal Goal) (*token.Token, error) {
+	var tok token.Token
 
 	if l.isInTemplateString() && !l.isTemplateContextChanger() && l.ch != 0 {
+		// Whitespace and comments are part of the template string
+		lineStart := l.line
+		colStart := l.column - 1
+		start := l.position
 		tok.Type = token.TokenType{Label: token.String}
 		var err error
 		tok.Literal, err = l.readTemplateString()
 		if err != nil {
 			return nil, err
 		}
+		tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+		tok.Start, tok.End = start, l.position
 		return &tok, nil
 	}
 
+	if l.currentContext() == jsxChildrenContext {
+		// Whitespace and comments are part of the JSX text
+		return l.scanJSXChildren()
+	}
+
+	if err := l.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if c := l.currentContext(); c == jsxOpenTagContext || c == jsxCloseTagContext {
+		tok, err := l.scanJSXTag()
+		if tok != nil || err != nil {
+			return tok, err
+		}
+	}
+
 	lineStart := l.line
 	colStart := l.column - 1
+	start := l.position
+
+	if l.comments && l.isHTMLComment() {
+		tok.Type = token.TokenType{Label: token.LineComment}
+		tok.Literal = l.skipHTMLComment()
+		tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+		tok.Start, tok.End = start, l.position
+		return &tok, nil
+	}
 
 	switch l.ch {
 
@@ -260,10 +604,15 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 	case ')':
 		tok = newToken(token.RParen, l.ch)
 	case '{':
+		l.pushContext(braceContext)
 		tok = newToken(token.LBrace, l.ch)
 	case '}':
-		if l.isInSubstitutionContext() {
-			l.substitutionDepth -= 1
+		if c := l.popContext(); c == substitutionContext {
+			tok = newToken(token.SubstitutionEnd, l.ch)
+		} else if goal == GoalTemplateTail {
+			// Whatever the lexer took the brace to close, the template
+			// literal continues after it.
+			l.pushContext(templateContext)
 			tok = newToken(token.SubstitutionEnd, l.ch)
 		} else {
 			tok = newToken(token.RBrace, l.ch)
@@ -284,6 +633,7 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 				return nil, err
 			}
 			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
 			return &tok, nil
 		} else {
 			tok = newToken(token.Dot, l.ch)
@@ -294,11 +644,17 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 		tok = newToken(token.Colon, l.ch)
 	case ',':
 		tok = newToken(token.Comma, l.ch)
+	case '@':
+		// Decorator
+		tok = newToken(token.At, l.ch)
 	case '?':
-		if l.peekChar(0) == '?' {
+		if l.peekChar(0) == '?' && l.peekChar(1) == '=' {
+			// Nullish coalescing assignment
+			tok = makeMultiCharToken(l, token.NullishCoalescingAssignment, 2)
+		} else if l.peekChar(0) == '?' {
 			// Nullish coalescing
 			tok = makeMultiCharToken(l, token.NullishCoalescing, 1)
-		} else if l.peekChar(0) == '.' {
+		} else if l.peekChar(0) == '.' && !isDigit(l.peekChar(1)) {
 			// Optional chaining
 			tok = makeMultiCharToken(l, token.OptionalChaining, 1)
 		} else {
@@ -307,7 +663,12 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 
 	// Operators
 	case '<':
-		if l.peekChar(0) == '<' && l.peekChar(1) == '=' {
+		if l.jsx && goal == GoalRegExp {
+			// JSX element
+			l.pushContext(jsxChildrenContext)
+			l.pushContext(jsxOpenTagContext)
+			tok = newToken(token.JSXTagStart, l.ch)
+		} else if l.peekChar(0) == '<' && l.peekChar(1) == '=' {
 			// Left shift assignment
 			tok = makeMultiCharToken(l, token.LeftShiftAssignment, 2)
 		} else if l.peekChar(0) == '<' {
@@ -321,7 +682,10 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 			tok = newToken(token.LT, l.ch)
 		}
 	case '>':
-		if l.peekChar(0) == '>' && l.peekChar(1) == '>' && l.peekChar(2) == '=' {
+		if goal == GoalTypeArguments {
+			// Greater than, closing a list of type arguments
+			tok = newToken(token.GT, l.ch)
+		} else if l.peekChar(0) == '>' && l.peekChar(1) == '>' && l.peekChar(2) == '=' {
 			// Unsigned right shift assignment
 			tok = makeMultiCharToken(l, token.UnsignedRightShiftAssignment, 3)
 		} else if l.peekChar(0) == '>' && l.peekChar(1) == '>' {
@@ -355,7 +719,9 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 			tok = newToken(token.Assignment, l.ch)
 		}
 	case '!':
-		if l.peekChar(0) == '=' && l.peekChar(1) == '=' {
+		if l.isNonNullAssertion() {
+			tok = newToken(token.NonNull, l.ch)
+		} else if l.peekChar(0) == '=' && l.peekChar(1) == '=' {
 			// Nonidentity
 			tok = makeMultiCharToken(l, token.Nonidentity, 2)
 		} else if l.peekChar(0) == '=' {
@@ -402,7 +768,36 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 			tok = newToken(token.Star, l.ch)
 		}
 	case '/':
-		if l.peekChar(0) == '=' {
+		if l.comments && l.peekChar(0) == '/' {
+			// Single line comment
+			tok.Type = token.TokenType{Label: token.LineComment}
+			tok.Literal = l.skipSingleLineComment()
+			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
+			return &tok, nil
+		} else if l.comments && l.peekChar(0) == '*' {
+			// Multi line comment
+			tok.Type = token.TokenType{Label: token.BlockComment}
+			var err error
+			tok.Literal, err = l.skipMultiLineComment()
+			if err != nil {
+				return nil, err
+			}
+			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
+			return &tok, nil
+		} else if goal == GoalRegExp {
+			// Regular expression
+			tok.Type = token.TokenType{Label: token.RegExp}
+			var err error
+			tok.Literal, err = l.readRegExp()
+			if err != nil {
+				return nil, err
+			}
+			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
+			return &tok, nil
+		} else if l.peekChar(0) == '=' {
 			// Division assignment
 			tok = makeMultiCharToken(l, token.DivisionAssignment, 1)
 		} else {
@@ -418,7 +813,10 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 			tok = newToken(token.Remainder, l.ch)
 		}
 	case '&':
-		if l.peekChar(0) == '&' {
+		if l.peekChar(0) == '&' && l.peekChar(1) == '=' {
+			// Logical AND assignment
+			tok = makeMultiCharToken(l, token.LogicalAndAssignment, 2)
+		} else if l.peekChar(0) == '&' {
 			// Logical AND
 			tok = makeMultiCharToken(l, token.LogicalAnd, 1)
 		} else if l.peekChar(0) == '=' {
@@ -429,7 +827,10 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 			tok = newToken(token.BitwiseAnd, l.ch)
 		}
 	case '|':
-		if l.peekChar(0) == '|' {
+		if l.peekChar(0) == '|' && l.peekChar(1) == '=' {
+			// Logical OR assignment
+			tok = makeMultiCharToken(l, token.LogicalOrAssignment, 2)
+		} else if l.peekChar(0) == '|' {
 			// Logical OR
 			tok = makeMultiCharToken(l, token.LogicalOr, 1)
 		} else if l.peekChar(0) == '=' {
@@ -462,34 +863,75 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 	case '`':
 		// Template literal
 		if l.isInTemplateString() {
-			l.templateDepth -= 1
+			l.popContext()
 			tok = newToken(token.TemplateEnd, l.ch)
 		} else {
-			l.templateDepth += 1
+			l.pushContext(templateContext)
 			tok = newToken(token.TemplateStart, l.ch)
 		}
-	// TODO: regex
 
 	// EOF
 	case 0:
+		if !l.atEOF() {
+			// Step over the NUL byte, as over any other unexpected
+			// character
+			l.readChar()
+			return nil, l.errorf(lineStart, colStart, "Unexpected character '\\x00'")
+		}
+		if l.flowComment {
+			// Report the comment once, after which the input ends
+			pos := l.flowCommentPos
+			l.flowComment, l.flowCommentPos = false, token.Position{}
+			return nil, l.errorf(pos.Line, pos.Column, "Unterminated flow-comment")
+		}
 		tok.Type = token.TokenType{Label: token.EOF}
 		tok.Literal = ""
 		tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+		tok.Start, tok.End = start, l.position
 		return &tok, nil
 
 	default:
 		if l.isInTemplateString() && l.ch == '$' && l.peekChar(0) == '{' {
-			l.substitutionDepth += 1
+			l.pushContext(substitutionContext)
 			tok.Type = token.TokenType{Label: token.SubstitutionStart}
 			tok.Literal = "${"
 			tok.Loc = l.makeSourceLocation(lineStart, colStart, +1)
 			l.readChar()
 			l.readChar()
+			tok.Start, tok.End = start, l.position
+			return &tok, nil
+		} else if l.ch == '#' && l.peekChar(0) == '!' &&
+			(l.position == 0 || l.position == len("\ufeff") && strings.HasPrefix(l.input, "\ufeff")) {
+			// Hashbang comment, which may follow a byte order mark
+			tok.Literal = l.skipSingleLineComment()
+			if !l.comments {
+				return l.scan(goal)
+			}
+			tok.Type = token.TokenType{Label: token.LineComment}
+			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
+			return &tok, nil
+		} else if l.ch == '#' && l.isIdentifierStart(1) {
+			// Private name
+			l.readChar()
+			tok.Type = token.TokenType{Label: token.PrivateName}
+			tok.Literal = l.readIdentifier()
+			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
 			return &tok, nil
-		} else if isLetter(l.ch) {
+		} else if l.isIdentifierStart(0) {
 			tok.Literal = l.readIdentifier()
-			tok.Type = token.LookupIdent(tok.Literal)
+			if l.dialect == TypeScript {
+				tok.Type = token.LookupTSIdent(tok.Literal)
+			} else {
+				tok.Type = token.LookupIdent(tok.Literal)
+			}
+			if tok.Type.Label == token.Await && l.sourceType == Script {
+				// await is only reserved in module code
+				tok.Type = token.TokenType{Label: token.Identifier}
+			}
 			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
 			return &tok, nil
 		} else if isDigit(l.ch) {
 			tok.Type = token.TokenType{Label: token.Numeric}
@@ -499,16 +941,26 @@ func (l *Lexer) NextToken() (*token.Token, error) {
 				return nil, err
 			}
 			tok.Loc = l.makeSourceLocation(lineStart, colStart, -1)
+			tok.Start, tok.End = start, l.position
 			return &tok, nil
 		} else {
-			return nil, errors.New(fmt.Sprintf("SyntaxError: Unexpected character '%s' (%d:%d)",
-				string(l.ch), lineStart, colStart))
+			ch := l.ch
+			// Step over the offending character so that callers may resume.
+			l.readChar()
+			return nil, l.errorf(lineStart, colStart, "Unexpected character '%s'", string(ch))
 		}
 	}
 
 	tok.Loc = l.makeSourceLocation(lineStart, colStart, 0)
 
 	l.readChar()
+	tok.Start, tok.End = start, l.position
 
 	return &tok, nil
 }
+
+// Source returns the input being tokenized, from which the raw text of a
+// token can be sliced with its Start and End offsets.
+func (l *Lexer) Source() string {
+	return l.input
+}
@@ -237,6 +237,7 @@ func TestPunctuator(t *testing.T) {
 ,
 ?
 ?.
+@
 `
 
 	tests := []struct {
@@ -256,6 +257,7 @@ func TestPunctuator(t *testing.T) {
 		{makeTT(token.Comma), ","},
 		{makeTT(token.Question), "?"},
 		{makeTT(token.OptionalChaining), "?."},
+		{makeTT(token.At), "@"},
 	}
 
 	l := New(input)
@@ -682,7 +684,7 @@ console.log(gcd(1263262, 553443)); // 11
 func TestError(t *testing.T) {
 	inputs := []string{
 		"# ",
-		"@",
+		"\\",
 		"'123",
 		"\"foo",
 		"\n\"bar\n\"",
@@ -690,13 +692,18 @@ func TestError(t *testing.T) {
 		"\n0B2",
 		"0o8",
 		"0xyz",
+		"/* y",
+		"'\\x4' + 1",
+		"\"\\u{110000}\"",
+		"'a\\u00g0'",
+		"'\\u{}'",
 	}
 
 	tests := []struct {
 		expectedMessage string
 	}{
 		{"SyntaxError: Unexpected character '#' (0:0)"},
-		{"SyntaxError: Unexpected character '@' (0:0)"},
+		{"SyntaxError: Unexpected character '\\' (0:0)"},
 		{"SyntaxError: Unterminated string constant (0:0)"},
 		{"SyntaxError: Unterminated string constant (0:0)"},
 		{"SyntaxError: Unterminated string constant (1:0)"},
@@ -704,6 +711,11 @@ func TestError(t *testing.T) {
 		{"SyntaxError: Expected number in radix 2 (1:2)"},
 		{"SyntaxError: Expected number in radix 8 (0:2)"},
 		{"SyntaxError: Expected number in radix 16 (0:2)"},
+		{"SyntaxError: Unterminated comment (0:0)"},
+		{"SyntaxError: Bad character escape sequence (0:1)"},
+		{"SyntaxError: Bad character escape sequence (0:1)"},
+		{"SyntaxError: Bad character escape sequence (0:2)"},
+		{"SyntaxError: Bad character escape sequence (0:1)"},
 	}
 
 	for i, tt := range tests {
@@ -723,3 +735,366 @@ func TestError(t *testing.T) {
 		}()
 	}
 }
+
+func TestCommentToken(t *testing.T) {
+	input := `// line
+/* block
+   comment */ x /**/`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLoc     token.SourceLocation
+		expectedStart   int
+		expectedEnd     int
+	}{
+		{makeTT(token.LineComment), " line", makeLoc(0, 0, 0, 7), 0, 7},
+		{makeTT(token.BlockComment), " block\n   comment ", makeLoc(1, 0, 2, 13), 8, 30},
+		{makeTT(token.Identifier), "x", makeLoc(2, 14, 2, 15), 31, 32},
+		{makeTT(token.BlockComment), "", makeLoc(2, 16, 2, 20), 33, 37},
+		{makeTT(token.EOF), "", makeLoc(2, 20, 2, 20), 37, 37},
+	}
+
+	l := New(input, WithComments())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Loc != tt.expectedLoc {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLoc, tok.Loc)
+		}
+
+		if tok.Start != tt.expectedStart || tok.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - offsets wrong. expected=%d-%d, got=%d-%d",
+				i, tt.expectedStart, tt.expectedEnd, tok.Start, tok.End)
+		}
+	}
+}
+
+func TestSourceType(t *testing.T) {
+	tests := []struct {
+		sourceType   SourceType
+		expectedType token.TokenType
+	}{
+		{Module, makeTT(token.Await)},
+		{Script, makeTT(token.Identifier)},
+	}
+
+	for i, tt := range tests {
+		l := New("await", WithSourceType(tt.sourceType))
+
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := "a # b\n'c\nd"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedError   *SyntaxError
+	}{
+		{"a", nil},
+		{"", &SyntaxError{Message: "Unexpected character '#'", Line: 0, Column: 2, Offset: 2}},
+		{"b", nil},
+		{"", &SyntaxError{Message: "Unterminated string constant", Line: 1, Column: 0, Offset: 6}},
+		{"d", nil},
+		{"", nil},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+
+		if tt.expectedError != nil {
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("tests[%d] - expected *SyntaxError, got=%#v", i, err)
+			}
+			if *serr != *tt.expectedError {
+				t.Fatalf("tests[%d] - error wrong. expected=%+v, got=%+v",
+					i, *tt.expectedError, *serr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestErrorRecoveryNUL(t *testing.T) {
+	l := New("a\x00b")
+
+	tok, err := l.NextToken()
+	if err != nil || tok.Literal != "a" {
+		t.Fatalf("first token wrong. got=%+v, %v", tok, err)
+	}
+	_, err = l.NextToken()
+	expected := SyntaxError{Message: "Unexpected character '\\x00'", Line: 0, Column: 1, Offset: 1}
+	serr, ok := err.(*SyntaxError)
+	if !ok || *serr != expected {
+		t.Fatalf("error wrong. expected=%+v, got=%#v", expected, err)
+	}
+	tok, err = l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.Identifier) || tok.Literal != "b" || tok.Start != 2 {
+		t.Fatalf("token after NUL wrong. got=%+v", tok)
+	}
+	tok, err = l.NextToken()
+	if err != nil || tok.Type != makeTT(token.EOF) {
+		t.Fatalf("expected EOF, got=%+v, %v", tok, err)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	input := "`${a}${b}` + c"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.TemplateStart), "`"},
+		{makeTT(token.SubstitutionStart), "${"},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.SubstitutionEnd), "}"},
+		{makeTT(token.SubstitutionStart), "${"},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.SubstitutionEnd), "}"},
+		{makeTT(token.TemplateEnd), "`"},
+		{makeTT(token.Plus), "+"},
+		{makeTT(token.Identifier), "c"},
+	}
+
+	toks, err := Tokenize(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	if len(toks) != len(tests) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d", len(tests), len(toks))
+	}
+
+	for i, tt := range tests {
+		if toks[i].Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, toks[i].Type)
+		}
+
+		if toks[i].Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, toks[i].Literal)
+		}
+	}
+
+	toks, err = Tokenize("a b #")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if len(toks) != 2 {
+		t.Fatalf("wrong number of tokens before error. expected=2, got=%d", len(toks))
+	}
+}
+
+func TestWithFile(t *testing.T) {
+	fset := token.NewFileSet()
+	fset.AddFile("a.js", "a")
+	f := fset.AddFile("b.js", "x\n  = 1 #")
+
+	l := New(f.Source(), WithFile(f))
+	expected := []string{"b.js:1:1", "b.js:2:3", "b.js:2:5"}
+	for i, pos := range expected {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got := fset.Position(tok.Pos).String(); got != pos {
+			t.Fatalf("tests[%d] - position wrong. expected=%q, got=%q", i, pos, got)
+		}
+		if got := fset.Token(tok.Compact()); got != *tok {
+			t.Fatalf("tests[%d] - token wrong. expected=%+v, got=%+v", i, *tok, got)
+		}
+	}
+
+	_, err := l.NextToken()
+	serr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected a syntax error, got=%v", err)
+	}
+	if got := fset.Position(serr.Pos).String(); got != "b.js:2:7" {
+		t.Fatalf("error position wrong. expected=%q, got=%q", "b.js:2:7", got)
+	}
+}
+
+func TestAll(t *testing.T) {
+	l := New("a b c")
+
+	var literals []string
+	for tok, err := range l.All() {
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		literals = append(literals, tok.Literal)
+		if tok.Literal == "b" {
+			break
+		}
+	}
+
+	if len(literals) != 2 {
+		t.Fatalf("wrong number of tokens. expected=2, got=%d", len(literals))
+	}
+
+	// Iteration resumes where it stopped
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Literal != "c" {
+		t.Fatalf("literal wrong. expected=%q, got=%q", "c", tok.Literal)
+	}
+
+	for i := 0; i < 2; i++ {
+		tok, err = l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		if tok.Type != makeTT(token.EOF) {
+			t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.EOF), tok.Type)
+		}
+	}
+}
+
+func TestModernSyntax(t *testing.T) {
+	input := "#!/usr/bin/env node\n" + `
+1e10 1.5E-3 .5e+2 2e 1_000_000 0xFF_FF 123n 0b1n
+a ||= b &&= c ??= d
+a?.b ?.5:c
+#x
+"\\" '\'' "a\
+b"
+` + "`\\${\\``" + `
+café ｘ\u0061
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.Numeric), "1e10"},
+		{makeTT(token.Numeric), "1.5E-3"},
+		{makeTT(token.Numeric), ".5e+2"},
+		{makeTT(token.Numeric), "2"},
+		{makeTT(token.Identifier), "e"},
+		{makeTT(token.Numeric), "1_000_000"},
+		{makeTT(token.Numeric), "0xFF_FF"},
+		{makeTT(token.Numeric), "123n"},
+		{makeTT(token.Numeric), "0b1n"},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.LogicalOrAssignment), "||="},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.LogicalAndAssignment), "&&="},
+		{makeTT(token.Identifier), "c"},
+		{makeTT(token.NullishCoalescingAssignment), "??="},
+		{makeTT(token.Identifier), "d"},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.OptionalChaining), "?."},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.Question), "?"},
+		{makeTT(token.Numeric), ".5"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.Identifier), "c"},
+		{makeTT(token.PrivateName), "x"},
+		{makeTT(token.String), "\\\\"},
+		{makeTT(token.String), "'"},
+		{makeTT(token.String), "a\\\nb"},
+		{makeTT(token.TemplateStart), "`"},
+		{makeTT(token.String), "\\${\\`"},
+		{makeTT(token.TemplateEnd), "`"},
+		{makeTT(token.Identifier), "café"},
+		{makeTT(token.Identifier), "ｘ\\u0061"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestSourceMappingURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a\n//# sourceMappingURL=a.js.map", "a.js.map"},
+		{"a\n//@ sourceMappingURL=old.map\n", "old.map"},
+		{"/*# sourceMappingURL=block.map */ a", "block.map"},
+		{"//# sourceMappingURL=first.map\n//# sourceMappingURL=last.map", "last.map"},
+		{"'//# sourceMappingURL=string.map'", ""},
+		{"// # sourceMappingURL=spaced.map", ""},
+		{"a", ""},
+	}
+
+	for i, tt := range tests {
+		for _, opts := range [][]Option{nil, {WithComments()}} {
+			l := New(tt.input, opts...)
+			for {
+				tok, err := l.NextToken()
+				if err != nil {
+					t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+				}
+				if tok.Type.Label == token.EOF {
+					break
+				}
+			}
+			if l.SourceMappingURL() != tt.expected {
+				t.Fatalf("tests[%d] - URL wrong. expected=%q, got=%q", i, tt.expected, l.SourceMappingURL())
+			}
+		}
+	}
+}
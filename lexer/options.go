@@ -0,0 +1,74 @@
+package lexer
+
+import "github.com/morinokami/js-lexer/token"
+
+// Option configures a Lexer created by New.
+type Option func(*Lexer)
+
+// SourceType is the goal symbol the input is lexed for.
+type SourceType int
+
+const (
+	Module SourceType = iota
+	Script
+)
+
+// Dialect is the language the input is written in.
+type Dialect int
+
+const (
+	JavaScript Dialect = iota
+	// TypeScript classifies its contextual keywords with
+	// token.LookupTSIdent, and reads a '!' right after an expression as a
+	// non-null assertion.
+	TypeScript
+	// Flow reads the contents of the comment types of Flow, /*:: ... */
+	// and /*: ... */, as tokens instead of skipping them as comments.
+	Flow
+)
+
+// WithComments makes NextToken return comments as LineComment and
+// BlockComment tokens instead of skipping them. The literal of a comment
+// token is its text without the delimiters.
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.comments = true
+	}
+}
+
+// WithSourceType sets whether the input is lexed as a module (the default)
+// or as a classic script, in which await is an ordinary identifier and
+// HTML-like comments, <!-- and --> at the start of a line, are allowed.
+func WithSourceType(sourceType SourceType) Option {
+	return func(l *Lexer) {
+		l.sourceType = sourceType
+	}
+}
+
+// SourceType returns the source type the lexer was created with.
+func (l *Lexer) SourceType() SourceType {
+	return l.sourceType
+}
+
+// WithDialect sets the language the input is lexed as, JavaScript by
+// default.
+func WithDialect(dialect Dialect) Option {
+	return func(l *Lexer) {
+		l.dialect = dialect
+	}
+}
+
+// Dialect returns the dialect the lexer was created with.
+func (l *Lexer) Dialect() Dialect {
+	return l.dialect
+}
+
+// WithFile sets the file of the input in a token.FileSet, which must have
+// been added with the input, so that the tokens and syntax errors carry
+// their position in the set. It is not meant for a Document, whose input
+// changes.
+func WithFile(f *token.File) Option {
+	return func(l *Lexer) {
+		l.file = f
+	}
+}
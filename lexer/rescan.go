@@ -0,0 +1,144 @@
+package lexer
+
+import (
+	"errors"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Goal selects how input whose tokenization depends on the syntactic
+// context is read, after the lexical goal symbols of the ECMAScript
+// specification.
+type Goal int
+
+const (
+	// GoalDiv reads '/' as the division operator and '}' as closing a brace
+	// or a template substitution, according to the braces seen so far
+	// (InputElementDiv).
+	GoalDiv Goal = iota
+	// GoalRegExp reads '/' as the start of a regular expression literal
+	// (InputElementRegExp) and, with WithJSX, '<' as the start of a JSX
+	// element.
+	GoalRegExp
+	// GoalTemplateTail reads '}' as the end of a template substitution
+	// (InputElementTemplateTail).
+	GoalTemplateTail
+	// GoalTypeArguments reads '>' as a token of its own, even where it
+	// starts '>>', '>>>', or '>=', as where it closes a TypeScript list of
+	// type arguments like Array<Array<T>>.
+	GoalTypeArguments
+)
+
+// WithRegExpHeuristic makes NextToken read '/' as the start of a regular
+// expression literal whenever the previous token cannot end an expression.
+// The guess is wrong in a few places, such as after the parenthesized
+// condition of an if statement; parsers should use NextTokenWithGoal or the
+// rescanning methods instead.
+func WithRegExpHeuristic() Option {
+	return func(l *Lexer) {
+		l.regExpHeuristic = true
+	}
+}
+
+// regExpAllowedAfter reports whether a token following one with the given
+// label may be a regular expression literal.
+func regExpAllowedAfter(label string) bool {
+	switch label {
+	case token.Identifier, token.Numeric, token.String, token.RegExp,
+		token.RParen, token.RBracket, token.TemplateEnd,
+		token.Increment, token.Decrement,
+		token.This, token.Super, token.Null, token.True, token.False,
+		token.JSXTagEnd, token.NonNull:
+		return false
+	}
+	// Contextual keywords are mostly used as identifiers.
+	return !token.IsContextualKeyword(label)
+}
+
+// ReScanAsRegExp reads tok, which must be the '/' or '/=' token most
+// recently returned, again as a regular expression literal.
+func (l *Lexer) ReScanAsRegExp(tok *token.Token) (*token.Token, error) {
+	if tok.Type.Label != token.Slash && tok.Type.Label != token.DivisionAssignment {
+		return nil, errors.New("lexer: token is not '/' or '/='")
+	}
+	return l.reScan(tok.Start, tok.Loc.Start, GoalRegExp)
+}
+
+// ReScanTemplateContinuation reads tok, which must be the '}' token most
+// recently returned, again as the end of a template substitution, so that
+// the lexer continues with the rest of the template literal.
+func (l *Lexer) ReScanTemplateContinuation(tok *token.Token) (*token.Token, error) {
+	if tok.Type.Label != token.RBrace && tok.Type.Label != token.SubstitutionEnd {
+		return nil, errors.New("lexer: token is not '}'")
+	}
+	return l.reScan(tok.Start, tok.Loc.Start, GoalTemplateTail)
+}
+
+// ReScanSlashToken is like ReScanAsRegExp for the last token returned.
+func (l *Lexer) ReScanSlashToken() (*token.Token, error) {
+	if l.last.label != token.Slash && l.last.label != token.DivisionAssignment {
+		return nil, errors.New("lexer: last token is not '/' or '/='")
+	}
+	return l.reScan(l.last.start, l.last.pos, GoalRegExp)
+}
+
+// ReScanTemplateToken is like ReScanTemplateContinuation for the last token
+// returned.
+func (l *Lexer) ReScanTemplateToken() (*token.Token, error) {
+	if l.last.label != token.RBrace && l.last.label != token.SubstitutionEnd {
+		return nil, errors.New("lexer: last token is not '}'")
+	}
+	return l.reScan(l.last.start, l.last.pos, GoalTemplateTail)
+}
+
+// ReScanLessThanToken reads the last token returned, which must be '<',
+// again as the start of a JSX element. Parsers call it where an expression
+// may start, after which the lexer tracks the tags and children of the
+// element by itself. It requires WithJSX.
+func (l *Lexer) ReScanLessThanToken() (*token.Token, error) {
+	if !l.jsx {
+		return nil, errors.New("lexer: JSX is not enabled")
+	}
+	if l.last.label != token.LT {
+		return nil, errors.New("lexer: last token is not '<'")
+	}
+	return l.reScan(l.last.start, l.last.pos, GoalRegExp)
+}
+
+// ReScanGreaterThanToken reads the last token returned, which must start
+// with '>', again as a single '>', so that the rest of it is read as the
+// following tokens. Parsers call it where a list of type arguments ends.
+func (l *Lexer) ReScanGreaterThanToken() (*token.Token, error) {
+	switch l.last.label {
+	case token.GT, token.GTEq, token.RightShift, token.UnsignedRightShift,
+		token.RightShiftAssignment, token.UnsignedRightShiftAssignment:
+	default:
+		return nil, errors.New("lexer: last token does not start with '>'")
+	}
+	return l.reScan(l.last.start, l.last.pos, GoalTypeArguments)
+}
+
+// reScan reads the last token, which starts at offset and pos, again for
+// goal.
+func (l *Lexer) reScan(offset int, pos token.Position, goal Goal) (*token.Token, error) {
+	if offset != l.last.start {
+		return nil, errors.New("lexer: token is not the last token returned")
+	}
+
+	// Undo the effect of the last token on the context stack.
+	if l.last.popped != noContext {
+		l.pushContext(l.last.popped)
+		l.last.popped = noContext
+	}
+	l.seek(offset, pos)
+	return l.NextTokenWithGoal(goal)
+}
+
+// seek moves the lexer to offset, whose position in the input is pos.
+func (l *Lexer) seek(offset int, pos token.Position) {
+	l.readPosition = offset
+	l.line = pos.Line
+	l.column = pos.Column
+	l.ch = 0
+	l.readChar()
+}
@@ -0,0 +1,269 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestReScanSlashToken(t *testing.T) {
+	input := "x = /[/]=\\/+/g.test(y)"
+
+	l := New(input)
+	for i := 0; i < 3; i++ {
+		if _, err := l.NextToken(); err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+	}
+
+	tok, err := l.ReScanSlashToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	if tok.Type != makeTT(token.RegExp) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.RegExp), tok.Type)
+	}
+
+	if tok.Literal != "/[/]=\\/+/g" {
+		t.Fatalf("literal wrong. expected=%q, got=%q", "/[/]=\\/+/g", tok.Literal)
+	}
+
+	if tok.Loc != makeLoc(0, 4, 0, 14) || tok.Start != 4 || tok.End != 14 {
+		t.Fatalf("location wrong. got=%+v %d-%d", tok.Loc, tok.Start, tok.End)
+	}
+
+	tok, err = l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.Dot) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.Dot), tok.Type)
+	}
+
+	l = New("x /a")
+	l.NextToken()
+	if _, err := l.ReScanSlashToken(); err == nil {
+		t.Fatalf("expected an error rescanning %q", "x")
+	}
+	l.NextToken()
+	if _, err := l.ReScanSlashToken(); err == nil {
+		t.Fatalf("expected an error rescanning an unterminated regular expression")
+	}
+}
+
+func TestReScanTemplateToken(t *testing.T) {
+	l := New("a } b`")
+	l.NextToken()
+	l.NextToken()
+
+	tok, err := l.ReScanTemplateToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.SubstitutionEnd) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.SubstitutionEnd), tok.Type)
+	}
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.String), " b"},
+		{makeTT(token.TemplateEnd), "`"},
+		{makeTT(token.EOF), ""},
+	}
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestReScanAsRegExp(t *testing.T) {
+	input := "if (x) /=a/.test(y)"
+
+	l := New(input)
+	var tok *token.Token
+	for i := 0; i < 5; i++ {
+		var err error
+		tok, err = l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+	}
+	if tok.Type != makeTT(token.DivisionAssignment) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.DivisionAssignment), tok.Type)
+	}
+
+	tok, err := l.ReScanAsRegExp(tok)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.RegExp) || tok.Literal != "/=a/" {
+		t.Fatalf("token wrong. got=%+v", tok)
+	}
+
+	next, err := l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if _, err := l.ReScanAsRegExp(tok); err == nil {
+		t.Fatalf("expected an error rescanning a regular expression")
+	}
+	if _, err := l.ReScanAsRegExp(&token.Token{Type: makeTT(token.Slash), Start: 0}); err == nil {
+		t.Fatalf("expected an error rescanning a token other than the last one")
+	}
+	if next.Type != makeTT(token.Dot) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.Dot), next.Type)
+	}
+}
+
+func TestReScanTemplateContinuation(t *testing.T) {
+	input := "{ a: `${ b } c` }"
+
+	l := New(input)
+	var tok *token.Token
+	for i := 0; i < 7; i++ {
+		var err error
+		tok, err = l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+	}
+	if tok.Type != makeTT(token.SubstitutionEnd) {
+		t.Fatalf("tokentype wrong. expected=%+v, got=%+v", makeTT(token.SubstitutionEnd), tok.Type)
+	}
+
+	// Rescanning a token that already ends a substitution changes nothing
+	tok, err := l.ReScanTemplateContinuation(tok)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.String), " c"},
+		{makeTT(token.TemplateEnd), "`"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.EOF), ""},
+	}
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenWithGoal(t *testing.T) {
+	input := "/a/ / } b`"
+
+	tests := []struct {
+		goal            Goal
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{GoalRegExp, makeTT(token.RegExp), "/a/"},
+		{GoalDiv, makeTT(token.Slash), "/"},
+		{GoalTemplateTail, makeTT(token.SubstitutionEnd), "}"},
+		{GoalDiv, makeTT(token.String), " b"},
+		{GoalDiv, makeTT(token.TemplateEnd), "`"},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok, err := l.NextTokenWithGoal(tt.goal)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestRegExpHeuristic(t *testing.T) {
+	input := `x = a / b / /c/g; // d
+return /e/.test(f)[0] / 2`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.Identifier), "x"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.RegExp), "/c/g"},
+		{makeTT(token.Semicolon), ";"},
+		{makeTT(token.LineComment), " d"},
+		{makeTT(token.Return), "return"},
+		{makeTT(token.RegExp), "/e/"},
+		{makeTT(token.Dot), "."},
+		{makeTT(token.Identifier), "test"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.Identifier), "f"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.LBracket), "["},
+		{makeTT(token.Numeric), "0"},
+		{makeTT(token.RBracket), "]"},
+		{makeTT(token.Slash), "/"},
+		{makeTT(token.Numeric), "2"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input, WithRegExpHeuristic(), WithComments())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package lexer
+
+import (
+	"slices"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// lastToken records what the rescanning methods need to know about the
+// token most recently returned by NextToken.
+type lastToken struct {
+	label  string
+	start  int
+	pos    token.Position
+	popped context
+}
+
+// Snapshot is the state of a Lexer at some point of the input, which can be
+// returned to with Restore.
+type Snapshot struct {
+	position     int
+	readPosition int
+	ch           byte
+	line         int
+	column       int
+	stack        []context
+	last         lastToken
+	flowComment  bool
+	flowPos      token.Position
+	strict       bool
+	strictScope  int
+	directives   directives
+	mappingURL   string
+}
+
+// Snapshot captures the current state of the lexer.
+func (l *Lexer) Snapshot() Snapshot {
+	return Snapshot{
+		position:     l.position,
+		readPosition: l.readPosition,
+		ch:           l.ch,
+		line:         l.line,
+		column:       l.column,
+		stack:        slices.Clone(l.stack),
+		last:         l.last,
+		flowComment:  l.flowComment,
+		flowPos:      l.flowCommentPos,
+		strict:       l.strict,
+		strictScope:  l.strictScope,
+		directives:   l.directives.clone(),
+		mappingURL:   l.sourceMappingURL,
+	}
+}
+
+// Restore returns the lexer to the state captured by s, so that the tokens
+// following the snapshot are returned again.
+func (l *Lexer) Restore(s Snapshot) {
+	l.position = s.position
+	l.readPosition = s.readPosition
+	l.ch = s.ch
+	l.line = s.line
+	l.column = s.column
+	l.stack = slices.Clone(s.stack)
+	l.last = s.last
+	l.flowComment = s.flowComment
+	l.flowCommentPos = s.flowPos
+	l.strict = s.strict
+	l.strictScope = s.strictScope
+	l.directives = s.directives.clone()
+	l.sourceMappingURL = s.mappingURL
+}
+
+// Peek returns the token n positions ahead without consuming any input:
+// Peek(0) returns the token the next call to NextToken will return.
+func (l *Lexer) Peek(n int) (*token.Token, error) {
+	s := l.Snapshot()
+	defer l.Restore(s)
+
+	var tok *token.Token
+	var err error
+	for i := 0; i <= n; i++ {
+		tok, err = l.NextToken()
+		if tok == nil {
+			return nil, err
+		}
+	}
+	return tok, err
+}
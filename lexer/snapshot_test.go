@@ -0,0 +1,125 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestPeek(t *testing.T) {
+	l := New("(a, b) => a")
+
+	tests := []struct {
+		n               int
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{0, makeTT(token.LParen), "("},
+		{5, makeTT(token.Arrow), "=>"},
+		{6, makeTT(token.Identifier), "a"},
+		{7, makeTT(token.EOF), ""},
+	}
+
+	for i, tt := range tests {
+		tok, err := l.Peek(tt.n)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.LParen) {
+		t.Fatalf("Peek consumed input. got=%+v", tok.Type)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	input := "`a${ {b: 1} }c${d}`"
+	l := New(input)
+
+	var s Snapshot
+	var expected []token.Token
+	for i := 0; ; i++ {
+		if i == 6 {
+			// Inside the braces of the first substitution
+			s = l.Snapshot()
+		}
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		if i >= 6 {
+			expected = append(expected, *tok)
+		}
+		if tok.Type.Label == token.EOF {
+			break
+		}
+	}
+
+	l.Restore(s)
+
+	for i, want := range expected {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if *tok != want {
+			t.Fatalf("tests[%d] - token wrong. expected=%+v, got=%+v", i, want, *tok)
+		}
+	}
+}
+
+func TestNestedBraceInSubstitution(t *testing.T) {
+	input := "`a${ {b: 1} }c`"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.TemplateStart), "`"},
+		{makeTT(token.String), "a"},
+		{makeTT(token.SubstitutionStart), "${"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.Numeric), "1"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.SubstitutionEnd), "}"},
+		{makeTT(token.String), "c"},
+		{makeTT(token.TemplateEnd), "`"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
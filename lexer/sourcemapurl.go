@@ -0,0 +1,26 @@
+package lexer
+
+import "strings"
+
+// SourceMappingURL returns the URL of the source map of the input, as given
+// by the last sourceMappingURL comment read so far, such as
+// //# sourceMappingURL=app.js.map, or "" if there has been none.
+func (l *Lexer) SourceMappingURL() string {
+	return l.sourceMappingURL
+}
+
+// readSourceMappingURL records the URL of a sourceMappingURL comment, given
+// the text of a comment without its delimiters. The obsolete form with '@'
+// instead of '#' is accepted too.
+func (l *Lexer) readSourceMappingURL(comment string) {
+	if len(comment) == 0 || comment[0] != '#' && comment[0] != '@' {
+		return
+	}
+	rest, ok := strings.CutPrefix(comment[1:], " sourceMappingURL=")
+	if !ok {
+		return
+	}
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		l.sourceMappingURL = fields[0]
+	}
+}
@@ -0,0 +1,198 @@
+package lexer
+
+import (
+	"strings"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// WithDetectedSourceType makes the lexer lex the input as the source type
+// DetectSourceType detects for it, overriding WithSourceType.
+func WithDetectedSourceType() Option {
+	return func(l *Lexer) {
+		l.autoSourceType = true
+	}
+}
+
+// DetectSourceType reports whether src must be a module, because it
+// contains an import or export declaration, import.meta, or an await
+// expression outside of functions, or may be a classic script. Dynamic
+// import() calls are allowed in both. The options are those src would be
+// lexed with, such as WithJSX.
+func DetectSourceType(src string, opts ...Option) SourceType {
+	return New(src, opts...).detectSourceType()
+}
+
+// detectSourceType pre-scans the input of a lexer that has not yet read
+// any of it with a copy of the lexer's configuration.
+func (l *Lexer) detectSourceType() SourceType {
+	s := &Lexer{
+		input:      l.input,
+		sourceType: Module,
+		dialect:    l.dialect,
+		jsx:        l.jsx,
+		// Regular expressions could contain anything.
+		regExpHeuristic: true,
+	}
+	s.readChar()
+
+	// Stack of open brackets, with the function bodies and class static
+	// blocks among the braces marked, to tell what is at the top level
+	type bracket struct {
+		label    string
+		function bool
+		// Token before an opening parenthesis
+		before string
+	}
+	var brackets []bracket
+	inFunction := func() bool {
+		for _, b := range brackets {
+			if b.function {
+				return true
+			}
+		}
+		return false
+	}
+
+	var prev string
+	// Whether the previous token is the identifier static
+	var prevStatic bool
+	// Token before the parenthesis most recently closed
+	var closedParenBefore string
+	for {
+		tok, _ := s.NextToken()
+		if tok == nil || tok.Type.Label == token.EOF {
+			return Script
+		}
+		label := tok.Type.Label
+		afterDot := prev == token.Dot || prev == token.OptionalChaining
+
+		switch label {
+		case token.LParen, token.LBracket:
+			brackets = append(brackets, bracket{label: label, before: prev})
+		case token.LBrace:
+			// await is an identifier in a static block, as in a function
+			function := prev == token.Arrow || prevStatic ||
+				prev == token.RParen && startsFunctionBody(closedParenBefore)
+			brackets = append(brackets, bracket{label: label, function: function})
+		case token.RParen, token.RBracket, token.RBrace, token.SubstitutionEnd:
+			if len(brackets) > 0 {
+				if label == token.RParen {
+					closedParenBefore = brackets[len(brackets)-1].before
+				}
+				brackets = brackets[:len(brackets)-1]
+			}
+		case token.SubstitutionStart:
+			brackets = append(brackets, bracket{label: label})
+		case token.Import:
+			if afterDot {
+				break
+			}
+			next, _ := s.Peek(0)
+			if next == nil {
+				return Script
+			}
+			switch next.Type.Label {
+			case token.LParen:
+				// Dynamic import
+			case token.Dot:
+				// import.meta
+				return Module
+			default:
+				if len(brackets) == 0 {
+					return Module
+				}
+			}
+		case token.Export:
+			if !afterDot && len(brackets) == 0 {
+				return Module
+			}
+		case token.Await:
+			if afterDot || inFunction() {
+				break
+			}
+			if prev == token.For {
+				// for await
+				return Module
+			}
+			next, _ := s.Peek(0)
+			if next == nil {
+				return Script
+			}
+			if startsAwaitOperand(next.Type.Label) && next.Loc.Start.Line == tok.Loc.End.Line {
+				return Module
+			}
+		}
+		prev = label
+		prevStatic = label == token.Identifier && tok.Literal == "static"
+	}
+}
+
+// startsFunctionBody reports whether a '{' following a parameter list whose
+// '(' came after a token labeled before opens a function body, as opposed
+// to the body of a statement like if or while.
+func startsFunctionBody(before string) bool {
+	switch before {
+	case token.If, token.For, token.While, token.Switch, token.Catch, token.With:
+		return false
+	}
+	return true
+}
+
+// startsAwaitOperand reports whether a token labeled label following await
+// makes it an await expression rather than an identifier. '(' and '[' are
+// left out, as they may follow an identifier too.
+func startsAwaitOperand(label string) bool {
+	switch label {
+	case token.Identifier, token.Numeric, token.String, token.RegExp,
+		token.TemplateStart, token.This, token.Super, token.Null, token.True,
+		token.False, token.New, token.Function, token.Class, token.Typeof,
+		token.Void, token.Delete, token.Bang, token.Tilde, token.Await,
+		token.Import, token.PrivateName:
+		return true
+	}
+	return token.IsContextualKeyword(label)
+}
+
+// isHTMLComment reports whether an HTML-like comment, which scripts allow
+// for compatibility, starts at the current position: <!-- anywhere, or -->
+// at the start of a line.
+func (l *Lexer) isHTMLComment() bool {
+	if l.sourceType != Script {
+		return false
+	}
+	rest := l.input[l.position:]
+	if strings.HasPrefix(rest, "<!--") {
+		return true
+	}
+	if !strings.HasPrefix(rest, "-->") {
+		return false
+	}
+	for i := l.position - 1; i >= 0; i-- {
+		switch l.input[i] {
+		case ' ', '\t':
+			continue
+		case '\n', '\r':
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// skipHTMLComment skips an HTML-like comment, which extends to the end of
+// the line like a single line comment, and returns its text.
+func (l *Lexer) skipHTMLComment() string {
+	n := len("-->")
+	if l.ch == '<' {
+		n = len("<!--")
+	}
+	for i := 0; i < n; i++ {
+		l.readChar()
+	}
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return l.input[position:l.position]
+}
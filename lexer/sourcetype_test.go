@@ -0,0 +1,150 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestDetectSourceType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SourceType
+	}{
+		{"", Script},
+		{"var x = 1;", Script},
+		{"import x from 'x';", Module},
+		{"import 'x';", Module},
+		{"import {a, b as c} from 'x'", Module},
+		{"export default 1", Module},
+		{"export const a = 1", Module},
+		{"// comment\n/* comment */ export {}", Module},
+		{"import('x').then(f)", Script},
+		{"const m = await import('x')", Module},
+		{"import.meta.url", Module},
+		{"function f() { return import.meta }", Module},
+		{"a.import; b?.export", Script},
+		{"({ import: 1, export: 2 })", Script},
+		{"x = { import() {} }", Script},
+		{"await fetch(u)", Module},
+		{"for await (const x of xs) {}", Module},
+		{"async function f() { await g() }", Script},
+		{"const f = async () => { await g() }", Script},
+		{"class A { async m() { await g() } }", Script},
+		{"if (a) { await g() }", Module},
+		{"class A { static { await x } }", Script},
+		{"class A { static { f() } }; await x", Module},
+		{"await(1)", Script},
+		{"var await = 1; await = 2", Script},
+		{"obj.await", Script},
+		{"x = `${await y}`", Module},
+		{"/import x from 'x'/.test(s)", Script},
+		{"'export default 1'", Script},
+		{"import x from", Module},
+		{"#", Script},
+	}
+
+	for i, tt := range tests {
+		got := DetectSourceType(tt.input)
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - source type of %q wrong. expected=%d, got=%d",
+				i, tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestWithDetectedSourceType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SourceType
+	}{
+		{"await x", Module},
+		{"await\nx", Script},
+		{"let a = 1", Script},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input, WithSourceType(Module), WithDetectedSourceType())
+		if l.SourceType() != tt.expected {
+			t.Fatalf("tests[%d] - source type wrong. expected=%d, got=%d",
+				i, tt.expected, l.SourceType())
+		}
+	}
+}
+
+func TestHTMLComment(t *testing.T) {
+	input := `<!-- hidden
+x = y-->z;
+  --> also hidden
+a <!--b`
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLocation token.SourceLocation
+	}{
+		{makeTT(token.LineComment), " hidden", makeLoc(0, 0, 0, 11)},
+		{makeTT(token.Identifier), "x", makeLoc(1, 0, 1, 1)},
+		{makeTT(token.Assignment), "=", makeLoc(1, 2, 1, 3)},
+		{makeTT(token.Identifier), "y", makeLoc(1, 4, 1, 5)},
+		// Not at the start of a line
+		{makeTT(token.Decrement), "--", makeLoc(1, 5, 1, 7)},
+		{makeTT(token.GT), ">", makeLoc(1, 7, 1, 8)},
+		{makeTT(token.Identifier), "z", makeLoc(1, 8, 1, 9)},
+		{makeTT(token.Semicolon), ";", makeLoc(1, 9, 1, 10)},
+		{makeTT(token.LineComment), " also hidden", makeLoc(2, 2, 2, 17)},
+		{makeTT(token.Identifier), "a", makeLoc(3, 0, 3, 1)},
+		{makeTT(token.LineComment), "b", makeLoc(3, 2, 3, 7)},
+		{makeTT(token.EOF), "", makeLoc(3, 7, 3, 7)},
+	}
+
+	l := New(input, WithSourceType(Script), WithComments())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, tok.Loc)
+		}
+	}
+}
+
+func TestHTMLCommentInModule(t *testing.T) {
+	expected := []string{
+		token.LT, token.Bang, token.Decrement, token.Identifier,
+		token.Decrement, token.GT, token.Identifier, token.EOF,
+	}
+
+	l := New("<!--x\n-->y")
+	for i, label := range expected {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if tok.Type != makeTT(label) {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, label, tok.Type.Label)
+		}
+	}
+
+	tokens, err := Tokenize("<!--x\n-->y", WithSourceType(Script))
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if len(tokens) != 0 {
+		t.Fatalf("expected the script to be all comments, got=%+v", tokens)
+	}
+}
@@ -0,0 +1,332 @@
+package lexer
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// WithStrict makes the lexer apply the rules of strict mode code from the
+// start of the input, as for the body of a module. Otherwise strict mode
+// starts at a "use strict" directive or a class.
+func WithStrict() Option {
+	return func(l *Lexer) {
+		l.strict = true
+	}
+}
+
+// Strict reports whether the lexer is lexing strict mode code, in which
+// legacy octal literals, octal escape sequences, and the words reserved in
+// strict mode are errors.
+func (l *Lexer) Strict() bool {
+	return l.strict
+}
+
+// directives follows the directive prologues of the script and of the
+// function bodies in it, and the class bodies, so that the lexer can switch
+// to strict mode at a "use strict" directive or a class body.
+type directives struct {
+	// Whether the next token may be part of a directive prologue
+	prologue bool
+	// Length of the context stack inside the body the prologue starts
+	scope int
+	// String literal that is a directive if the statement ends after it
+	candidate *token.Token
+	// First octal escape sequence in the prologue, which is an error if
+	// the prologue turns out to contain "use strict"
+	octal error
+
+	// Tokens before the open parentheses, and before the parenthesis most
+	// recently closed, to tell function bodies from blocks
+	parens      []string
+	closedParen string
+
+	// Length of the context stack inside the body of the class whose class
+	// keyword was read last, until the body opens
+	class int
+	// Lengths of the context stack inside the open class bodies
+	classBodies []int
+
+	// Whether a '{' opens an import or export clause, after import or
+	// export and the default binding of an import
+	clauseNext bool
+	// Length of the context stack inside the open import or export
+	// clause, or 0
+	clause int
+}
+
+func (d directives) clone() directives {
+	d.parens = slices.Clone(d.parens)
+	d.classBodies = slices.Clone(d.classBodies)
+	return d
+}
+
+// inClassBody reports whether the lexer is directly inside a class body,
+// where identifiers name the members of the class.
+func (l *Lexer) inClassBody() bool {
+	d := &l.directives
+	return len(d.classBodies) > 0 && d.classBodies[len(d.classBodies)-1] == len(l.stack)
+}
+
+// strictReserved is the words that are only reserved in strict mode code,
+// and so are lexed as identifiers.
+var strictReserved = map[string]bool{
+	"implements": true,
+	"interface":  true,
+	"let":        true,
+	"package":    true,
+	"private":    true,
+	"protected":  true,
+	"public":     true,
+	"static":     true,
+}
+
+// trackDirectives updates the lexer's strictness after it has read tok and
+// checks tok against the rules of strict mode. As a directive only ends
+// with the token after it, strict mode starts with that token. It is called
+// before l.last is updated with tok.
+func (l *Lexer) trackDirectives(tok *token.Token) error {
+	d := &l.directives
+	prev := l.last.label
+
+	if c := d.candidate; c != nil {
+		d.candidate = nil
+		if !endsDirective(tok, c) {
+			d.prologue = false
+		} else if l.input[c.Start+1:c.End-1] == "use strict" && !l.strict {
+			l.strict = true
+			l.strictScope = d.scope
+			if d.octal != nil {
+				return d.octal
+			}
+		}
+		if d.prologue && tok.Type.Label == token.Semicolon {
+			return nil
+		}
+	}
+	if l.strict && l.strictScope > 0 && len(l.stack) < l.strictScope && d.class != l.strictScope {
+		// End of the strict function or class body
+		l.strict = false
+		l.strictScope = 0
+	}
+
+	if d.prologue {
+		if tok.Type.Label == token.String && prev != token.TemplateStart && prev != token.SubstitutionEnd {
+			d.candidate = tok
+			if !l.strict && d.octal == nil {
+				d.octal = l.checkStrictString(tok)
+			}
+		} else {
+			d.prologue = false
+		}
+	}
+
+	clauseNext := false
+	switch tok.Type.Label {
+	case token.Import, token.Export:
+		clauseNext = prev != token.Dot && prev != token.OptionalChaining
+	case token.Identifier, token.Comma:
+		clauseNext = d.clauseNext
+	case token.LParen:
+		d.parens = append(d.parens, prev)
+	case token.RParen:
+		if len(d.parens) > 0 {
+			d.closedParen = d.parens[len(d.parens)-1]
+			d.parens = d.parens[:len(d.parens)-1]
+		}
+	case token.Class:
+		if prev != token.Dot && prev != token.OptionalChaining {
+			// The whole class, from its name on, is strict mode code
+			d.class = len(l.stack) + 1
+			if !l.strict {
+				l.strict = true
+				l.strictScope = d.class
+			}
+		}
+	case token.RBrace:
+		for len(d.classBodies) > 0 && d.classBodies[len(d.classBodies)-1] > len(l.stack) {
+			d.classBodies = d.classBodies[:len(d.classBodies)-1]
+		}
+		if d.class > len(l.stack)+1 {
+			// The class never got a body
+			d.class = 0
+		}
+		if d.clause > len(l.stack) {
+			d.clause = 0
+		}
+	case token.LBrace:
+		if d.clauseNext {
+			d.clause = len(l.stack)
+		} else if d.class == len(l.stack) && prev != token.Extends {
+			// Class body. A brace after extends opens an object literal in
+			// the heritage.
+			d.class = 0
+			d.classBodies = append(d.classBodies, len(l.stack))
+		} else if prev == token.Arrow || prev == token.RParen && startsFunctionBody(d.closedParen) {
+			d.prologue = true
+			d.scope = len(l.stack)
+			d.octal = nil
+		}
+	}
+	d.clauseNext = clauseNext
+
+	if !l.strict {
+		return nil
+	}
+	switch tok.Type.Label {
+	case token.Numeric:
+		if len(tok.Literal) > 1 && tok.Literal[0] == '0' && isDigit(tok.Literal[1]) {
+			if isLegacyOctal(tok.Literal) {
+				return l.errorf(tok.Loc.Start.Line, tok.Loc.Start.Column, "Octal literal in strict mode")
+			}
+			return l.errorf(tok.Loc.Start.Line, tok.Loc.Start.Column, "Invalid number")
+		}
+	case token.String:
+		if prev != token.TemplateStart && prev != token.SubstitutionEnd {
+			return l.checkStrictString(tok)
+		}
+	case token.Identifier:
+		if strictReserved[tok.Literal] && l.isReference(tok, prev) {
+			return l.errorf(tok.Loc.Start.Line, tok.Loc.Start.Column, "The keyword '%s' is reserved", tok.Literal)
+		}
+	}
+	return nil
+}
+
+// isReference reports whether the identifier tok, one of the words reserved
+// in strict mode, which follows a token labeled prev, is used as a binding
+// or a reference rather than as a property name, the name of a class member
+// or one of its modifiers, or let of a lexical declaration. The lexer tells
+// them apart by the tokens around tok, so that it misses some of the uses
+// the parser would reject.
+func (l *Lexer) isReference(tok *token.Token, prev string) bool {
+	if d := &l.directives; d.clause > 0 && d.clause == len(l.stack) {
+		// The names of import and export specifiers, which may be any
+		// string, are not checked
+		return false
+	}
+	switch prev {
+	case token.Var, token.Const, token.Function, token.Class:
+		return true
+	case token.Dot, token.OptionalChaining:
+		return false
+	}
+	if l.inClassBody() {
+		switch prev {
+		case token.LBrace, token.RBrace, token.Semicolon, token.Star,
+			token.RParen, token.RBracket, token.Identifier, token.PrivateName,
+			token.String, token.Numeric, token.TemplateEnd, token.RegExp,
+			token.Increment, token.Decrement:
+			// Member name or modifier, after another member, a modifier,
+			// or a decorator
+			return false
+		}
+		if token.IsKeyword(prev) || token.IsContextualKeyword(prev) {
+			return false
+		}
+	}
+	next := l.nextSignificantChar(tok.End)
+	switch {
+	case next == ':' || next == '(':
+		// Property name of an object literal or a method
+		return false
+	case tok.Literal == "let":
+		// A lexical declaration
+		return next != '[' && next != '{' && next != '\\' && !isLetter(next) && next < utf8.RuneSelf
+	}
+	return true
+}
+
+// nextSignificantChar returns the first byte of the input from offset on
+// that is not whitespace or part of a comment, or 0 at the end of the input.
+func (l *Lexer) nextSignificantChar(offset int) byte {
+	for i := offset; i < len(l.input); i++ {
+		switch ch := l.input[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\v' || ch == '\f':
+		case strings.HasPrefix(l.input[i:], "//"):
+			for i < len(l.input) && l.input[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(l.input[i:], "/*"):
+			end := strings.Index(l.input[i+2:], "*/")
+			if end < 0 {
+				return 0
+			}
+			i += end + 3
+		default:
+			return ch
+		}
+	}
+	return 0
+}
+
+// endsDirective reports whether tok, which follows the string literal c at
+// the start of a statement, ends the statement, possibly by automatic
+// semicolon insertion.
+func endsDirective(tok, c *token.Token) bool {
+	switch tok.Type.Label {
+	case token.Semicolon, token.RBrace, token.EOF:
+		return true
+	}
+	if tok.Loc.Start.Line == c.Loc.End.Line {
+		return false
+	}
+	// Tokens that cannot continue the expression of the statement
+	switch tok.Type.Label {
+	case token.Identifier, token.String, token.Numeric, token.LBrace,
+		token.Increment, token.Decrement, token.Bang, token.Tilde,
+		token.At, token.PrivateName:
+		return true
+	}
+	return token.IsKeyword(tok.Type.Label) && tok.Type.Label != token.In &&
+		tok.Type.Label != token.Instanceof || token.IsContextualKeyword(tok.Type.Label)
+}
+
+// checkStrictString returns an error for the first octal escape sequence,
+// or \8 or \9, in the string literal tok.
+func (l *Lexer) checkStrictString(tok *token.Token) error {
+	line, column := tok.Loc.Start.Line, tok.Loc.Start.Column
+	raw := l.input[tok.Start:tok.End]
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\n' {
+			line++
+			column = 0
+			continue
+		}
+		if raw[i] != '\\' || i+1 == len(raw) {
+			column++
+			continue
+		}
+		switch ch := raw[i+1]; {
+		case ch == '0' && (i+2 == len(raw) || !isDigit(raw[i+2])):
+			// \0 is not an octal escape
+		case isOctalChar(ch):
+			return l.errorf(line, column, "Octal literal in strict mode")
+		case ch == '8' || ch == '9':
+			return l.errorf(line, column, "Invalid escape sequence")
+		}
+		// Skip the escaped character
+		column++
+		i++
+		if raw[i] == '\n' {
+			line++
+			column = 0
+		} else {
+			column++
+		}
+	}
+	return nil
+}
+
+// isLegacyOctal reports whether the numeric literal starting with '0' is a
+// legacy octal literal such as 0755, rather than a decimal one such as 089.
+func isLegacyOctal(literal string) bool {
+	for i := 1; i < len(literal); i++ {
+		if !isOctalChar(literal[i]) {
+			return false
+		}
+	}
+	return true
+}
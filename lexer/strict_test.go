@@ -0,0 +1,192 @@
+package lexer
+
+import (
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Sloppy mode code
+		{`010; "\07"; 08`, ""},
+		{`"use\x20strict"; 010`, ""},
+		{`'use strict' + x; 010`, ""},
+		{"'use strict'\n(x); 010", ""},
+		{`x; "use strict"; 010`, ""},
+		{"`use strict`; 010", ""},
+		{`if (a) { "use strict"; 010 }`, ""},
+		{`function f() { "use strict" } 010`, ""},
+		{`f = () => { "use strict"; "\0" }; "\07"`, ""},
+		{`class A { m() {} } 010`, ""},
+		{`var implements, package, private, protected, public, interface, static, let`, ""},
+		// Reserved words as property names, class members, and let of a
+		// lexical declaration
+		{`"use strict"; a.public; a?.static; x = { private: 1, package() {} }`, ""},
+		{`"use strict"; let x; let [y] = z; let {w} = z`, ""},
+		{"class A { static x; static() {} public = 1; @dec static y; [k]\n static z; let() {} }", ""},
+		{"class A { x = `t`\n static y = 1; z = /re/\n static w; v = a++\n static u; t = a--\n static s }", ""},
+		{`"use strict"; const x = 1; export { x as let }`, ""},
+		{`"use strict"; export { x as static } from "y"`, ""},
+		{`"use strict"; import { static as s } from "m"`, ""},
+		{`"use strict"; import d, { public as p, private } from "m"; export { interface } from "m"`, ""},
+		// Strict mode code
+		{`"use strict"; 010`, "SyntaxError: Octal literal in strict mode (0:14)"},
+		{`'use strict'; 08`, "SyntaxError: Invalid number (0:14)"},
+		{"'use strict'\n  010", "SyntaxError: Octal literal in strict mode (1:2)"},
+		{`"a"; "use strict"; x = "\07"`, "SyntaxError: Octal literal in strict mode (0:24)"},
+		{`"use strict"; "\8"`, "SyntaxError: Invalid escape sequence (0:15)"},
+		{"\"use strict\"; \"a\\\n\\1\"", "SyntaxError: Octal literal in strict mode (1:0)"},
+		{`"\01"; "use strict"`, "SyntaxError: Octal literal in strict mode (0:1)"},
+		{`function f(a) { "use strict"; return 010 }`, "SyntaxError: Octal literal in strict mode (0:37)"},
+		{`class A { m() { 'use strict'; 07 } }`, "SyntaxError: Octal literal in strict mode (0:30)"},
+		{`f = async () => { "use strict"; { 07 } }`, "SyntaxError: Octal literal in strict mode (0:34)"},
+		// Class bodies
+		{`class A { m() { 010 } }`, "SyntaxError: Octal literal in strict mode (0:16)"},
+		{`x = class B extends C { static { "\07" } }`, "SyntaxError: Octal literal in strict mode (0:34)"},
+		{`class A extends {} { x = 08 }`, "SyntaxError: Invalid number (0:25)"},
+		{`x = class extends ({ m() { 010 } }) {}`, "SyntaxError: Octal literal in strict mode (0:27)"},
+		// Words reserved in strict mode
+		{`"use strict"; var implements`, "SyntaxError: The keyword 'implements' is reserved (0:18)"},
+		{`"use strict"; package = 1`, "SyntaxError: The keyword 'package' is reserved (0:14)"},
+		{`"use strict"; f(private)`, "SyntaxError: The keyword 'private' is reserved (0:16)"},
+		{`"use strict"; function protected() {}`, "SyntaxError: The keyword 'protected' is reserved (0:23)"},
+		{`"use strict"; x = public`, "SyntaxError: The keyword 'public' is reserved (0:18)"},
+		{`"use strict"; const interface = 1`, "SyntaxError: The keyword 'interface' is reserved (0:20)"},
+		{`"use strict"; static + 1`, "SyntaxError: The keyword 'static' is reserved (0:14)"},
+		{`"use strict"; var let`, "SyntaxError: The keyword 'let' is reserved (0:18)"},
+		{`"use strict"; let = 1`, "SyntaxError: The keyword 'let' is reserved (0:14)"},
+		{`class let {}`, "SyntaxError: The keyword 'let' is reserved (0:6)"},
+		{`class A { x = static }`, "SyntaxError: The keyword 'static' is reserved (0:14)"},
+		{`"use strict"; import { a } from "m"; { let }`, "SyntaxError: The keyword 'let' is reserved (0:39)"},
+	}
+
+	for i, tt := range tests {
+		_, err := Tokenize(tt.input, WithRegExpHeuristic())
+		if tt.expected == "" {
+			if err != nil {
+				t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+		if err.Error() != tt.expected {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestStrictScope(t *testing.T) {
+	tests := []struct {
+		expectedLiteral string
+		expectedStrict  bool
+	}{
+		{"function", false},
+		{"f", false},
+		{"(", false},
+		{")", false},
+		{"{", false},
+		{"use strict", false},
+		{";", true},
+		{"}", false},
+		{"use strict", false},
+	}
+
+	l := New(`function f() { "use strict"; } "use strict"`, WithSourceType(Script))
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if l.Strict() != tt.expectedStrict {
+			t.Fatalf("tests[%d] - strict wrong. expected=%t, got=%t",
+				i, tt.expectedStrict, l.Strict())
+		}
+	}
+
+	if _, err := Tokenize("010", WithStrict()); err == nil {
+		t.Fatalf("expected error with WithStrict")
+	}
+}
+
+func TestWithStrict(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     []Option
+		expected string
+	}{
+		// Modules are only lexed as strict mode code with WithStrict
+		{"010; '\\07'; interface; package", nil, ""},
+		{"010", []Option{WithStrict()}, "SyntaxError: Octal literal in strict mode (0:0)"},
+		{"function f() { var public }", []Option{WithStrict()}, "SyntaxError: The keyword 'public' is reserved (0:19)"},
+		{"interface Foo {}", []Option{WithStrict(), WithDialect(TypeScript)}, ""},
+		{"class A implements B { private x; public static y }", []Option{WithDialect(TypeScript)}, ""},
+	}
+
+	for i, tt := range tests {
+		_, err := Tokenize(tt.input, tt.opts...)
+		if tt.expected == "" {
+			if err != nil {
+				t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+		if err.Error() != tt.expected {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestStrictViolationToken(t *testing.T) {
+	tests := []struct {
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"use strict", ""},
+		{";", ""},
+		{"x", ""},
+		{"=", ""},
+		{"010", "SyntaxError: Octal literal in strict mode (0:18)"},
+		// The token before the division is the octal literal
+		{"/", ""},
+		{"2", ""},
+		{"", ""},
+	}
+
+	l := New(`"use strict"; x = 010 / 2`, WithRegExpHeuristic())
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if tok == nil {
+			t.Fatalf("tests[%d] - token is nil", i)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if (err == nil) != (tt.expectedError == "") {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%v", i, tt.expectedError, err)
+		}
+		if err != nil && err.Error() != tt.expectedError {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, err.Error())
+		}
+	}
+
+	d := NewDocument(`"use strict"; var package = 010;`)
+	if len(d.Tokens()) != 7 || len(d.Errors()) != 2 {
+		t.Fatalf("document wrong. expected 7 tokens and 2 errors, got=%d and %v", len(d.Tokens()), d.Errors())
+	}
+}
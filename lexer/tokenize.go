@@ -0,0 +1,43 @@
+package lexer
+
+import (
+	"iter"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// All returns an iterator over the remaining tokens of the input. The
+// iteration stops after the last token before EOF; the EOF token itself is
+// not yielded. If the input cannot be tokenized, the iterator yields the
+// error with a zero token and stops.
+func (l *Lexer) All() iter.Seq2[token.Token, error] {
+	return func(yield func(token.Token, error) bool) {
+		for {
+			tok, err := l.NextToken()
+			if err != nil {
+				yield(token.Token{}, err)
+				return
+			}
+			if tok.Type.Label == token.EOF {
+				return
+			}
+			if !yield(*tok, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Tokenize returns the tokens of src, not including the final EOF token. It
+// stops at the first error and returns it along with the tokens read so
+// far.
+func Tokenize(src string, opts ...Option) ([]token.Token, error) {
+	var toks []token.Token
+	for tok, err := range New(src, opts...).All() {
+		if err != nil {
+			return toks, err
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
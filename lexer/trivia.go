@@ -0,0 +1,174 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// TriviaKind is the kind of a piece of trivia.
+type TriviaKind int
+
+const (
+	// Whitespace is a run of spaces, tabs, and other white space
+	// characters.
+	Whitespace TriviaKind = iota
+	// LineBreak is a single line terminator: "\n", "\r\n", "\r", U+2028,
+	// or U+2029.
+	LineBreak
+	// LineComment is a single-line comment, including its delimiter, a
+	// hashbang, or an HTML-like comment.
+	LineComment
+	// BlockComment is a multi-line comment, including its delimiters.
+	BlockComment
+	// BOM is the byte order mark at the start of the input.
+	BOM
+	// Delimiter is text between tokens that is none of the above, such as
+	// the comment delimiters around Flow comment types.
+	Delimiter
+)
+
+var triviaKinds = [...]string{"whitespace", "line-break", "line-comment", "block-comment", "bom", "delimiter"}
+
+func (k TriviaKind) String() string {
+	return triviaKinds[k]
+}
+
+// Trivia is a piece of the input between tokens. Start and End are its byte
+// offsets in the input.
+type Trivia struct {
+	Kind  TriviaKind
+	Text  string
+	Start int
+	End   int
+}
+
+// FullToken is a token with its raw text and the trivia around it. Trailing
+// trivia runs from the end of the token up to, but not including, the next
+// line break, and leading trivia is the rest of the trivia before the
+// token.
+type FullToken struct {
+	token.Token
+	Raw      string
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// FullText returns the leading trivia, the raw text, and the trailing
+// trivia of t.
+func (t *FullToken) FullText() string {
+	var b strings.Builder
+	t.writeTo(&b)
+	return b.String()
+}
+
+func (t *FullToken) writeTo(b *strings.Builder) {
+	for _, tr := range t.Leading {
+		b.WriteString(tr.Text)
+	}
+	b.WriteString(t.Raw)
+	for _, tr := range t.Trailing {
+		b.WriteString(tr.Text)
+	}
+}
+
+// Text returns the full text of toks, which is the input they were read
+// from if they are the result of TokenizeLossless.
+func Text(toks []FullToken) string {
+	var b strings.Builder
+	for i := range toks {
+		toks[i].writeTo(&b)
+	}
+	return b.String()
+}
+
+// TokenizeLossless returns the tokens of src with their trivia, including
+// the final EOF token, whose leading trivia is whatever follows the last
+// token. Comments are returned as trivia, so WithComments has no effect.
+// Every byte of src belongs to exactly one token or piece of trivia, so
+// Text(toks) == src. It stops at the first error and returns it along with
+// the tokens read so far.
+func TokenizeLossless(src string, opts ...Option) ([]FullToken, error) {
+	l := New(src, append(opts, WithComments())...)
+
+	var toks []FullToken
+	var trivia []Trivia
+	prevEnd := 0
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return toks, err
+		}
+		trivia = appendTrivia(trivia, src, prevEnd, tok.Start)
+		prevEnd = tok.End
+
+		switch tok.Type.Label {
+		case token.LineComment:
+			trivia = append(trivia, Trivia{Kind: LineComment, Text: src[tok.Start:tok.End], Start: tok.Start, End: tok.End})
+			continue
+		case token.BlockComment:
+			trivia = append(trivia, Trivia{Kind: BlockComment, Text: src[tok.Start:tok.End], Start: tok.Start, End: tok.End})
+			continue
+		}
+
+		if len(toks) > 0 {
+			// The trivia on the line of the previous token is its trailing
+			// trivia
+			prev := &toks[len(toks)-1]
+			n := 0
+			for n < len(trivia) && trivia[n].Kind != LineBreak {
+				n++
+			}
+			prev.Trailing = trivia[:n:n]
+			trivia = trivia[n:]
+		}
+		toks = append(toks, FullToken{Token: *tok, Raw: src[tok.Start:tok.End], Leading: trivia})
+		trivia = nil
+		if tok.Type.Label == token.EOF {
+			return toks, nil
+		}
+	}
+}
+
+// appendTrivia appends the trivia in src[start:end], which contains no
+// tokens or comments, to trivia.
+func appendTrivia(trivia []Trivia, src string, start, end int) []Trivia {
+	for i := start; i < end; {
+		kind, size := triviaAt(src[:end], i)
+		if n := len(trivia) - 1; n >= 0 && trivia[n].End == i && trivia[n].Kind == kind && kind != LineBreak && kind != BOM {
+			trivia[n].End += size
+			trivia[n].Text = src[trivia[n].Start:trivia[n].End]
+		} else {
+			trivia = append(trivia, Trivia{Kind: kind, Text: src[i : i+size], Start: i, End: i + size})
+		}
+		i += size
+	}
+	return trivia
+}
+
+// triviaAt returns the kind and size of the character at src[i].
+func triviaAt(src string, i int) (TriviaKind, int) {
+	switch src[i] {
+	case '\n':
+		return LineBreak, 1
+	case '\r':
+		if i+1 < len(src) && src[i+1] == '\n' {
+			return LineBreak, 2
+		}
+		return LineBreak, 1
+	case ' ', '\t', '\v', '\f':
+		return Whitespace, 1
+	}
+	r, size := utf8.DecodeRuneInString(src[i:])
+	switch {
+	case r == '\u2028' || r == '\u2029':
+		return LineBreak, size
+	case r == '\ufeff' && i == 0:
+		return BOM, size
+	case r == '\ufeff' || unicode.IsSpace(r):
+		return Whitespace, size
+	}
+	return Delimiter, size
+}
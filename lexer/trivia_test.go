@@ -0,0 +1,145 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestTokenizeLossless(t *testing.T) {
+	input := "\ufeff#!/usr/bin/env node\r\n// one\r\nlet x = 1; /* two */\r\n\r\n  x\t// three\n"
+
+	type trivia struct {
+		kind TriviaKind
+		text string
+	}
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedRaw      string
+		expectedLeading  []trivia
+		expectedTrailing []trivia
+	}{
+		{makeTT(token.Identifier), "let", []trivia{
+			{BOM, "\ufeff"}, {LineComment, "#!/usr/bin/env node"}, {LineBreak, "\r\n"},
+			{LineComment, "// one"}, {LineBreak, "\r\n"},
+		}, []trivia{{Whitespace, " "}}},
+		{makeTT(token.Identifier), "x", nil, []trivia{{Whitespace, " "}}},
+		{makeTT(token.Assignment), "=", nil, []trivia{{Whitespace, " "}}},
+		{makeTT(token.Numeric), "1", nil, nil},
+		{makeTT(token.Semicolon), ";", nil, []trivia{{Whitespace, " "}, {BlockComment, "/* two */"}}},
+		{makeTT(token.Identifier), "x", []trivia{
+			{LineBreak, "\r\n"}, {LineBreak, "\r\n"}, {Whitespace, "  "},
+		}, []trivia{{Whitespace, "\t"}, {LineComment, "// three"}}},
+		{makeTT(token.EOF), "", []trivia{{LineBreak, "\n"}}, nil},
+	}
+
+	toks, err := TokenizeLossless(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if len(toks) != len(tests) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d", len(tests), len(toks))
+	}
+
+	check := func(i int, which string, expected []trivia, got []Trivia) {
+		if len(got) != len(expected) {
+			t.Fatalf("tests[%d] - %s trivia wrong. expected=%v, got=%v", i, which, expected, got)
+		}
+		for j, tr := range got {
+			if tr.Kind != expected[j].kind || tr.Text != expected[j].text {
+				t.Fatalf("tests[%d] - %s trivia[%d] wrong. expected=%s %q, got=%s %q",
+					i, which, j, expected[j].kind, expected[j].text, tr.Kind, tr.Text)
+			}
+			if input[tr.Start:tr.End] != tr.Text {
+				t.Fatalf("tests[%d] - %s trivia[%d] offsets wrong. got=%d-%d", i, which, j, tr.Start, tr.End)
+			}
+		}
+	}
+	for i, tt := range tests {
+		tok := toks[i]
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v", i, tt.expectedType, tok.Type)
+		}
+		if tok.Raw != tt.expectedRaw {
+			t.Fatalf("tests[%d] - raw text wrong. expected=%q, got=%q", i, tt.expectedRaw, tok.Raw)
+		}
+		check(i, "leading", tt.expectedLeading, tok.Leading)
+		check(i, "trailing", tt.expectedTrailing, tok.Trailing)
+	}
+
+	if got := Text(toks); got != input {
+		t.Fatalf("text wrong. expected=%q, got=%q", input, got)
+	}
+	if got := toks[0].FullText(); got != "\ufeff#!/usr/bin/env node\r\n// one\r\nlet " {
+		t.Fatalf("full text wrong. got=%q", got)
+	}
+}
+
+func TestTokenizeLosslessError(t *testing.T) {
+	tests := []string{
+		"a = 'unterminated",
+		"a /* unterminated",
+		"a\x00b",
+	}
+
+	for i, input := range tests {
+		if _, err := TokenizeLossless(input); err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+	}
+}
+
+var losslessSeeds = []string{
+	"",
+	"\ufeff",
+	"\r\n\r\n",
+	"let x = 1;\r\nlet y = `a ${x} b`; // c\r\n",
+	"#!/usr/bin/env node\n'use strict'\n/* a */ /* b */\n",
+	"a b c d\ufeffe",
+	"x = a / b / /re/g.exec(s)\n",
+	"<!-- html\nx --> y\n--> z",
+	"const el = <div a=\"b\">\n  text {x}\n</div>",
+	"let x/*: number */ = 1;",
+}
+
+// FuzzTokenizeLossless checks that the tokens and trivia of any input that
+// can be tokenized add up to the input.
+func FuzzTokenizeLossless(f *testing.F) {
+	for _, seed := range losslessSeeds {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	f.Fuzz(func(t *testing.T, input string, script bool) {
+		opts := []Option{WithRegExpHeuristic(), WithJSX()}
+		if script {
+			opts = append(opts, WithSourceType(Script), WithDialect(Flow))
+		}
+		toks, err := TokenizeLossless(input, opts...)
+		if err != nil {
+			return
+		}
+		if got := Text(toks); got != input {
+			t.Fatalf("text wrong. expected=%q, got=%q", input, got)
+		}
+		end := 0
+		for i, tok := range toks {
+			for _, tr := range tok.Leading {
+				if tr.Start != end || input[tr.Start:tr.End] != tr.Text {
+					t.Fatalf("toks[%d] - leading trivia wrong. got=%+v", i, tr)
+				}
+				end = tr.End
+			}
+			if tok.Start != end || input[tok.Start:tok.End] != tok.Raw {
+				t.Fatalf("toks[%d] - offsets wrong. expected start=%d, got=%d", i, end, tok.Start)
+			}
+			end = tok.End
+			for _, tr := range tok.Trailing {
+				if tr.Start != end || tr.Kind == LineBreak || input[tr.Start:tr.End] != tr.Text {
+					t.Fatalf("toks[%d] - trailing trivia wrong. got=%+v", i, tr)
+				}
+				end = tr.End
+			}
+		}
+	})
+}
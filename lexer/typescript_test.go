@@ -0,0 +1,211 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestTypeScript(t *testing.T) {
+	input := `@sealed
+declare abstract class A implements B {}
+interface P { readonly x: keyof T }
+type U = T extends (infer V)[] ? V : never;
+namespace N {}
+enum E {}
+let v = a!.b! as C satisfies D;
+if (a!==b && !c) {}
+let type = 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.At), "@"},
+		{makeTT(token.Identifier), "sealed"},
+		{makeTT(token.Declare), "declare"},
+		{makeTT(token.Abstract), "abstract"},
+		{makeTT(token.Class), "class"},
+		{makeTT(token.Identifier), "A"},
+		{makeTT(token.Implements), "implements"},
+		{makeTT(token.Identifier), "B"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.Interface), "interface"},
+		{makeTT(token.Identifier), "P"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.Readonly), "readonly"},
+		{makeTT(token.Identifier), "x"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.Keyof), "keyof"},
+		{makeTT(token.Identifier), "T"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.Type), "type"},
+		{makeTT(token.Identifier), "U"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Identifier), "T"},
+		{makeTT(token.Extends), "extends"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.Infer), "infer"},
+		{makeTT(token.Identifier), "V"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.LBracket), "["},
+		{makeTT(token.RBracket), "]"},
+		{makeTT(token.Question), "?"},
+		{makeTT(token.Identifier), "V"},
+		{makeTT(token.Colon), ":"},
+		{makeTT(token.Identifier), "never"},
+		{makeTT(token.Semicolon), ";"},
+		{makeTT(token.Namespace), "namespace"},
+		{makeTT(token.Identifier), "N"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.Enum), "enum"},
+		{makeTT(token.Identifier), "E"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.RBrace), "}"},
+		{makeTT(token.Identifier), "let"},
+		{makeTT(token.Identifier), "v"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.NonNull), "!"},
+		{makeTT(token.Dot), "."},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.NonNull), "!"},
+		{makeTT(token.As), "as"},
+		{makeTT(token.Identifier), "C"},
+		{makeTT(token.Satisfies), "satisfies"},
+		{makeTT(token.Identifier), "D"},
+		{makeTT(token.Semicolon), ";"},
+		{makeTT(token.If), "if"},
+		{makeTT(token.LParen), "("},
+		{makeTT(token.Identifier), "a"},
+		{makeTT(token.Nonidentity), "!=="},
+		{makeTT(token.Identifier), "b"},
+		{makeTT(token.LogicalAnd), "&&"},
+		{makeTT(token.Bang), "!"},
+		{makeTT(token.Identifier), "c"},
+		{makeTT(token.RParen), ")"},
+		{makeTT(token.LBrace), "{"},
+		{makeTT(token.RBrace), "}"},
+		// Contextual keywords remain usable as names
+		{makeTT(token.Identifier), "let"},
+		{makeTT(token.Type), "type"},
+		{makeTT(token.Assignment), "="},
+		{makeTT(token.Numeric), "1"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input, WithDialect(TypeScript))
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTypeScriptKeywordsInJavaScript(t *testing.T) {
+	input := "type interface keyof x!"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{makeTT(token.Identifier), "type"},
+		{makeTT(token.Identifier), "interface"},
+		{makeTT(token.Identifier), "keyof"},
+		{makeTT(token.Identifier), "x"},
+		{makeTT(token.Bang), "!"},
+		{makeTT(token.EOF), ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestReScanGreaterThanToken(t *testing.T) {
+	input := "let a: Array<Array<T>>= b"
+
+	l := New(input, WithDialect(TypeScript))
+	for i := 0; i < 9; i++ {
+		if _, err := l.NextToken(); err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+	}
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLocation token.SourceLocation
+	}{
+		{makeTT(token.GT), ">", makeLoc(0, 20, 0, 21)},
+		{makeTT(token.GT), ">", makeLoc(0, 21, 0, 22)},
+	}
+
+	for i, tt := range tests {
+		tok, err := l.ReScanGreaterThanToken()
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%+v, got=%+v",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Loc != tt.expectedLocation {
+			t.Fatalf("tests[%d] - location wrong. expected=%+v, got=%+v",
+				i, tt.expectedLocation, tok.Loc)
+		}
+
+		// The rest of the original token follows.
+		if _, err := l.NextToken(); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+	}
+
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if tok.Type != makeTT(token.Identifier) || tok.Literal != "b" {
+		t.Fatalf("token after '>>=' wrong. got=%+v", tok)
+	}
+
+	if _, err := New("a < b").ReScanGreaterThanToken(); err == nil {
+		t.Fatalf("expected error after a token other than '>'")
+	}
+}
@@ -0,0 +1,139 @@
+// Package minify removes the whitespace and comments a JavaScript program
+// does not need, working on its tokens rather than on a syntax tree.
+package minify
+
+import (
+	"strings"
+
+	"github.com/morinokami/js-lexer/internal/join"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/sourcemap"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Minify returns src with comments removed, except for hashbangs and
+// legal comments, which start with ! or contain @license or @preserve,
+// and with only the whitespace needed to keep the tokens apart and the
+// meaning of the program unchanged. The options are passed to the lexer.
+func Minify(src string, opts ...lexer.Option) (string, error) {
+	m := &minifier{src: src}
+	if err := m.minify(opts); err != nil {
+		return "", err
+	}
+	return m.out.String(), nil
+}
+
+// MinifyWithSourceMap is like Minify, but also returns a source map from
+// the minified code to src, which the map names source.
+func MinifyWithSourceMap(src, source string, opts ...lexer.Option) (string, *sourcemap.Map, error) {
+	m := &minifier{src: src, source: source, sourceMap: sourcemap.NewGenerator("")}
+	m.sourceMap.AddSource(source, src)
+	if err := m.minify(opts); err != nil {
+		return "", nil, err
+	}
+	code := m.out.String()
+	return code, m.sourceMap.Map(code), nil
+}
+
+type minifier struct {
+	src       string
+	source    string
+	sourceMap *sourcemap.Generator
+	out       strings.Builder
+	// Position in the output, for the source map
+	line, column int
+
+	// The last token written, and the last token of the program read,
+	// which may have been a comment that was dropped
+	written *token.Token
+	code    *token.Token
+}
+
+func (m *minifier) minify(opts []lexer.Option) error {
+	opts = append([]lexer.Option{lexer.WithRegExpHeuristic(), lexer.WithComments()}, opts...)
+	toks, err := lexer.Tokenize(m.src, opts...)
+	if err != nil {
+		return err
+	}
+
+	for i := range toks {
+		tok := &toks[i]
+		atLineStart := strings.HasSuffix(m.out.String(), "\n")
+		switch tok.Type.Label {
+		case token.LineComment, token.BlockComment:
+			if !isLegalComment(m.src[tok.Start:tok.End]) {
+				continue
+			}
+			if m.written != nil && !atLineStart && m.needsSpace(tok) {
+				m.write(" ", nil)
+			}
+			m.write(m.src[tok.Start:tok.End], tok)
+			if tok.Type.Label == token.LineComment {
+				m.write("\n", nil)
+			}
+			continue
+		}
+
+		if m.code != nil && m.needsLineBreak(tok) {
+			if !atLineStart {
+				m.write("\n", nil)
+			}
+		} else if m.written != nil && !atLineStart && m.needsSpace(tok) {
+			m.write(" ", nil)
+		}
+		m.write(m.src[tok.Start:tok.End], tok)
+		m.code = tok
+	}
+	return nil
+}
+
+// write writes text to the output, mapping it to the position of tok if
+// tok is not nil.
+func (m *minifier) write(text string, tok *token.Token) {
+	if tok != nil {
+		m.written = tok
+		if m.sourceMap != nil {
+			m.sourceMap.AddMapping(sourcemap.Mapping{
+				Generated: token.Position{Line: m.line, Column: m.column},
+				Source:    m.source,
+				Original:  tok.Loc.Start,
+			})
+		}
+	}
+	m.out.WriteString(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		m.line += strings.Count(text, "\n")
+		m.column = len(text) - i - 1
+	} else {
+		m.column += len(text)
+	}
+}
+
+// isLegalComment reports whether the comment text is to be kept.
+func isLegalComment(text string) bool {
+	return strings.HasPrefix(text, "#!") || strings.HasPrefix(text, "/*!") || strings.HasPrefix(text, "//!") ||
+		strings.Contains(text, "@license") || strings.Contains(text, "@preserve")
+}
+
+// needsLineBreak reports whether the line break between the last token of
+// the program read and tok has to be kept, because a semicolon was
+// inserted at it, or might have been.
+func (m *minifier) needsLineBreak(tok *token.Token) bool {
+	return join.HasLineBreak(m.src[m.code.End:tok.Start]) && join.SemicolonMayBeInserted(m.code, tok)
+}
+
+// needsSpace reports whether the last token written and tok, written next
+// to each other, would be read as different tokens.
+func (m *minifier) needsSpace(tok *token.Token) bool {
+	prev := m.written
+	switch {
+	case tok.Type.Label == token.TemplateEnd, tok.Type.Label == token.SubstitutionStart,
+		tok.Type.Label == token.String && m.code != nil &&
+			(m.code.Type.Label == token.TemplateStart || m.code.Type.Label == token.SubstitutionEnd),
+		tok.Type.Label == token.JSXText, prev.Type.Label == token.JSXText:
+		// Parts of templates and JSX text
+		return false
+	}
+
+	return join.NeedsSpace(m.src, prev, tok)
+}
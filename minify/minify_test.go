@@ -0,0 +1,85 @@
+package minify
+
+import (
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var  a = 1 ;\n\nfunction f ( x ) {\n  return x * 2 ;\n}", "var a=1;function f(x){return x*2;}"},
+		{"a - -b; a + +b; a - --b; a+ ++b; a - +b", "a- -b;a+ +b;a- --b;a+ ++b;a-+b"},
+		{"x / /re/g.exec(y) / 2", "x/ /re/g.exec(y)/2"},
+		{"x = /a/ in y", "x=/a/ in y"},
+		{"1 .toString(); 1.5 .toFixed(); 0x1 .toString()", "1 .toString();1.5.toFixed();0x1.toString()"},
+		{"typeof x === 'y'; a instanceof B; 'a' in b", "typeof x==='y';a instanceof B;'a'in b"},
+		{"a ? .5 : b", "a? .5:b"},
+		{"`a ${ b + `c ${ d }` } e` ; x", "`a ${b+`c ${d}`} e`;x"},
+		// Comments
+		{"a /* b */ + // c\nd", "a+d"},
+		{"/*! license */\nvar a; /** @preserve */ b //! keep\nc", "/*! license */var a;/** @preserve */b//! keep\nc"},
+		{"#!/usr/bin/env node\nrun()", "#!/usr/bin/env node\nrun()"},
+		{"a / /* c */ /re/", "a/ /re/"},
+		// Automatic semicolon insertion
+		{"return\nx", "return\nx"},
+		{"function f() { return /* a\n */ x }", "function f(){return\nx}"},
+		{"a\nb", "a\nb"},
+		{"a\n++b", "a\n++b"},
+		{"a = b\n(c)", "a=b(c)"},
+		{"a = b\n.c\n[d]", "a=b.c[d]"},
+		// Line breaks after ) are kept, as it could end a call
+		{"if (a)\n  b\nelse\n  c", "if(a)\nb\nelse c"},
+		{"let x = 1\nlet y = [\n  1,\n  2\n]\n", "let x=1\nlet y=[1,2]"},
+		{"break\n;", "break;"},
+		{"async\nfunction f() {}", "async\nfunction f(){}"},
+	}
+
+	for i, tt := range tests {
+		got, err := Minify(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - output wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestMinifyJSX(t *testing.T) {
+	input := "const el = <div\n  className=\"a\"\n  id='b'>\n  Hello,   {name}\n</div>"
+	expected := "const el=<div className=\"a\" id='b'>\n  Hello,   {name}\n</div>"
+
+	got, err := Minify(input, lexer.WithJSX())
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if got != expected {
+		t.Fatalf("output wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestMinifyError(t *testing.T) {
+	if _, err := Minify("a = 'unterminated"); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestMinifyWithSourceMap(t *testing.T) {
+	input := "function add(a, b) {\n  return a + b;\n}\n"
+
+	got, m, err := MinifyWithSourceMap(input, "add.js")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if got != "function add(a,b){return a+b;}" {
+		t.Fatalf("output wrong. got=%q", got)
+	}
+	expected := `{"version":3,"sources":["add.js"],"sourcesContent":["function add(a, b) {\n  return a + b;\n}\n"],"names":[],"mappings":"AAAA,SAAS,GAAG,CAAC,CAAC,CAAE,CAAC,CAAE,CACjB,OAAO,CAAE,CAAE,CAAC,CACd"}`
+	if string(m.JSON()) != expected {
+		t.Fatalf("source map wrong. expected=%q, got=%q", expected, string(m.JSON()))
+	}
+}
@@ -0,0 +1,772 @@
+package parser
+
+import (
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Operator precedences of binary operators, from lowest to highest
+const (
+	_ int = iota
+	NULLISH
+	LOGICAL_OR
+	LOGICAL_AND
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
+	EQUALITY
+	RELATIONAL
+	SHIFT
+	ADDITIVE
+	MULTIPLICATIVE
+	EXPONENTIATION
+)
+
+var precedences = map[string]int{
+	token.NullishCoalescing:  NULLISH,
+	token.LogicalOr:          LOGICAL_OR,
+	token.LogicalAnd:         LOGICAL_AND,
+	token.BitwiseOr:          BITWISE_OR,
+	token.BitwiseXor:         BITWISE_XOR,
+	token.BitwiseAnd:         BITWISE_AND,
+	token.Equality:           EQUALITY,
+	token.Inequality:         EQUALITY,
+	token.Identity:           EQUALITY,
+	token.Nonidentity:        EQUALITY,
+	token.LT:                 RELATIONAL,
+	token.GT:                 RELATIONAL,
+	token.LTEq:               RELATIONAL,
+	token.GTEq:               RELATIONAL,
+	token.Instanceof:         RELATIONAL,
+	token.In:                 RELATIONAL,
+	token.LeftShift:          SHIFT,
+	token.RightShift:         SHIFT,
+	token.UnsignedRightShift: SHIFT,
+	token.Plus:               ADDITIVE,
+	token.Minus:              ADDITIVE,
+	token.Star:               MULTIPLICATIVE,
+	token.Slash:              MULTIPLICATIVE,
+	token.Remainder:          MULTIPLICATIVE,
+	token.Exponentiation:     EXPONENTIATION,
+}
+
+var assignmentOperators = map[string]bool{
+	token.Assignment:                   true,
+	token.AdditionAssignment:           true,
+	token.SubtractionAssignment:        true,
+	token.MultiplicationAssignment:     true,
+	token.DivisionAssignment:           true,
+	token.RemainderAssignment:          true,
+	token.ExponentiationAssignment:     true,
+	token.LeftShiftAssignment:          true,
+	token.RightShiftAssignment:         true,
+	token.UnsignedRightShiftAssignment: true,
+	token.BitwiseAndAssignment:         true,
+	token.BitwiseOrAssignment:          true,
+	token.BitwiseXorAssignment:         true,
+	token.LogicalAndAssignment:         true,
+	token.LogicalOrAssignment:          true,
+	token.NullishCoalescingAssignment:  true,
+}
+
+// parseExpression parses an expression, which may be a comma-separated
+// sequence. If noIn is true, the in operator is not consumed, as in the
+// head of a for statement.
+func (p *Parser) parseExpression(noIn bool) ast.Expression {
+	m := p.mark()
+	expr := p.parseMaybeAssign(noIn)
+	if !p.is(token.Comma) {
+		return expr
+	}
+	exprs := []ast.Expression{expr}
+	for p.eat(token.Comma) {
+		exprs = append(exprs, p.parseMaybeAssign(noIn))
+	}
+	return &ast.SequenceExpression{Base: p.finish("SequenceExpression", m), Expressions: exprs}
+}
+
+// parseMaybeAssign parses an assignment expression, which includes arrow
+// functions and yield expressions.
+func (p *Parser) parseMaybeAssign(noIn bool) ast.Expression {
+	if p.inGenerator && p.is(token.Yield) {
+		return p.parseYield(noIn)
+	}
+
+	m := p.mark()
+	left := p.parseMaybeConditional(noIn)
+	if op := p.cur.Type.Label; assignmentOperators[op] {
+		var target ast.Pattern
+		if op == token.Assignment {
+			target = p.toAssignable(left)
+		} else {
+			target = p.checkSimpleAssignTarget(left)
+		}
+		p.next()
+		right := p.parseMaybeAssign(noIn)
+		return &ast.AssignmentExpression{Base: p.finish("AssignmentExpression", m), Operator: op, Left: target, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseYield(noIn bool) ast.Expression {
+	m := p.mark()
+	p.next()
+	delegate := false
+	var arg ast.Expression
+	if !p.hasNewlineBefore() {
+		delegate = p.eat(token.Star)
+		if delegate || p.startsExpression() {
+			arg = p.parseMaybeAssign(noIn)
+		}
+	}
+	return &ast.YieldExpression{Base: p.finish("YieldExpression", m), Argument: arg, Delegate: delegate}
+}
+
+// startsExpression reports whether the current token may start an
+// expression, for operands that are optional like that of yield.
+func (p *Parser) startsExpression() bool {
+	switch p.cur.Type.Label {
+	case token.RParen, token.RBracket, token.RBrace, token.Comma, token.Semicolon,
+		token.Colon, token.EOF, token.SubstitutionEnd, token.TemplateEnd, token.Question:
+		return false
+	}
+	_, isBinary := precedences[p.cur.Type.Label]
+	return !isBinary || p.is(token.Plus) || p.is(token.Minus) || p.is(token.Slash)
+}
+
+func (p *Parser) parseMaybeConditional(noIn bool) ast.Expression {
+	m := p.mark()
+	test := p.parseExprOps(noIn)
+	if !p.eat(token.Question) {
+		return test
+	}
+	consequent := p.parseMaybeAssign(false)
+	p.expect(token.Colon)
+	alternate := p.parseMaybeAssign(noIn)
+	return &ast.ConditionalExpression{
+		Base:       p.finish("ConditionalExpression", m),
+		Test:       test,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}
+}
+
+func (p *Parser) parseExprOps(noIn bool) ast.Expression {
+	m := p.mark()
+	var left ast.Expression
+	if p.is(token.PrivateName) {
+		// #x in obj
+		left = p.parsePrivateIdentifier()
+		if !p.is(token.In) {
+			p.unexpected()
+		}
+	} else {
+		left = p.parseMaybeUnary()
+		if _, isArrow := left.(*ast.ArrowFunctionExpression); isArrow {
+			return left
+		}
+		p.checkExponentiationOperand(left, m)
+	}
+	return p.parseExprOp(left, m, 0, noIn)
+}
+
+// parseExprOp parses the binary operators following left whose precedence
+// is higher than minPrec.
+func (p *Parser) parseExprOp(left ast.Expression, m marker, minPrec int, noIn bool) ast.Expression {
+	for {
+		op := p.cur.Type.Label
+		prec, ok := precedences[op]
+		if !ok || prec <= minPrec || noIn && op == token.In {
+			return left
+		}
+		opTok := p.cur
+		p.next()
+
+		rm := p.mark()
+		right := p.parseMaybeUnary()
+		p.checkExponentiationOperand(right, rm)
+		// ** is right-associative
+		nextMin := prec
+		if op == token.Exponentiation {
+			nextMin = prec - 1
+		}
+		right = p.parseExprOp(right, rm, nextMin, noIn)
+
+		if op == token.NullishCoalescing && (isUnparenthesizedLogical(left, m) || isUnparenthesizedLogical(right, rm)) {
+			p.raise(opTok, "Logical expressions and coalesce expressions cannot be mixed. Wrap either by parentheses")
+		}
+
+		base := p.finish("BinaryExpression", m)
+		switch op {
+		case token.LogicalOr, token.LogicalAnd, token.NullishCoalescing:
+			base.Type = "LogicalExpression"
+			left = &ast.LogicalExpression{Base: base, Operator: op, Left: left, Right: right}
+		default:
+			left = &ast.BinaryExpression{Base: base, Operator: op, Left: left, Right: right}
+		}
+	}
+}
+
+// isUnparenthesizedLogical reports whether expr, which started at m, is a
+// || or && expression without parentheses around it, which cannot be an
+// operand of ??.
+func isUnparenthesizedLogical(expr ast.Expression, m marker) bool {
+	logical, ok := expr.(*ast.LogicalExpression)
+	return ok && logical.Operator != token.NullishCoalescing && logical.Start == m.start
+}
+
+// checkExponentiationOperand rejects an unparenthesized unary expression,
+// which started at m, as the left operand of **.
+func (p *Parser) checkExponentiationOperand(expr ast.Expression, m marker) {
+	if !p.is(token.Exponentiation) || expr.Info().Start != m.start {
+		return
+	}
+	switch expr.(type) {
+	case *ast.UnaryExpression, *ast.AwaitExpression:
+		p.raise(p.cur, "Unary operator used immediately before exponentiation expression. Parenthesis must be used to disambiguate operator precedence")
+	}
+}
+
+func (p *Parser) parseMaybeUnary() ast.Expression {
+	m := p.mark()
+
+	if p.is(token.Await) || p.isWord("await") && p.inAsync {
+		if p.canAwait() {
+			p.next()
+			arg := p.parseMaybeUnary()
+			return &ast.AwaitExpression{Base: p.finish("AwaitExpression", m), Argument: arg}
+		}
+	}
+
+	switch op := p.cur.Type.Label; op {
+	case token.Increment, token.Decrement:
+		p.next()
+		arg := p.parseMaybeUnary()
+		p.checkSimpleAssignTarget(arg)
+		return &ast.UpdateExpression{Base: p.finish("UpdateExpression", m), Operator: op, Prefix: true, Argument: arg}
+	case token.Delete, token.Void, token.Typeof, token.Plus, token.Minus, token.Bang, token.Tilde:
+		p.next()
+		arg := p.parseMaybeUnary()
+		if _, isIdent := arg.(*ast.Identifier); isIdent && op == token.Delete && p.strict() {
+			p.raiseAt(arg, "Deleting local variable in strict mode")
+		}
+		return &ast.UnaryExpression{Base: p.finish("UnaryExpression", m), Operator: op, Prefix: true, Argument: arg}
+	}
+
+	expr := p.parseExprSubscripts()
+	for (p.is(token.Increment) || p.is(token.Decrement)) && !p.hasNewlineBefore() {
+		p.checkSimpleAssignTarget(expr)
+		op := p.cur.Type.Label
+		p.next()
+		expr = &ast.UpdateExpression{Base: p.finish("UpdateExpression", m), Operator: op, Argument: expr}
+	}
+	return expr
+}
+
+// parseExprSubscripts parses a left-hand side expression: an atom followed
+// by member accesses, calls, and tagged templates.
+func (p *Parser) parseExprSubscripts() ast.Expression {
+	m := p.mark()
+	expr := p.parseExprAtom()
+	if _, isArrow := expr.(*ast.ArrowFunctionExpression); isArrow {
+		return expr
+	}
+	return p.parseSubscripts(expr, m, false)
+}
+
+// parseSubscripts parses the member accesses, calls, and tagged templates
+// following base. If noCalls is true, as for the callee of new, it stops
+// before a call.
+func (p *Parser) parseSubscripts(base ast.Expression, m marker, noCalls bool) ast.Expression {
+	chained := false
+	for {
+		optional := false
+		if p.is(token.OptionalChaining) {
+			if noCalls {
+				p.raise(p.cur, "Optional chaining cannot appear in the callee of new expressions")
+			}
+			p.next()
+			optional = true
+			chained = true
+		}
+
+		switch {
+		case p.is(token.LBracket):
+			p.next()
+			property := p.parseExpression(false)
+			p.expect(token.RBracket)
+			base = &ast.MemberExpression{
+				Base:     p.finish("MemberExpression", m),
+				Object:   base,
+				Property: property,
+				Computed: true,
+				Optional: optional,
+			}
+		case optional && !p.is(token.LParen) || p.eat(token.Dot):
+			var property ast.Expression
+			if p.is(token.PrivateName) {
+				property = p.parsePrivateIdentifier()
+			} else {
+				property = p.parseIdent(true)
+			}
+			base = &ast.MemberExpression{
+				Base:     p.finish("MemberExpression", m),
+				Object:   base,
+				Property: property,
+				Optional: optional,
+			}
+		case !noCalls && p.is(token.LParen):
+			maybeAsyncArrow := !optional && isAsyncIdent(base) && !p.hasNewlineBefore()
+			p.next()
+			args := p.parseExprList(token.RParen)
+			if maybeAsyncArrow && p.is(token.Arrow) && !p.hasNewlineBefore() {
+				return p.parseArrow(m, p.toParams(args), true)
+			}
+			base = &ast.CallExpression{
+				Base:      p.finish("CallExpression", m),
+				Callee:    base,
+				Arguments: args,
+				Optional:  optional,
+			}
+		case p.is(token.TemplateStart):
+			if chained {
+				p.raise(p.cur, "Optional chaining cannot appear in the tag of tagged template expressions")
+			}
+			quasi := p.parseTemplate(true)
+			base = &ast.TaggedTemplateExpression{Base: p.finish("TaggedTemplateExpression", m), Tag: base, Quasi: quasi}
+		default:
+			if chained {
+				base = &ast.ChainExpression{Base: p.finish("ChainExpression", m), Expression: base}
+			}
+			return base
+		}
+	}
+}
+
+// isAsyncIdent reports whether expr is the identifier async, written
+// without escapes, which may start an async arrow function.
+func isAsyncIdent(expr ast.Expression) bool {
+	id, ok := expr.(*ast.Identifier)
+	return ok && id.Name == "async" && id.End-id.Start == len("async")
+}
+
+// parseExprList parses a comma-separated list of expressions, which may
+// contain spread elements, up to and including the closing token.
+func (p *Parser) parseExprList(closing string) []ast.Expression {
+	exprs := []ast.Expression{}
+	for !p.eat(closing) {
+		if p.is(token.Ellipsis) {
+			exprs = append(exprs, p.parseSpread())
+		} else {
+			exprs = append(exprs, p.parseMaybeAssign(false))
+		}
+		if !p.is(closing) {
+			p.expect(token.Comma)
+		}
+	}
+	return exprs
+}
+
+func (p *Parser) parseSpread() *ast.SpreadElement {
+	m := p.mark()
+	p.expect(token.Ellipsis)
+	arg := p.parseMaybeAssign(false)
+	return &ast.SpreadElement{Base: p.finish("SpreadElement", m), Argument: arg}
+}
+
+func (p *Parser) parseExprAtom() ast.Expression {
+	m := p.mark()
+
+	switch p.cur.Type.Label {
+	case token.This:
+		p.next()
+		return &ast.ThisExpression{Base: p.finish("ThisExpression", m)}
+	case token.Super:
+		p.next()
+		if !p.is(token.LParen) && !p.is(token.Dot) && !p.is(token.LBracket) {
+			p.unexpected()
+		}
+		return &ast.Super{Base: p.finish("Super", m)}
+	case token.Identifier, token.Yield, token.Await:
+		if p.isAsyncFunction() {
+			p.next()
+			return p.parseFunctionExpression(m, true)
+		}
+		id := p.parseIdent(false)
+		if p.is(token.Arrow) && !p.hasNewlineBefore() {
+			return p.parseArrow(m, []ast.Pattern{id}, false)
+		}
+		if id.Name == "async" && p.is(token.Identifier) && !p.hasNewlineBefore() {
+			// async x => ...
+			param := p.parseIdent(false)
+			if !p.is(token.Arrow) || p.hasNewlineBefore() {
+				p.unexpected()
+			}
+			return p.parseArrow(m, []ast.Pattern{param}, true)
+		}
+		return id
+	case token.Numeric, token.String, token.Null, token.True, token.False:
+		return p.parseLiteral()
+	case token.Slash, token.DivisionAssignment:
+		tok, err := p.l.ReScanSlashToken()
+		if err != nil {
+			panic(bailout{err})
+		}
+		p.cur = tok
+		return p.parseLiteral()
+	case token.LParen:
+		return p.parseParenAndDistinguish()
+	case token.LBracket:
+		p.next()
+		elements := []ast.Expression{}
+		for !p.eat(token.RBracket) {
+			if p.is(token.Comma) {
+				// Hole
+				p.next()
+				elements = append(elements, nil)
+				continue
+			}
+			if p.is(token.Ellipsis) {
+				elements = append(elements, p.parseSpread())
+			} else {
+				elements = append(elements, p.parseMaybeAssign(false))
+			}
+			if !p.is(token.RBracket) {
+				p.expect(token.Comma)
+			}
+		}
+		return &ast.ArrayExpression{Base: p.finish("ArrayExpression", m), Elements: elements}
+	case token.LBrace:
+		return p.parseObject()
+	case token.Function:
+		return p.parseFunctionExpression(m, false)
+	case token.Class:
+		p.next()
+		var id *ast.Identifier
+		if p.is(token.Identifier) {
+			id = p.parseBindingIdent()
+		}
+		class := p.parseClassRest(id)
+		return &ast.ClassExpression{Base: p.finish("ClassExpression", m), Class: class}
+	case token.New:
+		return p.parseNew()
+	case token.TemplateStart:
+		return p.parseTemplate(false)
+	case token.Import:
+		p.next()
+		if p.eat(token.Dot) {
+			meta := &ast.Identifier{Base: p.finish("Identifier", m), Name: "import"}
+			property := p.parseIdent(true)
+			if property.Name != "meta" {
+				p.raiseAt(property, "The only valid meta property for import is 'import.meta'")
+			}
+			p.checkModule()
+			return &ast.MetaProperty{Base: p.finish("MetaProperty", m), Meta: meta, Property: property}
+		}
+		p.expect(token.LParen)
+		source := p.parseMaybeAssign(false)
+		var options ast.Expression
+		if p.eat(token.Comma) && !p.is(token.RParen) {
+			options = p.parseMaybeAssign(false)
+			p.eat(token.Comma)
+		}
+		p.expect(token.RParen)
+		return &ast.ImportExpression{Base: p.finish("ImportExpression", m), Source: source, Options: options}
+	}
+
+	p.unexpected()
+	return nil
+}
+
+func (p *Parser) parseFunctionExpression(m marker, async bool) ast.Expression {
+	p.expect(token.Function)
+	generator := p.eat(token.Star)
+	var id *ast.Identifier
+	if !p.is(token.LParen) {
+		// The name of a generator or async function follows its own rules
+		// for yield and await.
+		saved := p.enterFunction(p.inFunction && async, generator)
+		id = p.parseBindingIdent()
+		p.exitFunction(saved)
+	}
+	fn := p.parseFunctionRest(id, async, generator, false)
+	return &ast.FunctionExpression{Base: p.finish("FunctionExpression", m), Function: fn}
+}
+
+func (p *Parser) parseNew() ast.Expression {
+	m := p.mark()
+	p.next()
+	if p.eat(token.Dot) {
+		meta := &ast.Identifier{Base: p.finish("Identifier", m), Name: "new"}
+		property := p.parseIdent(true)
+		if property.Name != "target" {
+			p.raiseAt(property, "The only valid meta property for new is 'new.target'")
+		}
+		return &ast.MetaProperty{Base: p.finish("MetaProperty", m), Meta: meta, Property: property}
+	}
+
+	cm := p.mark()
+	if p.is(token.Import) {
+		p.raise(p.cur, "Cannot use new with import()")
+	}
+	callee := p.parseSubscripts(p.parseExprAtom(), cm, true)
+	args := []ast.Expression{}
+	if p.eat(token.LParen) {
+		args = p.parseExprList(token.RParen)
+	}
+	return &ast.NewExpression{Base: p.finish("NewExpression", m), Callee: callee, Arguments: args}
+}
+
+// parseParenAndDistinguish parses a parenthesized expression or the
+// parameters of an arrow function, which begin alike.
+func (p *Parser) parseParenAndDistinguish() ast.Expression {
+	m := p.mark()
+	p.expect(token.LParen)
+
+	im := p.mark()
+	var exprs []ast.Expression
+	var rest *ast.SpreadElement
+	trailingComma := false
+	for !p.is(token.RParen) {
+		if p.is(token.Ellipsis) {
+			rest = p.parseSpread()
+			if !p.is(token.RParen) {
+				p.raise(p.cur, "Comma is not permitted after the rest element")
+			}
+			break
+		}
+		exprs = append(exprs, p.parseMaybeAssign(false))
+		if !p.is(token.RParen) {
+			p.expect(token.Comma)
+			trailingComma = p.is(token.RParen)
+		}
+	}
+	p.expect(token.RParen)
+
+	if p.is(token.Arrow) && !p.hasNewlineBefore() {
+		if rest != nil {
+			exprs = append(exprs, rest)
+		}
+		return p.parseArrow(m, p.toParams(exprs), false)
+	}
+
+	if len(exprs) == 0 || rest != nil || trailingComma {
+		p.unexpected()
+	}
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	seq := &ast.SequenceExpression{Expressions: exprs}
+	seq.Base = p.finish("SequenceExpression", im)
+	last := exprs[len(exprs)-1].Info()
+	seq.End = last.End
+	seq.Loc.End = last.Loc.End
+	return seq
+}
+
+// parseArrow parses the body of an arrow function, starting at the arrow.
+func (p *Parser) parseArrow(m marker, params []ast.Pattern, async bool) ast.Expression {
+	p.expect(token.Arrow)
+	saved := p.enterFunction(async, false)
+	defer p.exitFunction(saved)
+
+	for _, param := range params {
+		p.declare(param, bindVar)
+	}
+	p.checkParams(params)
+	fn := ast.Function{Params: params, Async: async}
+	if p.is(token.LBrace) {
+		fn.Body = p.parseFunctionBody()
+	} else {
+		fn.Body = p.parseMaybeAssign(false)
+		fn.Expression = true
+	}
+	return &ast.ArrowFunctionExpression{Base: p.finish("ArrowFunctionExpression", m), Function: fn}
+}
+
+func (p *Parser) parseObject() ast.Expression {
+	m := p.mark()
+	p.expect(token.LBrace)
+	props := []ast.Node{}
+	for !p.eat(token.RBrace) {
+		if p.is(token.Ellipsis) {
+			props = append(props, p.parseSpread())
+		} else {
+			props = append(props, p.parseProperty())
+		}
+		if !p.is(token.RBrace) {
+			p.expect(token.Comma)
+		}
+	}
+	return &ast.ObjectExpression{Base: p.finish("ObjectExpression", m), Properties: props}
+}
+
+func (p *Parser) parseProperty() *ast.Property {
+	m := p.mark()
+	async, generator := false, false
+	kind := "init"
+
+	if p.isWord("async") || p.isWord("get") || p.isWord("set") {
+		word := p.parseIdent(false)
+		if p.isModifierEnd() || word.Name == "async" && p.hasNewlineBefore() {
+			return p.parsePropertyValue(m, word, false, false, false, kind)
+		}
+		if word.Name == "async" {
+			async = true
+		} else {
+			kind = word.Name
+		}
+	}
+	if p.eat(token.Star) {
+		if kind != "init" {
+			p.unexpected()
+		}
+		generator = true
+	}
+
+	key, computed := p.parsePropertyName(false)
+	return p.parsePropertyValue(m, key, computed, async, generator, kind)
+}
+
+func (p *Parser) parsePropertyValue(m marker, key ast.Expression, computed, async, generator bool, kind string) *ast.Property {
+	prop := &ast.Property{Key: key, Kind: kind, Computed: computed}
+
+	switch {
+	case p.is(token.LParen):
+		fm := p.mark()
+		fn := p.parseFunctionRest(nil, async, generator, true)
+		prop.Value = &ast.FunctionExpression{Base: p.finish("FunctionExpression", fm), Function: fn}
+		prop.Method = kind == "init"
+	case async || generator || kind != "init":
+		p.unexpected()
+	case p.eat(token.Colon):
+		prop.Value = p.parseMaybeAssign(false)
+	default:
+		id, ok := key.(*ast.Identifier)
+		if !ok || computed || token.IsKeyword(id.Name) && id.Name != "await" && id.Name != "yield" {
+			p.unexpected()
+		}
+		if id.Name == "yield" && (p.inGenerator || p.strict()) {
+			p.raiseAt(id, "The keyword 'yield' is reserved")
+		}
+		prop.Shorthand = true
+		if p.is(token.Assignment) {
+			// Cover initializer, valid only if the object is a pattern
+			p.coverInits = append(p.coverInits, coverInit{prop, p.cur})
+			p.next()
+			right := p.parseMaybeAssign(false)
+			prop.Value = &ast.AssignmentPattern{Base: p.finish("AssignmentPattern", markerOf(id)), Left: id, Right: right}
+		} else {
+			value := *id
+			prop.Value = &value
+		}
+	}
+
+	prop.Base = p.finish("Property", m)
+	return prop
+}
+
+// parsePropertyName parses the key of a property or class element and
+// reports whether it is computed.
+func (p *Parser) parsePropertyName(allowPrivate bool) (ast.Expression, bool) {
+	switch p.cur.Type.Label {
+	case token.LBracket:
+		p.next()
+		key := p.parseMaybeAssign(false)
+		p.expect(token.RBracket)
+		return key, true
+	case token.String, token.Numeric:
+		return p.parseLiteral(), false
+	case token.PrivateName:
+		if !allowPrivate {
+			p.unexpected()
+		}
+		return p.parsePrivateIdentifier(), false
+	}
+	return p.parseIdent(true), false
+}
+
+// parseIdent parses an identifier. If liberal is true, keywords are
+// accepted as well, as in property names.
+func (p *Parser) parseIdent(liberal bool) *ast.Identifier {
+	m := p.mark()
+	label := p.cur.Type.Label
+	switch {
+	case label == token.Identifier:
+	case liberal && token.IsKeyword(label):
+	case label == token.Yield && !p.inGenerator:
+		if p.strict() {
+			p.raise(p.cur, "The keyword 'yield' is reserved")
+		}
+	case label == token.Await && !p.canAwait():
+	default:
+		p.unexpected()
+	}
+	name := p.cur.Literal
+	p.next()
+	return &ast.Identifier{Base: p.finish("Identifier", m), Name: name}
+}
+
+// parseBindingIdent parses an identifier that declares a binding.
+func (p *Parser) parseBindingIdent() *ast.Identifier {
+	return p.parseIdent(false)
+}
+
+func (p *Parser) parsePrivateIdentifier() *ast.PrivateIdentifier {
+	m := p.mark()
+	name := p.cur.Literal
+	p.expect(token.PrivateName)
+	return &ast.PrivateIdentifier{Base: p.finish("PrivateIdentifier", m), Name: name}
+}
+
+// parseTemplate parses a template literal. In a tagged template, invalid
+// escape sequences are allowed and have no cooked value.
+func (p *Parser) parseTemplate(tagged bool) *ast.TemplateLiteral {
+	m := p.mark()
+	p.expect(token.TemplateStart)
+
+	tmpl := &ast.TemplateLiteral{Quasis: []*ast.TemplateElement{}, Expressions: []ast.Expression{}}
+	for {
+		tmpl.Quasis = append(tmpl.Quasis, p.parseTemplateElement(tagged))
+		if p.is(token.TemplateEnd) {
+			tmpl.Quasis[len(tmpl.Quasis)-1].Tail = true
+			p.next()
+			break
+		}
+		if !p.eat(token.SubstitutionStart) {
+			p.unexpected()
+		}
+		tmpl.Expressions = append(tmpl.Expressions, p.parseExpression(false))
+		if !p.is(token.SubstitutionEnd) {
+			p.unexpected()
+		}
+		p.next()
+	}
+	tmpl.Base = p.finish("TemplateLiteral", m)
+	return tmpl
+}
+
+func (p *Parser) parseTemplateElement(tagged bool) *ast.TemplateElement {
+	elem := &ast.TemplateElement{}
+	if p.is(token.String) {
+		m := p.mark()
+		elem.Value.Raw = normalizeLineTerminators(p.cur.Literal)
+		p.next()
+		elem.Base = p.finish("TemplateElement", m)
+	} else {
+		// Empty element, which ends where it starts
+		elem.Base = ast.Base{
+			Type:  "TemplateElement",
+			Start: p.cur.Start,
+			End:   p.cur.Start,
+			Loc:   token.SourceLocation{Start: p.cur.Loc.Start, End: p.cur.Loc.Start},
+		}
+	}
+	raw := elem.Value.Raw
+	if cooked, ok := cookTemplate(raw); ok {
+		elem.Value.Cooked = &cooked
+	} else if !tagged {
+		p.raiseAt(elem, "Bad escape sequence in untagged template literal")
+	}
+	return elem
+}
@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// parseLiteral parses a numeric, string, regular expression, null, or
+// boolean literal.
+func (p *Parser) parseLiteral() *ast.Literal {
+	m := p.mark()
+	tok := p.cur
+	lit := &ast.Literal{Raw: p.l.Source()[tok.Start:tok.End]}
+	switch tok.Type.Label {
+	case token.Numeric:
+		if strings.HasSuffix(lit.Raw, "n") {
+			lit.Bigint = bigintValue(lit.Raw)
+		} else {
+			lit.Value = numericValue(lit.Raw)
+		}
+	case token.String:
+		cooked, ok := cookString(lit.Raw[1 : len(lit.Raw)-1])
+		if !ok {
+			p.raise(tok, "Bad character escape sequence")
+		}
+		lit.Value = cooked
+	case token.RegExp:
+		i := strings.LastIndexByte(lit.Raw, '/')
+		lit.Regex = &ast.RegExpLiteral{Pattern: lit.Raw[1:i], Flags: lit.Raw[i+1:]}
+	case token.Null:
+		lit.Value = nil
+	case token.True:
+		lit.Value = true
+	case token.False:
+		lit.Value = false
+	default:
+		p.unexpected()
+	}
+	p.next()
+	lit.Base = p.finish("Literal", m)
+	return lit
+}
+
+// numericValue returns the value of a numeric literal.
+func numericValue(raw string) float64 {
+	raw = strings.ReplaceAll(raw, "_", "")
+	if len(raw) > 1 && raw[0] == '0' {
+		base := 0
+		switch raw[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		default:
+			// Legacy octal, unless a digit 8 or 9 makes it decimal
+			if !strings.ContainsAny(raw, ".eE89") {
+				return baseNValue(raw[1:], 8)
+			}
+		}
+		if base != 0 {
+			return baseNValue(raw[2:], base)
+		}
+	}
+	// Out of range values become ±Inf, which ParseFloat returns along with
+	// an error
+	f, _ := strconv.ParseFloat(raw, 64)
+	return f
+}
+
+func baseNValue(digits string, base int) float64 {
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return math.NaN()
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f
+}
+
+// bigintValue returns the decimal digits of a BigInt literal, as ESTree
+// represents them.
+func bigintValue(raw string) string {
+	raw = strings.ReplaceAll(strings.TrimSuffix(raw, "n"), "_", "")
+	base := 10
+	if len(raw) > 1 && raw[0] == '0' {
+		switch raw[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+	}
+	if base == 10 {
+		return raw
+	}
+	n, _ := new(big.Int).SetString(raw[2:], base)
+	return n.String()
+}
+
+// cookString returns the value of the body of a string literal, with its
+// escape sequences interpreted. It reports false for a malformed escape.
+func cookString(body string) (string, bool) {
+	return cook(body, true)
+}
+
+// cookTemplate returns the cooked value of a template element. It reports
+// false for a malformed escape or a legacy octal one, which tagged
+// templates allow and leave without a cooked value.
+func cookTemplate(raw string) (string, bool) {
+	return cook(raw, false)
+}
+
+func cook(s string, allowOctal bool) (string, bool) {
+	if !strings.ContainsRune(s, '\\') {
+		return s, true
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", false
+		}
+		ch := s[i]
+		i++
+		switch ch {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\r':
+			// Line continuation
+			if i < len(s) && s[i] == '\n' {
+				i++
+			}
+		case '\n':
+			// Line continuation
+		case 'x':
+			if i+2 > len(s) {
+				return "", false
+			}
+			n, err := strconv.ParseUint(s[i:i+2], 16, 8)
+			if err != nil {
+				return "", false
+			}
+			b.WriteRune(rune(n))
+			i += 2
+		case 'u':
+			r, n, ok := readUnicodeEscape(s[i:])
+			if !ok {
+				return "", false
+			}
+			i += n
+			// A surrogate pair written as two escapes
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i:], `\u`) {
+				if r2, n2, ok := readUnicodeEscape(s[i+2:]); ok {
+					if pair := utf16.DecodeRune(r, r2); pair != utf8.RuneError {
+						r = pair
+						i += 2 + n2
+					}
+				}
+			}
+			b.WriteRune(r)
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			if ch == '0' && (i >= len(s) || !isDigit(s[i])) {
+				b.WriteByte(0)
+				break
+			}
+			if !allowOctal {
+				return "", false
+			}
+			// Legacy octal escape of up to three digits, at most \377
+			j := i
+			for j < len(s) && j-i < 2 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			if ch > '3' && j > i+1 {
+				j = i + 1
+			}
+			n, _ := strconv.ParseUint(s[i-1:j], 8, 8)
+			b.WriteRune(rune(n))
+			i = j
+		case '8', '9':
+			if !allowOctal {
+				return "", false
+			}
+			b.WriteByte(ch)
+		default:
+			// Identity escape, which may be a multibyte character
+			r, size := utf8.DecodeRuneInString(s[i-1:])
+			if r == '\u2028' || r == '\u2029' {
+				// Line continuation
+			} else {
+				b.WriteRune(r)
+			}
+			i += size - 1
+		}
+	}
+	return b.String(), true
+}
+
+// readUnicodeEscape reads the part of a \u escape following the u, either
+// four hex digits or a code point in braces, and returns the rune and the
+// number of bytes read.
+func readUnicodeEscape(s string) (rune, int, bool) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return 0, 0, false
+		}
+		n, err := strconv.ParseUint(s[1:end], 16, 32)
+		if err != nil || n > maxCodePoint {
+			return 0, 0, false
+		}
+		return rune(n), end + 1, true
+	}
+	if len(s) < 4 {
+		return 0, 0, false
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	return rune(n), 4, true
+}
+
+const maxCodePoint = 0x10FFFF
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// normalizeLineTerminators replaces CRLF and CR in the raw text of a
+// template element with LF, as the specification requires.
+func normalizeLineTerminators(raw string) string {
+	if !strings.ContainsRune(raw, '\r') {
+		return raw
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n")
+}
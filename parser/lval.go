@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// parseBindingAtom parses the target of a binding: an identifier or an
+// array or object pattern.
+func (p *Parser) parseBindingAtom() ast.Pattern {
+	m := p.mark()
+	switch p.cur.Type.Label {
+	case token.LBracket:
+		p.next()
+		elements := p.parseBindingList(token.RBracket, true)
+		return &ast.ArrayPattern{Base: p.finish("ArrayPattern", m), Elements: elements}
+	case token.LBrace:
+		return p.parseObjectPattern()
+	}
+	return p.parseBindingIdent()
+}
+
+// parseBindingList parses binding elements, with optional defaults and a
+// final rest element, up to and including the closing token. If allowHoles
+// is true, as in array patterns, elements may be elided.
+func (p *Parser) parseBindingList(closing string, allowHoles bool) []ast.Pattern {
+	elements := []ast.Pattern{}
+	for !p.eat(closing) {
+		if allowHoles && p.is(token.Comma) {
+			p.next()
+			elements = append(elements, nil)
+			continue
+		}
+		if p.is(token.Ellipsis) {
+			elements = append(elements, p.parseRestBinding())
+			if !p.is(closing) {
+				p.raise(p.cur, "Comma is not permitted after the rest element")
+			}
+			continue
+		}
+		elements = append(elements, p.parseMaybeDefault())
+		if !p.is(closing) {
+			p.expect(token.Comma)
+		}
+	}
+	return elements
+}
+
+func (p *Parser) parseRestBinding() *ast.RestElement {
+	m := p.mark()
+	p.expect(token.Ellipsis)
+	arg := p.parseBindingAtom()
+	return &ast.RestElement{Base: p.finish("RestElement", m), Argument: arg}
+}
+
+// parseMaybeDefault parses a binding element with an optional default
+// value.
+func (p *Parser) parseMaybeDefault() ast.Pattern {
+	m := p.mark()
+	left := p.parseBindingAtom()
+	if !p.eat(token.Assignment) {
+		return left
+	}
+	right := p.parseMaybeAssign(false)
+	return &ast.AssignmentPattern{Base: p.finish("AssignmentPattern", m), Left: left, Right: right}
+}
+
+func (p *Parser) parseObjectPattern() ast.Pattern {
+	m := p.mark()
+	p.expect(token.LBrace)
+	props := []ast.Node{}
+	for !p.eat(token.RBrace) {
+		if p.is(token.Ellipsis) {
+			rm := p.mark()
+			p.next()
+			arg := p.parseBindingIdent()
+			props = append(props, &ast.RestElement{Base: p.finish("RestElement", rm), Argument: arg})
+			if !p.is(token.RBrace) {
+				p.raise(p.cur, "Comma is not permitted after the rest element")
+			}
+			continue
+		}
+
+		pm := p.mark()
+		key, computed := p.parsePropertyName(false)
+		prop := &ast.Property{Key: key, Kind: "init", Computed: computed}
+		if p.eat(token.Colon) {
+			prop.Value = p.parseMaybeDefault()
+		} else {
+			id, ok := key.(*ast.Identifier)
+			if !ok || computed || token.IsKeyword(id.Name) {
+				p.unexpected()
+			}
+			prop.Shorthand = true
+			value := *id
+			prop.Value = &value
+			if p.eat(token.Assignment) {
+				right := p.parseMaybeAssign(false)
+				prop.Value = &ast.AssignmentPattern{Base: p.finish("AssignmentPattern", markerOf(id)), Left: &value, Right: right}
+			}
+		}
+		prop.Base = p.finish("Property", pm)
+		props = append(props, prop)
+
+		if !p.is(token.RBrace) {
+			p.expect(token.Comma)
+		}
+	}
+	return &ast.ObjectPattern{Base: p.finish("ObjectPattern", m), Properties: props}
+}
+
+// toAssignable converts an expression parsed before an assignment operator,
+// or in the head of a for-in or for-of statement, to the pattern it stands
+// for.
+func (p *Parser) toAssignable(expr ast.Node) ast.Pattern {
+	switch n := expr.(type) {
+	case *ast.Identifier:
+		return n
+	case *ast.MemberExpression:
+		return n
+	case *ast.ObjectPattern, *ast.ArrayPattern, *ast.AssignmentPattern, *ast.RestElement:
+		return n.(ast.Pattern)
+	case *ast.ObjectExpression:
+		pattern := &ast.ObjectPattern{Base: n.Base, Properties: []ast.Node{}}
+		pattern.Type = "ObjectPattern"
+		for i, prop := range n.Properties {
+			switch prop := prop.(type) {
+			case *ast.Property:
+				if prop.Kind != "init" || prop.Method {
+					p.raiseAt(prop.Key, "Object pattern can't contain getter, setter, or method")
+				}
+				p.dropCoverInit(prop)
+				prop.Value = p.toAssignable(prop.Value)
+				pattern.Properties = append(pattern.Properties, prop)
+			case *ast.SpreadElement:
+				if i != len(n.Properties)-1 {
+					p.raiseAt(prop, "Comma is not permitted after the rest element")
+				}
+				pattern.Properties = append(pattern.Properties, p.toRest(prop))
+			}
+		}
+		return pattern
+	case *ast.ArrayExpression:
+		pattern := &ast.ArrayPattern{Base: n.Base, Elements: []ast.Pattern{}}
+		pattern.Type = "ArrayPattern"
+		for i, elem := range n.Elements {
+			switch elem := elem.(type) {
+			case nil:
+				pattern.Elements = append(pattern.Elements, nil)
+			case *ast.SpreadElement:
+				if i != len(n.Elements)-1 {
+					p.raiseAt(elem, "Comma is not permitted after the rest element")
+				}
+				pattern.Elements = append(pattern.Elements, p.toRest(elem))
+			default:
+				pattern.Elements = append(pattern.Elements, p.toAssignable(elem))
+			}
+		}
+		return pattern
+	case *ast.AssignmentExpression:
+		if n.Operator != token.Assignment {
+			p.raiseAt(n, "Only '=' operator can be used for specifying default value.")
+		}
+		pattern := &ast.AssignmentPattern{Base: n.Base, Left: n.Left, Right: n.Right}
+		pattern.Type = "AssignmentPattern"
+		return pattern
+	}
+	p.raiseAt(expr, "Assigning to rvalue")
+	return nil
+}
+
+// dropCoverInit forgets the cover initializer of prop, if any, as the
+// object of prop is a pattern.
+func (p *Parser) dropCoverInit(prop *ast.Property) {
+	for i, c := range p.coverInits {
+		if c.prop == prop {
+			p.coverInits = append(p.coverInits[:i], p.coverInits[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *Parser) toRest(spread *ast.SpreadElement) *ast.RestElement {
+	rest := &ast.RestElement{Base: spread.Base, Argument: p.toAssignable(spread.Argument)}
+	rest.Type = "RestElement"
+	return rest
+}
+
+// toParams converts the expressions parsed before an arrow to parameters.
+func (p *Parser) toParams(exprs []ast.Expression) []ast.Pattern {
+	params := []ast.Pattern{}
+	for i, expr := range exprs {
+		if spread, ok := expr.(*ast.SpreadElement); ok {
+			if i != len(exprs)-1 {
+				p.raiseAt(spread, "Comma is not permitted after the rest element")
+			}
+			params = append(params, p.toRest(spread))
+			continue
+		}
+		param := p.toAssignable(expr)
+		if _, ok := param.(*ast.MemberExpression); ok {
+			p.raiseAt(param, "Binding member expression")
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// checkSimpleAssignTarget checks that expr, the operand of an update or a
+// compound assignment, is an identifier or a member expression.
+func (p *Parser) checkSimpleAssignTarget(expr ast.Expression) ast.Pattern {
+	switch n := expr.(type) {
+	case *ast.Identifier:
+		return n
+	case *ast.MemberExpression:
+		return n
+	}
+	p.raiseAt(expr, "Assigning to rvalue")
+	return nil
+}
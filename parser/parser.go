@@ -0,0 +1,299 @@
+// Package parser implements a recursive descent parser for JavaScript that
+// builds an ESTree syntax tree from the tokens of a lexer.Lexer.
+package parser
+
+import (
+	"fmt"
+
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+type Parser struct {
+	l   *lexer.Lexer
+	cur *token.Token
+
+	// End of the previous token, which is where the node being parsed ends
+	prevEnd    int
+	prevEndPos token.Position
+
+	module bool
+
+	// Function context
+	inFunction  bool
+	inAsync     bool
+	inGenerator bool
+
+	// Labels, loops, and switch statements enclosing the current statement
+	// in the current function, which break and continue may target
+	labels []label
+
+	// Scopes from the program to the current block
+	scopes []*scope
+
+	// Shorthand properties with an initializer, like {a = 1}, which are
+	// valid only in objects that turn out to be patterns
+	coverInits []coverInit
+}
+
+// label is a labeled statement, or a loop or switch statement, which has no
+// name.
+type label struct {
+	name string
+	// Kind is "loop" or "switch" for the statements continue or break
+	// without a label may target, or empty.
+	kind string
+	// Start of the body of a labeled statement
+	body int
+}
+
+// coverInit is a shorthand property with an initializer and its = token.
+type coverInit struct {
+	prop   *ast.Property
+	assign *token.Token
+}
+
+// bailout is panicked with to abandon parsing at the first error.
+type bailout struct {
+	err error
+}
+
+func New(l *lexer.Lexer) *Parser {
+	return &Parser{
+		l:      l,
+		module: l.SourceType() == lexer.Module,
+	}
+}
+
+// ParseProgram parses the whole input. It stops at the first syntax error,
+// which is returned as a *lexer.SyntaxError.
+func (p *Parser) ParseProgram() (prog *ast.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			prog, err = nil, b.err
+		}
+	}()
+
+	p.next()
+	p.enterScope(true)
+	m := p.mark()
+	body := p.parseStatementList(true, token.EOF)
+	prog = &ast.Program{Body: body, SourceType: "script"}
+	if p.module {
+		prog.SourceType = "module"
+	}
+	prog.Base = p.finish("Program", m)
+	// The program spans the whole input, including trailing whitespace.
+	prog.End = p.cur.End
+	prog.Loc.End = p.cur.Loc.End
+	return prog, nil
+}
+
+// ParseScript parses src as a classic script.
+func ParseScript(src string) (*ast.Program, error) {
+	return New(lexer.New(src, lexer.WithSourceType(lexer.Script))).ParseProgram()
+}
+
+// ParseModule parses src as a module, which is strict mode code.
+func ParseModule(src string) (*ast.Program, error) {
+	return New(lexer.New(src, lexer.WithSourceType(lexer.Module), lexer.WithStrict())).ParseProgram()
+}
+
+// next advances to the next token, skipping comments.
+func (p *Parser) next() {
+	p.advance(lexer.GoalDiv)
+}
+
+func (p *Parser) advance(goal lexer.Goal) {
+	if p.cur != nil {
+		p.prevEnd = p.cur.End
+		p.prevEndPos = p.cur.Loc.End
+	}
+	for {
+		tok, err := p.l.NextTokenWithGoal(goal)
+		if err != nil {
+			panic(bailout{err})
+		}
+		if tok.Type.Label == token.LineComment || tok.Type.Label == token.BlockComment {
+			continue
+		}
+		p.cur = asName(tok)
+		return
+	}
+}
+
+// peek returns the token after the current one.
+func (p *Parser) peek() *token.Token {
+	for n := 0; ; n++ {
+		tok, err := p.l.Peek(n)
+		if err != nil && tok == nil {
+			// Let the error surface when the token is actually read.
+			return &token.Token{Type: token.TokenType{Label: token.EOF}}
+		}
+		if tok.Type.Label != token.LineComment && tok.Type.Label != token.BlockComment {
+			return asName(tok)
+		}
+	}
+}
+
+// asName returns tok as an identifier if it is a contextual keyword, which
+// the parser recognizes by name like let and async.
+func asName(tok *token.Token) *token.Token {
+	if token.IsContextualKeyword(tok.Type.Label) {
+		tok.Type = token.TokenType{Label: token.Identifier}
+	}
+	return tok
+}
+
+func (p *Parser) is(label string) bool {
+	return p.cur.Type.Label == label
+}
+
+// isWord reports whether the current token is the identifier name, which
+// is how contextual keywords such as let, async, and of are recognized.
+func (p *Parser) isWord(name string) bool {
+	return p.cur.Type.Label == token.Identifier && p.cur.Literal == name
+}
+
+func (p *Parser) eat(label string) bool {
+	if p.is(label) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) eatWord(name string) bool {
+	if p.isWord(name) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) expect(label string) {
+	if !p.eat(label) {
+		p.unexpected()
+	}
+}
+
+func (p *Parser) expectWord(name string) {
+	if !p.eatWord(name) {
+		p.unexpected()
+	}
+}
+
+// hasNewlineBefore reports whether a line terminator separates the current
+// token from the previous one.
+func (p *Parser) hasNewlineBefore() bool {
+	return p.cur.Loc.Start.Line > p.prevEndPos.Line
+}
+
+// canInsertSemicolon reports whether a semicolon may be inserted before the
+// current token.
+func (p *Parser) canInsertSemicolon() bool {
+	return p.is(token.EOF) || p.is(token.RBrace) || p.hasNewlineBefore()
+}
+
+func (p *Parser) semicolon() {
+	if !p.eat(token.Semicolon) && !p.canInsertSemicolon() {
+		p.unexpected()
+	}
+}
+
+func (p *Parser) unexpected() {
+	if p.is(token.EOF) {
+		p.raise(p.cur, "Unexpected end of input")
+	}
+	p.raise(p.cur, "Unexpected token")
+}
+
+func (p *Parser) raise(tok *token.Token, format string, a ...interface{}) {
+	panic(bailout{&lexer.SyntaxError{
+		Message: fmt.Sprintf(format, a...),
+		Line:    tok.Loc.Start.Line,
+		Column:  tok.Loc.Start.Column,
+		Offset:  tok.Start,
+	}})
+}
+
+func (p *Parser) raiseAt(n ast.Node, format string, a ...interface{}) {
+	b := n.Info()
+	panic(bailout{&lexer.SyntaxError{
+		Message: fmt.Sprintf(format, a...),
+		Line:    b.Loc.Start.Line,
+		Column:  b.Loc.Start.Column,
+		Offset:  b.Start,
+	}})
+}
+
+// marker is the start of a node being parsed.
+type marker struct {
+	start int
+	pos   token.Position
+}
+
+func (p *Parser) mark() marker {
+	return marker{start: p.cur.Start, pos: p.cur.Loc.Start}
+}
+
+func markerOf(n ast.Node) marker {
+	b := n.Info()
+	return marker{start: b.Start, pos: b.Loc.Start}
+}
+
+// finish returns the base of a node of type typ that starts at m and ends
+// with the previous token.
+func (p *Parser) finish(typ string, m marker) ast.Base {
+	return ast.Base{
+		Type:  typ,
+		Start: m.start,
+		End:   p.prevEnd,
+		Loc:   token.SourceLocation{Start: m.pos, End: p.prevEndPos},
+	}
+}
+
+// functionContext saves the function context so that it can be restored
+// after parsing a nested function, which has a scope of its own.
+type functionContext struct {
+	inFunction  bool
+	inAsync     bool
+	inGenerator bool
+	labels      []label
+}
+
+func (p *Parser) enterFunction(async, generator bool) functionContext {
+	saved := functionContext{p.inFunction, p.inAsync, p.inGenerator, p.labels}
+	p.inFunction = true
+	p.inAsync = async
+	p.inGenerator = generator
+	p.labels = nil
+	p.enterScope(true)
+	return saved
+}
+
+func (p *Parser) exitFunction(saved functionContext) {
+	p.inFunction = saved.inFunction
+	p.inAsync = saved.inAsync
+	p.inGenerator = saved.inGenerator
+	p.labels = saved.labels
+	p.exitScope()
+}
+
+// strict reports whether the current token is in strict mode code, which
+// the lexer keeps track of: all of the input with lexer.WithStrict, as
+// ParseModule lexes it, classes, and the code after a "use strict"
+// directive.
+func (p *Parser) strict() bool {
+	return p.l.Strict()
+}
+
+// canAwait reports whether await starts an await expression here.
+func (p *Parser) canAwait() bool {
+	return p.inAsync || p.module && !p.inFunction
+}
@@ -0,0 +1,612 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b * c", "(a + (b * c))"},
+		{"a * b + c", "((a * b) + c)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+		{"(-a) ** b", "((-a) ** b)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a ?? b", "(a ?? b)"},
+		{"(a || b) ?? c", "((a || b) ?? c)"},
+		{"a ?? (b && c)", "(a ?? (b && c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a | b ^ c & d", "(a | (b ^ (c & d)))"},
+		{"a == b < c << d", "(a == (b < (c << d)))"},
+		{"a in b instanceof c", "((a in b) instanceof c)"},
+		{"!a === typeof b", "((!a) === (typeof b))"},
+		{"a++ + ++b", "((a++) + (++b))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"a += b ? c : d", "(a += (b ? c : d))"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"a.b(c)[d]", "a.b(c)[d]"},
+		{"new a.b(c).d", "new a.b(c).d"},
+		{"new new a()()", "new new a()()"},
+		{"a?.b.c(d)", "a?.b.c(d)"},
+		{"a, b = c", "a, (b = c)"},
+		{"x => y + 1", "(x) => (y + 1)"},
+		{"async (a, b) => a", "async (a, b) => a"},
+		{"async(a, b)", "async(a, b)"},
+		{"a / b / c", "((a / b) / c)"},
+		{"a = /b/g", "(a = /b/g)"},
+	}
+
+	for i, tt := range tests {
+		prog, err := ParseScript(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		stmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("tests[%d] - statement is not *ast.ExpressionStatement. got=%T", i, prog.Body[0])
+		}
+		if got := render(stmt.Expression); got != tt.expected {
+			t.Fatalf("tests[%d] - expression wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"var a = 1; let b; const c = 2", []string{"VariableDeclaration", "VariableDeclaration", "VariableDeclaration"}},
+		{"function f() {} class A {}", []string{"FunctionDeclaration", "ClassDeclaration"}},
+		{"async function f() {} function* g() {}", []string{"FunctionDeclaration", "FunctionDeclaration"}},
+		{"if (a) b; else c", []string{"IfStatement"}},
+		{"for (;;) {} for (a in b) ; for (const x of y) {}", []string{"ForStatement", "ForInStatement", "ForOfStatement"}},
+		{"while (a) b(); do b(); while (a)", []string{"WhileStatement", "DoWhileStatement"}},
+		{"try {} catch {} try {} catch (e) {} finally {}", []string{"TryStatement", "TryStatement"}},
+		{"switch (a) { case 1: break; default: }", []string{"SwitchStatement"}},
+		{"a: for (;;) { continue a }", []string{"LabeledStatement"}},
+		{"a: b: while (c) continue a; a: { break a }", []string{"LabeledStatement", "LabeledStatement"}},
+		{"do { if (a) continue; else break } while (b)", []string{"DoWhileStatement"}},
+		{"throw a; debugger; ;", []string{"ThrowStatement", "DebuggerStatement", "EmptyStatement"}},
+		{"{ a }", []string{"BlockStatement"}},
+		{"with (a) b", []string{"WithStatement"}},
+		// let and async are identifiers unless they start a declaration
+		{"let\nx", []string{"VariableDeclaration"}},
+		{"let = 1", []string{"ExpressionStatement"}},
+		{"let accessor = accessor", []string{"VariableDeclaration"}},
+		{"async\nfunction f() {}", []string{"ExpressionStatement", "FunctionDeclaration"}},
+		// Automatic semicolon insertion
+		{"a\nb", []string{"ExpressionStatement", "ExpressionStatement"}},
+		{"a\n++b", []string{"ExpressionStatement", "ExpressionStatement"}},
+		{"a = b\n(c)", []string{"ExpressionStatement"}},
+		{"function f() { return\na }", []string{"FunctionDeclaration"}},
+	}
+
+	for i, tt := range tests {
+		prog, err := ParseScript(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		var got []string
+		for _, stmt := range prog.Body {
+			got = append(got, stmt.Info().Type)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Fatalf("tests[%d] - statements wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "a"`, `{"type":"ImportDeclaration","specifiers":[],"source":{"type":"Literal","value":"a","raw":"\"a\""},"attributes":[]}`},
+		{`import a, { b as c } from "m"`, `{"type":"ImportDeclaration","specifiers":[{"type":"ImportDefaultSpecifier","local":{"type":"Identifier","name":"a"}},{"type":"ImportSpecifier","imported":{"type":"Identifier","name":"b"},"local":{"type":"Identifier","name":"c"}}],"source":{"type":"Literal","value":"m","raw":"\"m\""},"attributes":[]}`},
+		{`import * as ns from "m" with { type: "json" }`, `{"type":"ImportDeclaration","specifiers":[{"type":"ImportNamespaceSpecifier","local":{"type":"Identifier","name":"ns"}}],"source":{"type":"Literal","value":"m","raw":"\"m\""},"attributes":[{"type":"ImportAttribute","key":{"type":"Identifier","name":"type"},"value":{"type":"Literal","value":"json","raw":"\"json\""}}]}`},
+		{`export { a as "b c" }`, `{"type":"ExportNamedDeclaration","declaration":null,"specifiers":[{"type":"ExportSpecifier","local":{"type":"Identifier","name":"a"},"exported":{"type":"Literal","value":"b c","raw":"\"b c\""}}],"source":null,"attributes":[]}`},
+		{`export * as ns from "m"`, `{"type":"ExportAllDeclaration","exported":{"type":"Identifier","name":"ns"},"source":{"type":"Literal","value":"m","raw":"\"m\""},"attributes":[]}`},
+		{`export default 1 + 2`, `{"type":"ExportDefaultDeclaration","declaration":{"type":"BinaryExpression","operator":"+","left":{"type":"Literal","value":1,"raw":"1"},"right":{"type":"Literal","value":2,"raw":"2"}}}`},
+	}
+
+	for i, tt := range tests {
+		prog, err := ParseModule(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		if got := compactJSON(t, prog.Body[0]); got != tt.expected {
+			t.Fatalf("tests[%d] - AST wrong.\nexpected=%s\ngot=     %s", i, tt.expected, got)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, , b = 1, ...c] = d", `{"type":"ArrayPattern","elements":[{"type":"Identifier","name":"a"},null,{"type":"AssignmentPattern","left":{"type":"Identifier","name":"b"},"right":{"type":"Literal","value":1,"raw":"1"}},{"type":"RestElement","argument":{"type":"Identifier","name":"c"}}]}`},
+		{"let {a, b: [c], d = 1, ...e} = f", `{"type":"ObjectPattern","properties":[{"type":"Property","key":{"type":"Identifier","name":"a"},"value":{"type":"Identifier","name":"a"},"kind":"init","method":false,"shorthand":true,"computed":false},{"type":"Property","key":{"type":"Identifier","name":"b"},"value":{"type":"ArrayPattern","elements":[{"type":"Identifier","name":"c"}]},"kind":"init","method":false,"shorthand":false,"computed":false},{"type":"Property","key":{"type":"Identifier","name":"d"},"value":{"type":"AssignmentPattern","left":{"type":"Identifier","name":"d"},"right":{"type":"Literal","value":1,"raw":"1"}},"kind":"init","method":false,"shorthand":true,"computed":false},{"type":"RestElement","argument":{"type":"Identifier","name":"e"}}]}`},
+	}
+
+	for i, tt := range tests {
+		prog, err := ParseScript(tt.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		decl := prog.Body[0].(*ast.VariableDeclaration)
+		if got := compactJSON(t, decl.Declarations[0].ID); got != tt.expected {
+			t.Fatalf("tests[%d] - pattern wrong.\nexpected=%s\ngot=     %s", i, tt.expected, got)
+		}
+	}
+
+	// Expressions before = are converted to patterns
+	assignments := []struct {
+		input    string
+		expected string
+	}{
+		{"[a, b] = c", "ArrayPattern"},
+		{"({a, b: c = 1} = d)", "ObjectPattern"},
+		{"a.b = c", "MemberExpression"},
+		{"for ([a, b] of c);", "ArrayPattern"},
+		{"([a], {b}) => a", "ArrayPattern"},
+		{"[{a = 1}] = b", "ArrayPattern"},
+		{"({a = 1}) => a", "ObjectPattern"},
+		{"for ({a = 1} of b);", "ObjectPattern"},
+	}
+
+	for i, tt := range assignments {
+		prog, err := ParseScript(tt.input)
+		if err != nil {
+			t.Fatalf("assignments[%d] - unexpected error: %q", i, err.Error())
+		}
+		var target ast.Node
+		switch stmt := prog.Body[0].(type) {
+		case *ast.ExpressionStatement:
+			switch expr := stmt.Expression.(type) {
+			case *ast.AssignmentExpression:
+				target = expr.Left
+			case *ast.ArrowFunctionExpression:
+				target = expr.Params[0]
+			}
+		case *ast.ForOfStatement:
+			target = stmt.Left
+		}
+		if target == nil || target.Info().Type != tt.expected {
+			t.Fatalf("assignments[%d] - target wrong. expected=%q, got=%+v", i, tt.expected, target)
+		}
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0x1F", `{"type":"Literal","value":31,"raw":"0x1F"}`},
+		{"0o17", `{"type":"Literal","value":15,"raw":"0o17"}`},
+		{"0b11", `{"type":"Literal","value":3,"raw":"0b11"}`},
+		{"017", `{"type":"Literal","value":15,"raw":"017"}`},
+		{"019", `{"type":"Literal","value":19,"raw":"019"}`},
+		{"1_000.5e1", `{"type":"Literal","value":10005,"raw":"1_000.5e1"}`},
+		{"0xFFn", `{"type":"Literal","value":null,"raw":"0xFFn","bigint":"255"}`},
+		{`"a\n\x41\u0042\u{1F600}\uD83D\uDE00"`, `{"type":"Literal","value":"a\nAB😀😀","raw":"\"a\\n\\x41\\u0042\\u{1F600}\\uD83D\\uDE00\""}`},
+		{`'it\'s \101'`, `{"type":"Literal","value":"it's A","raw":"'it\\'s \\101'"}`},
+		{"\"a\\\nb\"", `{"type":"Literal","value":"ab","raw":"\"a\\\nb\""}`},
+		{"/[/]+/gu", `{"type":"Literal","value":null,"raw":"/[/]+/gu","regex":{"pattern":"[/]+","flags":"gu"}}`},
+		{"null", `{"type":"Literal","value":null,"raw":"null"}`},
+		{"true", `{"type":"Literal","value":true,"raw":"true"}`},
+		{"`a\\tb`", `{"type":"TemplateLiteral","quasis":[{"type":"TemplateElement","tail":true,"value":{"cooked":"a\tb","raw":"a\\tb"}}],"expressions":[]}`},
+		{"`${a}`", `{"type":"TemplateLiteral","quasis":[{"type":"TemplateElement","tail":false,"value":{"cooked":"","raw":""}},{"type":"TemplateElement","tail":true,"value":{"cooked":"","raw":""}}],"expressions":[{"type":"Identifier","name":"a"}]}`},
+		{"t`\\u`", `{"type":"TaggedTemplateExpression","tag":{"type":"Identifier","name":"t"},"quasi":{"type":"TemplateLiteral","quasis":[{"type":"TemplateElement","tail":true,"value":{"cooked":null,"raw":"\\u"}}],"expressions":[]}}`},
+	}
+
+	for i, tt := range tests {
+		prog, err := ParseScript("(" + tt.input + ")")
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		expr := prog.Body[0].(*ast.ExpressionStatement).Expression
+		if got := compactJSON(t, expr); got != tt.expected {
+			t.Fatalf("tests[%d] - literal wrong.\nexpected=%s\ngot=     %s", i, tt.expected, got)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	input := `class A extends B {
+  static x = 1;
+  #y;
+  constructor() { super(); }
+  get z() { return this.#y }
+  static { init() }
+  async *gen() {}
+  static() {}
+  has(o) { return #y in o }
+}`
+
+	prog, err := ParseScript(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	class := prog.Body[0].(*ast.ClassDeclaration)
+
+	expected := []string{
+		"PropertyDefinition static x",
+		"PropertyDefinition #y",
+		"MethodDefinition constructor constructor",
+		"MethodDefinition get z",
+		"StaticBlock",
+		"MethodDefinition method gen async generator",
+		"MethodDefinition method static",
+		"MethodDefinition method has",
+	}
+	if len(class.Body.Body) != len(expected) {
+		t.Fatalf("wrong number of class elements. expected=%d, got=%d", len(expected), len(class.Body.Body))
+	}
+	for i, elem := range class.Body.Body {
+		if got := describeClassElement(elem); got != expected[i] {
+			t.Fatalf("elements[%d] - wrong. expected=%q, got=%q", i, expected[i], got)
+		}
+	}
+}
+
+func TestLocations(t *testing.T) {
+	input := "let a = 1;\nfoo(a,\n  b)"
+
+	prog, err := ParseScript(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	tests := []struct {
+		node       ast.Node
+		start, end int
+		loc        token.SourceLocation
+	}{
+		{prog, 0, 22, makeLoc(0, 0, 2, 4)},
+		{prog.Body[0], 0, 10, makeLoc(0, 0, 0, 10)},
+		{prog.Body[0].(*ast.VariableDeclaration).Declarations[0], 4, 9, makeLoc(0, 4, 0, 9)},
+		{prog.Body[1], 11, 22, makeLoc(1, 0, 2, 4)},
+		{prog.Body[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], 20, 21, makeLoc(2, 2, 2, 3)},
+	}
+
+	for i, tt := range tests {
+		b := tt.node.Info()
+		if b.Start != tt.start || b.End != tt.end {
+			t.Fatalf("tests[%d] - %s offsets wrong. expected=%d-%d, got=%d-%d", i, b.Type, tt.start, tt.end, b.Start, b.End)
+		}
+		if b.Loc != tt.loc {
+			t.Fatalf("tests[%d] - %s location wrong. expected=%+v, got=%+v", i, b.Type, tt.loc, b.Loc)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		module   bool
+		expected string
+	}{
+		{"a +", false, "SyntaxError: Unexpected end of input (0:3)"},
+		{"var 1", false, "SyntaxError: Unexpected token (0:4)"},
+		{"a\nb c", false, "SyntaxError: Unexpected token (1:2)"},
+		{"1 = 2", false, "SyntaxError: Assigning to rvalue (0:0)"},
+		{"a++ = b", false, "SyntaxError: Assigning to rvalue (0:0)"},
+		{"a?.b = c", false, "SyntaxError: Assigning to rvalue (0:0)"},
+		{"({a: 1} = b)", false, "SyntaxError: Assigning to rvalue (0:5)"},
+		{"-a ** 2", false, "SyntaxError: Unary operator used immediately before exponentiation expression. Parenthesis must be used to disambiguate operator precedence (0:3)"},
+		{"return 1", false, "SyntaxError: 'return' outside of function (0:0)"},
+		{"`\\u`", false, "SyntaxError: Bad escape sequence in untagged template literal (0:1)"},
+		{`"\u{110000}"`, false, "SyntaxError: Bad character escape sequence (0:1)"},
+		{"function f() { await x }", true, "SyntaxError: Unexpected token (0:21)"},
+		{"'", false, "SyntaxError: Unterminated string constant (0:0)"},
+		{"a ?? b || c", false, "SyntaxError: Logical expressions and coalesce expressions cannot be mixed. Wrap either by parentheses (0:2)"},
+		{"a || b ?? c", false, "SyntaxError: Logical expressions and coalesce expressions cannot be mixed. Wrap either by parentheses (0:7)"},
+		{"a ?? b && c", false, "SyntaxError: Logical expressions and coalesce expressions cannot be mixed. Wrap either by parentheses (0:2)"},
+		{"a && b ?? c", false, "SyntaxError: Logical expressions and coalesce expressions cannot be mixed. Wrap either by parentheses (0:7)"},
+		{"({a = 1})", false, "SyntaxError: Shorthand property assignments are valid only in destructuring patterns (0:4)"},
+		{"f({a = 1})", false, "SyntaxError: Shorthand property assignments are valid only in destructuring patterns (0:5)"},
+		{"({a: {b = 1}}.c = d)", false, "SyntaxError: Shorthand property assignments are valid only in destructuring patterns (0:8)"},
+		{"({a = {b = 1}} = c)", false, "SyntaxError: Shorthand property assignments are valid only in destructuring patterns (0:9)"},
+		{"continue;", false, "SyntaxError: Unsyntactic continue (0:0)"},
+		{"break", false, "SyntaxError: Unsyntactic break (0:0)"},
+		{"while (a) { break foo }", false, "SyntaxError: Unsyntactic break (0:12)"},
+		{"a: { continue a }", false, "SyntaxError: Unsyntactic continue (0:5)"},
+		{"switch (a) { case 1: continue }", false, "SyntaxError: Unsyntactic continue (0:21)"},
+		{"for (;;) { function f() { break } }", false, "SyntaxError: Unsyntactic break (0:26)"},
+		{"a: { a: ; }", false, "SyntaxError: Label 'a' is already declared (0:5)"},
+		{"(a, a) => 1", false, "SyntaxError: Argument name clash (0:4)"},
+		{"function f(a, [a]) {}", false, "SyntaxError: Argument name clash (0:15)"},
+		{"function f(a, a) { 'use strict' }", false, "SyntaxError: Argument name clash (0:14)"},
+		{"({m(a, a) {}})", false, "SyntaxError: Argument name clash (0:7)"},
+		{"var a; let a", false, "SyntaxError: Identifier 'a' has already been declared (0:11)"},
+		{"let a; { var a }", false, "SyntaxError: Identifier 'a' has already been declared (0:13)"},
+		{"function f(a) { let a }", false, "SyntaxError: Identifier 'a' has already been declared (0:20)"},
+		{"try {} catch (e) { let e }", false, "SyntaxError: Identifier 'e' has already been declared (0:23)"},
+		{"try {} catch ([e]) { var e }", false, "SyntaxError: Identifier 'e' has already been declared (0:25)"},
+		{"switch (a) { case 1: let b; case 2: let b }", false, "SyntaxError: Identifier 'b' has already been declared (0:40)"},
+		// Module code is strict mode code
+		{"var yield", true, "SyntaxError: The keyword 'yield' is reserved (0:4)"},
+		{"x = 09", true, "SyntaxError: Invalid number (0:4)"},
+		{"if (x) function f(){}", true, "SyntaxError: Unexpected token (0:7)"},
+		{"if (x) {} else function f(){}", true, "SyntaxError: Unexpected token (0:15)"},
+		{"l: function f(){}", true, "SyntaxError: Unexpected token (0:3)"},
+		{"with (a) {}", true, "SyntaxError: 'with' in strict mode (0:0)"},
+		{"delete x", true, "SyntaxError: Deleting local variable in strict mode (0:7)"},
+		{"x = { yield }", true, "SyntaxError: The keyword 'yield' is reserved (0:6)"},
+		{"class A { m() { var yield } }", false, "SyntaxError: The keyword 'yield' is reserved (0:20)"},
+		{"function f() { 'use strict'; with (a) {} }", false, "SyntaxError: 'with' in strict mode (0:29)"},
+		{"function f(a, a) {}", true, "SyntaxError: Argument name clash (0:14)"},
+		{"let [a, a] = b", true, "SyntaxError: Identifier 'a' has already been declared (0:8)"},
+		{"function f() {} function f() {}", true, "SyntaxError: Identifier 'f' has already been declared (0:25)"},
+		{"import a from 'x'; let a", true, "SyntaxError: Identifier 'a' has already been declared (0:23)"},
+	}
+
+	for i, tt := range tests {
+		parse := ParseScript
+		if tt.module {
+			parse = ParseModule
+		}
+		_, err := parse(tt.input)
+		if err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+		if _, ok := err.(*lexer.SyntaxError); !ok {
+			t.Fatalf("tests[%d] - error is not *lexer.SyntaxError. got=%T", i, err)
+		}
+		if err.Error() != tt.expected {
+			t.Fatalf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestSloppyMode(t *testing.T) {
+	// The early errors of strict mode code do not apply to scripts
+	input := "var yield; if (x) function f(){} else function g(){}; l: function h(){}; with (a) {}; delete x; 010"
+	if _, err := ParseScript(input); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if _, err := ParseModule("a.yield; x = { with: 1, yield: 2 }; delete a.b"); err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+}
+
+func TestRedeclarations(t *testing.T) {
+	// Names may be declared again where the bindings do not conflict
+	tests := []string{
+		"function f(a, a) {}",
+		"var a; var a",
+		"var f; function f() {} function f() {}",
+		"{ function f() {} function f() {} }",
+		"function f(a) { var a }",
+		"try {} catch (e) { var e }",
+		"for (let i;;) { let i } let i",
+		"{ let a } { let a }",
+		"let a; (function() { let a })",
+		"class A { static { let a } static { let a } }",
+	}
+
+	for i, tt := range tests {
+		if _, err := ParseScript(tt); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+	}
+}
+
+func TestAwait(t *testing.T) {
+	// await is an identifier in scripts outside async functions
+	prog, err := ParseScript("await(x)")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if typ := prog.Body[0].(*ast.ExpressionStatement).Expression.Info().Type; typ != "CallExpression" {
+		t.Fatalf("expression type wrong. expected=%q, got=%q", "CallExpression", typ)
+	}
+
+	// and a top-level await expression in modules
+	prog, err = ParseModule("await x")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	if typ := prog.Body[0].(*ast.ExpressionStatement).Expression.Info().Type; typ != "AwaitExpression" {
+		t.Fatalf("expression type wrong. expected=%q, got=%q", "AwaitExpression", typ)
+	}
+}
+
+func makeLoc(startLine, startColumn, endLine, endColumn int) token.SourceLocation {
+	return token.SourceLocation{
+		Start: token.Position{Line: startLine, Column: startColumn},
+		End:   token.Position{Line: endLine, Column: endColumn},
+	}
+}
+
+// compactJSON renders n as JSON without locations, keeping the field order
+// of the node types.
+func compactJSON(t *testing.T, n ast.Node) string {
+	t.Helper()
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("marshal failed: %q", err.Error())
+	}
+	var sb strings.Builder
+	writeJSON(&sb, b)
+	return sb.String()
+}
+
+// writeJSON copies the JSON object b to sb, dropping start, end, and loc
+// members.
+func writeJSON(sb *strings.Builder, b []byte) {
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	writeValue(sb, dec)
+}
+
+func writeValue(sb *strings.Builder, dec *json.Decoder) {
+	tok, _ := dec.Token()
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			sb.WriteByte('{')
+			first := true
+			for dec.More() {
+				key, _ := dec.Token()
+				if key == "start" || key == "end" || key == "loc" {
+					var skip json.RawMessage
+					dec.Decode(&skip)
+					continue
+				}
+				if !first {
+					sb.WriteByte(',')
+				}
+				first = false
+				k, _ := json.Marshal(key)
+				sb.Write(k)
+				sb.WriteByte(':')
+				writeValue(sb, dec)
+			}
+			dec.Token()
+			sb.WriteByte('}')
+		case '[':
+			sb.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				writeValue(sb, dec)
+			}
+			dec.Token()
+			sb.WriteByte(']')
+		}
+	default:
+		var buf strings.Builder
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(tok)
+		sb.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+	}
+}
+
+func describeClassElement(n ast.Node) string {
+	var parts []string
+	key := func(k ast.Expression) string {
+		switch k := k.(type) {
+		case *ast.Identifier:
+			return k.Name
+		case *ast.PrivateIdentifier:
+			return "#" + k.Name
+		}
+		return "?"
+	}
+	switch n := n.(type) {
+	case *ast.PropertyDefinition:
+		parts = append(parts, n.Type)
+		if n.Static {
+			parts = append(parts, "static")
+		}
+		parts = append(parts, key(n.Key))
+	case *ast.MethodDefinition:
+		parts = append(parts, n.Type, n.Kind)
+		if n.Static {
+			parts = append(parts, "static")
+		}
+		parts = append(parts, key(n.Key))
+		if n.Value.Async {
+			parts = append(parts, "async")
+		}
+		if n.Value.Generator {
+			parts = append(parts, "generator")
+		}
+	default:
+		parts = append(parts, n.Info().Type)
+	}
+	return strings.Join(parts, " ")
+}
+
+// render prints an expression with each operation parenthesized, to show
+// how it was grouped.
+func render(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		return n.Name
+	case *ast.Literal:
+		return n.Raw
+	case *ast.BinaryExpression:
+		return fmt.Sprintf("(%s %s %s)", render(n.Left), n.Operator, render(n.Right))
+	case *ast.LogicalExpression:
+		return fmt.Sprintf("(%s %s %s)", render(n.Left), n.Operator, render(n.Right))
+	case *ast.AssignmentExpression:
+		return fmt.Sprintf("(%s %s %s)", render(n.Left), n.Operator, render(n.Right))
+	case *ast.UnaryExpression:
+		if len(n.Operator) > 1 {
+			return fmt.Sprintf("(%s %s)", n.Operator, render(n.Argument))
+		}
+		return fmt.Sprintf("(%s%s)", n.Operator, render(n.Argument))
+	case *ast.UpdateExpression:
+		if n.Prefix {
+			return fmt.Sprintf("(%s%s)", n.Operator, render(n.Argument))
+		}
+		return fmt.Sprintf("(%s%s)", render(n.Argument), n.Operator)
+	case *ast.ConditionalExpression:
+		return fmt.Sprintf("(%s ? %s : %s)", render(n.Test), render(n.Consequent), render(n.Alternate))
+	case *ast.SequenceExpression:
+		var exprs []string
+		for _, e := range n.Expressions {
+			exprs = append(exprs, render(e))
+		}
+		return strings.Join(exprs, ", ")
+	case *ast.ChainExpression:
+		return render(n.Expression)
+	case *ast.MemberExpression:
+		dot := "."
+		if n.Optional {
+			dot = "?."
+		}
+		if n.Computed {
+			if !n.Optional {
+				dot = ""
+			}
+			return fmt.Sprintf("%s%s[%s]", render(n.Object), dot, render(n.Property))
+		}
+		return render(n.Object) + dot + render(n.Property)
+	case *ast.CallExpression:
+		optional := ""
+		if n.Optional {
+			optional = "?."
+		}
+		return fmt.Sprintf("%s%s(%s)", render(n.Callee), optional, renderList(n.Arguments))
+	case *ast.NewExpression:
+		return fmt.Sprintf("new %s(%s)", render(n.Callee), renderList(n.Arguments))
+	case *ast.ArrowFunctionExpression:
+		var params []string
+		for _, p := range n.Params {
+			params = append(params, render(p))
+		}
+		async := ""
+		if n.Async {
+			async = "async "
+		}
+		return fmt.Sprintf("%s(%s) => %s", async, strings.Join(params, ", "), render(n.Body))
+	}
+	return fmt.Sprintf("<%T>", n)
+}
+
+func renderList(exprs []ast.Expression) string {
+	var out []string
+	for _, e := range exprs {
+		out = append(out, render(e))
+	}
+	return strings.Join(out, ", ")
+}
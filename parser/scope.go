@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"github.com/morinokami/js-lexer/ast"
+)
+
+// scope holds the names declared in a block, a function, or the program, to
+// reject redeclarations.
+type scope struct {
+	// Function is true for the scope of a function or the program, which
+	// var declarations belong to.
+	function bool
+	lexical  map[string]bool
+	vars     map[string]bool
+	// Functions declared with function declarations
+	functions map[string]bool
+	// Name of the parameter of a catch clause, which var declarations in
+	// the clause may redeclare if it is an identifier
+	catchParam string
+}
+
+// Kinds of bindings
+const (
+	bindVar = iota
+	bindLexical
+	bindFunction
+	// Parameter of a catch clause that is an identifier
+	bindCatchParam
+)
+
+func (p *Parser) enterScope(function bool) {
+	p.scopes = append(p.scopes, &scope{
+		function:  function,
+		lexical:   map[string]bool{},
+		vars:      map[string]bool{},
+		functions: map[string]bool{},
+	})
+}
+
+func (p *Parser) exitScope() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+func (p *Parser) currentScope() *scope {
+	return p.scopes[len(p.scopes)-1]
+}
+
+// functionsAsVar reports whether function declarations in s are var
+// bindings, as they are at the top level of functions and scripts. They
+// are lexical bindings elsewhere.
+func (p *Parser) functionsAsVar(s *scope) bool {
+	return s.function && (s != p.scopes[0] || !p.module)
+}
+
+// functionBinding returns the kind of binding a function declaration
+// creates in the current scope. In sloppy mode code, functions declared
+// in blocks may be redeclared like var bindings.
+func (p *Parser) functionBinding(async, generator bool) int {
+	switch {
+	case !p.strict() && !async && !generator:
+		return bindFunction
+	case p.functionsAsVar(p.currentScope()):
+		return bindVar
+	}
+	return bindLexical
+}
+
+// declare declares the names bound by pattern, raising an error if one is
+// already declared in a way that conflicts.
+func (p *Parser) declare(pattern ast.Node, binding int) {
+	for _, id := range boundNames(pattern, nil) {
+		p.declareName(id, binding)
+	}
+}
+
+func (p *Parser) declareName(id *ast.Identifier, binding int) {
+	name := id.Name
+	s := p.currentScope()
+	redeclared := false
+	switch binding {
+	case bindLexical:
+		redeclared = s.lexical[name] || s.functions[name] || s.vars[name]
+		s.lexical[name] = true
+	case bindCatchParam:
+		s.lexical[name] = true
+		s.catchParam = name
+	case bindFunction:
+		redeclared = s.lexical[name] || !p.functionsAsVar(s) && s.vars[name]
+		s.functions[name] = true
+	default:
+		// A var binding belongs to the nearest function scope, but must not
+		// conflict with the lexical bindings in the scopes on the way.
+		for i := len(p.scopes) - 1; i >= 0; i-- {
+			s := p.scopes[i]
+			if s.lexical[name] && s.catchParam != name || !p.functionsAsVar(s) && s.functions[name] {
+				redeclared = true
+				break
+			}
+			s.vars[name] = true
+			if s.function {
+				break
+			}
+		}
+	}
+	if redeclared {
+		p.raiseAt(id, "Identifier '%s' has already been declared", name)
+	}
+}
+
+// checkParams rejects parameters with the same name, which are allowed only
+// in simple parameter lists of sloppy mode functions other than arrow
+// functions and methods.
+func (p *Parser) checkParams(params []ast.Pattern) {
+	seen := map[string]bool{}
+	for _, param := range params {
+		for _, id := range boundNames(param, nil) {
+			if seen[id.Name] {
+				p.raiseAt(id, "Argument name clash")
+			}
+			seen[id.Name] = true
+		}
+	}
+}
+
+// isSimpleParamList reports whether params are all identifiers, without
+// defaults, patterns, or a rest parameter.
+func isSimpleParamList(params []ast.Pattern) bool {
+	for _, param := range params {
+		if _, ok := param.(*ast.Identifier); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasUseStrict reports whether body starts with a "use strict" directive.
+func hasUseStrict(body *ast.BlockStatement) bool {
+	for _, stmt := range body.Body {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok || es.Directive == "" {
+			break
+		}
+		if es.Directive == "use strict" {
+			return true
+		}
+	}
+	return false
+}
+
+// boundNames appends the identifiers bound by pattern to names.
+func boundNames(pattern ast.Node, names []*ast.Identifier) []*ast.Identifier {
+	switch n := pattern.(type) {
+	case *ast.Identifier:
+		names = append(names, n)
+	case *ast.ObjectPattern:
+		for _, prop := range n.Properties {
+			switch prop := prop.(type) {
+			case *ast.Property:
+				names = boundNames(prop.Value, names)
+			case *ast.RestElement:
+				names = boundNames(prop.Argument, names)
+			}
+		}
+	case *ast.ArrayPattern:
+		for _, elem := range n.Elements {
+			if elem != nil {
+				names = boundNames(elem, names)
+			}
+		}
+	case *ast.AssignmentPattern:
+		names = boundNames(n.Left, names)
+	case *ast.RestElement:
+		names = boundNames(n.Argument, names)
+	}
+	return names
+}
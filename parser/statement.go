@@ -0,0 +1,829 @@
+package parser
+
+import (
+	"github.com/morinokami/js-lexer/ast"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// parseStatementList parses statements up to the closing token, which is
+// not consumed. If directives is true, the leading string literal
+// statements are marked as directives.
+func (p *Parser) parseStatementList(directives bool, closing string) []ast.Statement {
+	body := []ast.Statement{}
+	for !p.is(closing) {
+		if p.is(token.EOF) {
+			p.unexpected()
+		}
+		pending := len(p.coverInits)
+		var stmt ast.Statement
+		if directives && p.is(token.String) {
+			stmt = p.parseStatement()
+			if es, ok := stmt.(*ast.ExpressionStatement); ok && isDirective(es) {
+				raw := es.Expression.(*ast.Literal).Raw
+				es.Directive = raw[1 : len(raw)-1]
+			} else {
+				directives = false
+			}
+		} else {
+			directives = false
+			stmt = p.parseStatement()
+		}
+		p.checkCoverInits(pending)
+		body = append(body, stmt)
+	}
+	return body
+}
+
+// checkCoverInits rejects the cover initializers left after the first n,
+// which the statement just parsed has in objects that are not patterns.
+func (p *Parser) checkCoverInits(n int) {
+	if len(p.coverInits) > n {
+		p.raise(p.coverInits[n].assign, "Shorthand property assignments are valid only in destructuring patterns")
+	}
+}
+
+// isDirective reports whether es consists of a string literal alone, not
+// part of a larger expression.
+func isDirective(es *ast.ExpressionStatement) bool {
+	lit, ok := es.Expression.(*ast.Literal)
+	if !ok {
+		return false
+	}
+	_, isString := lit.Value.(string)
+	return isString && len(lit.Raw) >= 2 && (lit.Raw[0] == '"' || lit.Raw[0] == '\'')
+}
+
+func (p *Parser) parseStatement() ast.Statement {
+	m := p.mark()
+
+	switch p.cur.Type.Label {
+	case token.LBrace:
+		return p.parseBlock(true)
+	case token.Semicolon:
+		p.next()
+		return &ast.EmptyStatement{Base: p.finish("EmptyStatement", m)}
+	case token.Var, token.Const:
+		decl := p.parseVar(p.cur.Literal, false)
+		p.semicolon()
+		decl.Base = p.finish("VariableDeclaration", m)
+		return decl
+	case token.Function:
+		return p.parseFunctionDeclaration(m, false, false)
+	case token.Class:
+		return p.parseClassDeclaration(m, false)
+	case token.If:
+		return p.parseIf()
+	case token.For:
+		return p.parseFor()
+	case token.While:
+		p.next()
+		test := p.parseParenExpression()
+		body := p.parseLoopBody()
+		return &ast.WhileStatement{Base: p.finish("WhileStatement", m), Test: test, Body: body}
+	case token.Do:
+		p.next()
+		body := p.parseLoopBody()
+		p.expect(token.While)
+		test := p.parseParenExpression()
+		// A semicolon is always inserted after a do-while statement.
+		p.eat(token.Semicolon)
+		return &ast.DoWhileStatement{Base: p.finish("DoWhileStatement", m), Body: body, Test: test}
+	case token.Return:
+		if !p.inFunction {
+			p.raise(p.cur, "'return' outside of function")
+		}
+		p.next()
+		var arg ast.Expression
+		if !p.is(token.Semicolon) && !p.canInsertSemicolon() {
+			arg = p.parseExpression(false)
+		}
+		p.semicolon()
+		return &ast.ReturnStatement{Base: p.finish("ReturnStatement", m), Argument: arg}
+	case token.Break, token.Continue:
+		isBreak := p.is(token.Break)
+		p.next()
+		var label *ast.Identifier
+		if p.is(token.Identifier) && !p.hasNewlineBefore() {
+			label = p.parseIdent(false)
+		}
+		p.semicolon()
+		var stmt ast.Statement
+		if isBreak {
+			stmt = &ast.BreakStatement{Base: p.finish("BreakStatement", m), Label: label}
+		} else {
+			stmt = &ast.ContinueStatement{Base: p.finish("ContinueStatement", m), Label: label}
+		}
+		p.checkJump(stmt, label, isBreak)
+		return stmt
+	case token.Throw:
+		p.next()
+		if p.hasNewlineBefore() {
+			p.raise(p.cur, "Illegal newline after throw")
+		}
+		arg := p.parseExpression(false)
+		p.semicolon()
+		return &ast.ThrowStatement{Base: p.finish("ThrowStatement", m), Argument: arg}
+	case token.Try:
+		return p.parseTry()
+	case token.Switch:
+		return p.parseSwitch()
+	case token.Debugger:
+		p.next()
+		p.semicolon()
+		return &ast.DebuggerStatement{Base: p.finish("DebuggerStatement", m)}
+	case token.With:
+		if p.strict() {
+			p.raise(p.cur, "'with' in strict mode")
+		}
+		p.next()
+		object := p.parseParenExpression()
+		body := p.parseStatement()
+		return &ast.WithStatement{Base: p.finish("WithStatement", m), Object: object, Body: body}
+	case token.Import:
+		if next := p.peek(); next.Type.Label != token.LParen && next.Type.Label != token.Dot {
+			p.checkModule()
+			return p.parseImport()
+		}
+	case token.Export:
+		p.checkModule()
+		return p.parseExport()
+	case token.Identifier:
+		if p.isWord("let") && p.isLetDeclaration() {
+			decl := p.parseVar("let", false)
+			p.semicolon()
+			decl.Base = p.finish("VariableDeclaration", m)
+			return decl
+		}
+		if p.isAsyncFunction() {
+			p.next()
+			return p.parseFunctionDeclaration(m, true, false)
+		}
+	}
+
+	expr := p.parseExpression(false)
+	if id, ok := expr.(*ast.Identifier); ok && p.eat(token.Colon) {
+		return p.parseLabeledStatement(m, id)
+	}
+	p.semicolon()
+	return &ast.ExpressionStatement{Base: p.finish("ExpressionStatement", m), Expression: expr}
+}
+
+func (p *Parser) parseLabeledStatement(m marker, id *ast.Identifier) ast.Statement {
+	for _, l := range p.labels {
+		if l.name == id.Name {
+			p.raiseAt(id, "Label '%s' is already declared", id.Name)
+		}
+	}
+	kind := ""
+	switch p.cur.Type.Label {
+	case token.For, token.While, token.Do:
+		kind = "loop"
+	case token.Switch:
+		kind = "switch"
+	}
+	// The labels right before this one label the same statement.
+	for i := len(p.labels) - 1; i >= 0 && p.labels[i].name != "" && p.labels[i].body == m.start; i-- {
+		p.labels[i].kind = kind
+		p.labels[i].body = p.cur.Start
+	}
+	p.labels = append(p.labels, label{name: id.Name, kind: kind, body: p.cur.Start})
+	body := p.parseSubStatement()
+	p.labels = p.labels[:len(p.labels)-1]
+	return &ast.LabeledStatement{Base: p.finish("LabeledStatement", m), Label: id, Body: body}
+}
+
+// checkJump checks that the break or continue statement stmt has a target:
+// the statement with the label, or an enclosing loop or, for break, switch
+// statement.
+func (p *Parser) checkJump(stmt ast.Statement, target *ast.Identifier, isBreak bool) {
+	for _, l := range p.labels {
+		if target == nil && (l.kind == "loop" || l.kind == "switch" && isBreak) {
+			return
+		}
+		if target != nil && l.name == target.Name && (isBreak || l.kind == "loop") {
+			return
+		}
+	}
+	keyword := "continue"
+	if isBreak {
+		keyword = "break"
+	}
+	p.raiseAt(stmt, "Unsyntactic %s", keyword)
+}
+
+// parseLoopBody parses the body of a loop, which break and continue may
+// target.
+func (p *Parser) parseLoopBody() ast.Statement {
+	p.labels = append(p.labels, label{kind: "loop"})
+	body := p.parseStatement()
+	p.labels = p.labels[:len(p.labels)-1]
+	return body
+}
+
+// parseSubStatement parses the body of an if statement or a labeled
+// statement, which may be a function declaration in sloppy mode code only.
+func (p *Parser) parseSubStatement() ast.Statement {
+	if p.is(token.Function) && p.strict() {
+		p.unexpected()
+	}
+	return p.parseStatement()
+}
+
+func (p *Parser) checkModule() {
+	if !p.module {
+		p.raise(p.cur, "'import' and 'export' may appear only with 'sourceType: module'")
+	}
+}
+
+// isLetDeclaration reports whether the current let starts a lexical
+// declaration rather than being an identifier.
+func (p *Parser) isLetDeclaration() bool {
+	next := p.peek()
+	switch next.Type.Label {
+	case token.LBracket, token.LBrace, token.Identifier, token.Yield, token.Await:
+		return true
+	}
+	return false
+}
+
+// isAsyncFunction reports whether the current token is async starting an
+// async function.
+func (p *Parser) isAsyncFunction() bool {
+	if !p.isWord("async") {
+		return false
+	}
+	next := p.peek()
+	return next.Type.Label == token.Function && next.Loc.Start.Line == p.cur.Loc.End.Line
+}
+
+// parseBlock parses a block statement. If newScope is false, the block
+// shares the current scope, as the body of a catch clause does.
+func (p *Parser) parseBlock(newScope bool) *ast.BlockStatement {
+	m := p.mark()
+	p.expect(token.LBrace)
+	if newScope {
+		p.enterScope(false)
+	}
+	body := p.parseStatementList(false, token.RBrace)
+	if newScope {
+		p.exitScope()
+	}
+	p.next()
+	return &ast.BlockStatement{Base: p.finish("BlockStatement", m), Body: body}
+}
+
+func (p *Parser) parseParenExpression() ast.Expression {
+	p.expect(token.LParen)
+	expr := p.parseExpression(false)
+	p.expect(token.RParen)
+	return expr
+}
+
+// parseVar parses the declarators of a variable declaration of the given
+// kind, starting at the keyword. The caller sets the base.
+func (p *Parser) parseVar(kind string, noIn bool) *ast.VariableDeclaration {
+	p.next()
+	decl := &ast.VariableDeclaration{Kind: kind}
+	for {
+		m := p.mark()
+		id := p.parseBindingAtom()
+		if kind == "var" {
+			p.declare(id, bindVar)
+		} else {
+			p.declare(id, bindLexical)
+		}
+		var init ast.Expression
+		if p.eat(token.Assignment) {
+			init = p.parseMaybeAssign(noIn)
+		}
+		decl.Declarations = append(decl.Declarations,
+			&ast.VariableDeclarator{Base: p.finish("VariableDeclarator", m), ID: id, Init: init})
+		if !p.eat(token.Comma) {
+			break
+		}
+	}
+	return decl
+}
+
+func (p *Parser) parseIf() ast.Statement {
+	m := p.mark()
+	p.next()
+	test := p.parseParenExpression()
+	consequent := p.parseSubStatement()
+	var alternate ast.Statement
+	if p.eat(token.Else) {
+		alternate = p.parseSubStatement()
+	}
+	return &ast.IfStatement{Base: p.finish("IfStatement", m), Test: test, Consequent: consequent, Alternate: alternate}
+}
+
+func (p *Parser) parseFor() ast.Statement {
+	m := p.mark()
+	p.next()
+	// The declarations in the head are scoped to the statement.
+	p.enterScope(false)
+	defer p.exitScope()
+	await := false
+	if p.is(token.Await) || p.isWord("await") && p.inAsync {
+		if !p.canAwait() {
+			p.unexpected()
+		}
+		await = true
+		p.next()
+	}
+	p.expect(token.LParen)
+
+	var init ast.Node
+	if p.is(token.Semicolon) {
+		// No init
+	} else if p.is(token.Var) || p.is(token.Const) || p.isWord("let") && p.isLetDeclaration() {
+		im := p.mark()
+		decl := p.parseVar(p.cur.Literal, true)
+		decl.Base = p.finish("VariableDeclaration", im)
+		if (p.is(token.In) || p.isWord("of")) && len(decl.Declarations) == 1 {
+			return p.parseForInOf(m, decl, await)
+		}
+		init = decl
+	} else {
+		expr := p.parseExpression(true)
+		if p.is(token.In) || p.isWord("of") {
+			return p.parseForInOf(m, p.toAssignable(expr), await)
+		}
+		init = expr
+	}
+	if await {
+		p.unexpected()
+	}
+
+	p.expect(token.Semicolon)
+	var test, update ast.Expression
+	if !p.is(token.Semicolon) {
+		test = p.parseExpression(false)
+	}
+	p.expect(token.Semicolon)
+	if !p.is(token.RParen) {
+		update = p.parseExpression(false)
+	}
+	p.expect(token.RParen)
+	body := p.parseLoopBody()
+	return &ast.ForStatement{Base: p.finish("ForStatement", m), Init: init, Test: test, Update: update, Body: body}
+}
+
+func (p *Parser) parseForInOf(m marker, left ast.Node, await bool) ast.Statement {
+	isIn := p.is(token.In)
+	if isIn && await {
+		p.unexpected()
+	}
+	p.next()
+	var right ast.Expression
+	if isIn {
+		right = p.parseExpression(false)
+	} else {
+		right = p.parseMaybeAssign(false)
+	}
+	p.expect(token.RParen)
+	body := p.parseLoopBody()
+	if isIn {
+		return &ast.ForInStatement{Base: p.finish("ForInStatement", m), Left: left, Right: right, Body: body}
+	}
+	return &ast.ForOfStatement{Base: p.finish("ForOfStatement", m), Left: left, Right: right, Body: body, Await: await}
+}
+
+func (p *Parser) parseTry() ast.Statement {
+	m := p.mark()
+	p.next()
+	stmt := &ast.TryStatement{Block: p.parseBlock(true)}
+	if p.is(token.Catch) {
+		cm := p.mark()
+		p.next()
+		p.enterScope(false)
+		var param ast.Pattern
+		if p.eat(token.LParen) {
+			param = p.parseBindingAtom()
+			if _, ok := param.(*ast.Identifier); ok {
+				p.declare(param, bindCatchParam)
+			} else {
+				p.declare(param, bindLexical)
+			}
+			p.expect(token.RParen)
+		}
+		body := p.parseBlock(false)
+		p.exitScope()
+		stmt.Handler = &ast.CatchClause{Base: p.finish("CatchClause", cm), Param: param, Body: body}
+	}
+	if p.eat(token.Finally) {
+		stmt.Finalizer = p.parseBlock(true)
+	}
+	if stmt.Handler == nil && stmt.Finalizer == nil {
+		p.raise(p.cur, "Missing catch or finally clause")
+	}
+	stmt.Base = p.finish("TryStatement", m)
+	return stmt
+}
+
+func (p *Parser) parseSwitch() ast.Statement {
+	m := p.mark()
+	p.next()
+	stmt := &ast.SwitchStatement{Discriminant: p.parseParenExpression(), Cases: []*ast.SwitchCase{}}
+	p.expect(token.LBrace)
+	p.enterScope(false)
+	p.labels = append(p.labels, label{kind: "switch"})
+	sawDefault := false
+	for !p.eat(token.RBrace) {
+		cm := p.mark()
+		var test ast.Expression
+		if p.eat(token.Case) {
+			test = p.parseExpression(false)
+		} else if p.is(token.Default) {
+			if sawDefault {
+				p.raise(p.cur, "Multiple default clauses")
+			}
+			sawDefault = true
+			p.next()
+		} else {
+			p.unexpected()
+		}
+		p.expect(token.Colon)
+		consequent := []ast.Statement{}
+		for !p.is(token.Case) && !p.is(token.Default) && !p.is(token.RBrace) {
+			if p.is(token.EOF) {
+				p.unexpected()
+			}
+			consequent = append(consequent, p.parseStatement())
+		}
+		stmt.Cases = append(stmt.Cases,
+			&ast.SwitchCase{Base: p.finish("SwitchCase", cm), Test: test, Consequent: consequent})
+	}
+	p.labels = p.labels[:len(p.labels)-1]
+	p.exitScope()
+	stmt.Base = p.finish("SwitchStatement", m)
+	return stmt
+}
+
+// parseFunctionDeclaration parses a function declaration starting at the
+// function keyword. If optionalID is true, as in export default, the name
+// may be omitted.
+func (p *Parser) parseFunctionDeclaration(m marker, async, optionalID bool) *ast.FunctionDeclaration {
+	p.expect(token.Function)
+	generator := p.eat(token.Star)
+	var id *ast.Identifier
+	if !optionalID || !p.is(token.LParen) {
+		id = p.parseBindingIdent()
+		p.declare(id, p.functionBinding(async, generator))
+	}
+	fn := p.parseFunctionRest(id, async, generator, false)
+	return &ast.FunctionDeclaration{Base: p.finish("FunctionDeclaration", m), Function: fn}
+}
+
+// parseFunctionRest parses the parameters and body of a function, which is
+// a method of a class or an object literal if method is true.
+func (p *Parser) parseFunctionRest(id *ast.Identifier, async, generator, method bool) ast.Function {
+	saved := p.enterFunction(async, generator)
+	defer p.exitFunction(saved)
+
+	strict := p.strict()
+	params := p.parseParams()
+	for _, param := range params {
+		p.declare(param, bindVar)
+	}
+	body := p.parseFunctionBody()
+	if strict || method || !isSimpleParamList(params) || hasUseStrict(body) {
+		p.checkParams(params)
+	}
+	return ast.Function{ID: id, Params: params, Body: body, Async: async, Generator: generator}
+}
+
+func (p *Parser) parseParams() []ast.Pattern {
+	p.expect(token.LParen)
+	return p.parseBindingList(token.RParen, false)
+}
+
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	m := p.mark()
+	p.expect(token.LBrace)
+	body := p.parseStatementList(true, token.RBrace)
+	p.next()
+	return &ast.BlockStatement{Base: p.finish("BlockStatement", m), Body: body}
+}
+
+func (p *Parser) parseClassDeclaration(m marker, optionalID bool) *ast.ClassDeclaration {
+	p.expect(token.Class)
+	var id *ast.Identifier
+	if !optionalID || p.is(token.Identifier) {
+		id = p.parseBindingIdent()
+		p.declare(id, bindLexical)
+	}
+	class := p.parseClassRest(id)
+	return &ast.ClassDeclaration{Base: p.finish("ClassDeclaration", m), Class: class}
+}
+
+// parseClassRest parses the heritage and body of a class.
+func (p *Parser) parseClassRest(id *ast.Identifier) ast.Class {
+	var superClass ast.Expression
+	if p.eat(token.Extends) {
+		superClass = p.parseExprSubscripts()
+	}
+
+	m := p.mark()
+	p.expect(token.LBrace)
+	body := []ast.Node{}
+	for !p.eat(token.RBrace) {
+		if p.eat(token.Semicolon) {
+			continue
+		}
+		body = append(body, p.parseClassElement())
+	}
+	return ast.Class{ID: id, SuperClass: superClass, Body: &ast.ClassBody{Base: p.finish("ClassBody", m), Body: body}}
+}
+
+// isModifierEnd reports whether the current token ends a class element or
+// property right after a word like static, get, or async, which then is the
+// name of the element rather than a modifier.
+func (p *Parser) isModifierEnd() bool {
+	switch p.cur.Type.Label {
+	case token.LParen, token.Assignment, token.Semicolon, token.RBrace, token.Comma, token.Colon, token.EOF:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseClassElement() ast.Node {
+	m := p.mark()
+
+	static := false
+	if p.isWord("static") {
+		word := p.parseIdent(false)
+		if p.is(token.LBrace) {
+			// Static initialization block
+			saved := p.enterFunction(false, false)
+			p.next()
+			body := p.parseStatementList(false, token.RBrace)
+			p.next()
+			p.exitFunction(saved)
+			return &ast.StaticBlock{Base: p.finish("StaticBlock", m), Body: body}
+		}
+		if p.isModifierEnd() {
+			return p.parseClassMemberKey(m, word, false, false, false, false, "method")
+		}
+		static = true
+	}
+
+	return p.parseClassMemberWithModifiers(m, static)
+}
+
+func (p *Parser) parseClassMemberWithModifiers(m marker, static bool) ast.Node {
+	async, generator := false, false
+	kind := "method"
+
+	if p.isWord("async") {
+		word := p.parseIdent(false)
+		if p.isModifierEnd() || p.hasNewlineBefore() {
+			return p.parseClassMemberKey(m, word, false, static, false, false, kind)
+		}
+		async = true
+	}
+	if p.eat(token.Star) {
+		generator = true
+	}
+	if !async && !generator && (p.isWord("get") || p.isWord("set")) {
+		word := p.parseIdent(false)
+		if p.isModifierEnd() {
+			return p.parseClassMemberKey(m, word, false, static, false, false, kind)
+		}
+		kind = word.Name
+	}
+
+	key, computed := p.parsePropertyName(true)
+	return p.parseClassMemberKey(m, key, computed, static, async, generator, kind)
+}
+
+// parseClassMemberKey parses the rest of a class element after its key. A
+// word first taken for a modifier, like static or get, may be the key.
+func (p *Parser) parseClassMemberKey(m marker, key ast.Expression, computed, static, async, generator bool, kind string) ast.Node {
+	if p.is(token.LParen) {
+		if kind == "method" && !static && !computed && propertyName(key) == "constructor" {
+			kind = "constructor"
+		}
+		fm := p.mark()
+		fn := p.parseFunctionRest(nil, async, generator, true)
+		value := &ast.FunctionExpression{Base: p.finish("FunctionExpression", fm), Function: fn}
+		return &ast.MethodDefinition{
+			Base:     p.finish("MethodDefinition", m),
+			Key:      key,
+			Value:    value,
+			Kind:     kind,
+			Computed: computed,
+			Static:   static,
+		}
+	}
+
+	if async || generator || kind != "method" {
+		p.unexpected()
+	}
+
+	var value ast.Expression
+	if p.eat(token.Assignment) {
+		saved := p.enterFunction(false, false)
+		value = p.parseMaybeAssign(false)
+		p.exitFunction(saved)
+	}
+	p.semicolon()
+	return &ast.PropertyDefinition{
+		Base:     p.finish("PropertyDefinition", m),
+		Key:      key,
+		Value:    value,
+		Computed: computed,
+		Static:   static,
+	}
+}
+
+// propertyName returns the name of a non-computed property key.
+func propertyName(key ast.Expression) string {
+	switch key := key.(type) {
+	case *ast.Identifier:
+		return key.Name
+	case *ast.Literal:
+		if s, ok := key.Value.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Modules
+
+func (p *Parser) parseImport() ast.Statement {
+	m := p.mark()
+	p.next()
+
+	decl := &ast.ImportDeclaration{Specifiers: []ast.Node{}, Attributes: []*ast.ImportAttribute{}}
+	if !p.is(token.String) {
+		if p.is(token.Identifier) {
+			sm := p.mark()
+			local := p.parseBindingIdent()
+			p.declare(local, bindLexical)
+			decl.Specifiers = append(decl.Specifiers,
+				&ast.ImportDefaultSpecifier{Base: p.finish("ImportDefaultSpecifier", sm), Local: local})
+			if !p.eat(token.Comma) {
+				goto from
+			}
+		}
+		if p.is(token.Star) {
+			sm := p.mark()
+			p.next()
+			p.expectWord("as")
+			local := p.parseBindingIdent()
+			p.declare(local, bindLexical)
+			decl.Specifiers = append(decl.Specifiers,
+				&ast.ImportNamespaceSpecifier{Base: p.finish("ImportNamespaceSpecifier", sm), Local: local})
+		} else {
+			p.expect(token.LBrace)
+			for !p.eat(token.RBrace) {
+				sm := p.mark()
+				imported := p.parseModuleExportName()
+				var local *ast.Identifier
+				if p.eatWord("as") {
+					local = p.parseBindingIdent()
+				} else {
+					id, ok := imported.(*ast.Identifier)
+					if !ok || token.IsKeyword(id.Name) {
+						p.unexpected()
+					}
+					local = id
+				}
+				p.declare(local, bindLexical)
+				decl.Specifiers = append(decl.Specifiers,
+					&ast.ImportSpecifier{Base: p.finish("ImportSpecifier", sm), Imported: imported, Local: local})
+				if !p.is(token.RBrace) {
+					p.expect(token.Comma)
+				}
+			}
+		}
+	from:
+		p.expectWord("from")
+	}
+	if !p.is(token.String) {
+		p.unexpected()
+	}
+	decl.Source = p.parseLiteral()
+	decl.Attributes = p.parseImportAttributes()
+	p.semicolon()
+	decl.Base = p.finish("ImportDeclaration", m)
+	return decl
+}
+
+// parseImportAttributes parses the with clause of an import or export, or
+// its deprecated assert form.
+func (p *Parser) parseImportAttributes() []*ast.ImportAttribute {
+	attrs := []*ast.ImportAttribute{}
+	if !p.is(token.With) && !(p.isWord("assert") && !p.hasNewlineBefore()) {
+		return attrs
+	}
+	p.next()
+	p.expect(token.LBrace)
+	for !p.eat(token.RBrace) {
+		m := p.mark()
+		var key ast.Expression
+		if p.is(token.String) {
+			key = p.parseLiteral()
+		} else {
+			key = p.parseIdent(true)
+		}
+		p.expect(token.Colon)
+		if !p.is(token.String) {
+			p.unexpected()
+		}
+		value := p.parseLiteral()
+		attrs = append(attrs, &ast.ImportAttribute{Base: p.finish("ImportAttribute", m), Key: key, Value: value})
+		if !p.is(token.RBrace) {
+			p.expect(token.Comma)
+		}
+	}
+	return attrs
+}
+
+// parseModuleExportName parses an identifier name or a string literal.
+func (p *Parser) parseModuleExportName() ast.Expression {
+	if p.is(token.String) {
+		return p.parseLiteral()
+	}
+	return p.parseIdent(true)
+}
+
+func (p *Parser) parseExport() ast.Statement {
+	m := p.mark()
+	p.next()
+
+	// export * from "mod"; export * as ns from "mod";
+	if p.eat(token.Star) {
+		decl := &ast.ExportAllDeclaration{}
+		if p.eatWord("as") {
+			decl.Exported = p.parseModuleExportName()
+		}
+		p.expectWord("from")
+		if !p.is(token.String) {
+			p.unexpected()
+		}
+		decl.Source = p.parseLiteral()
+		decl.Attributes = p.parseImportAttributes()
+		p.semicolon()
+		decl.Base = p.finish("ExportAllDeclaration", m)
+		return decl
+	}
+
+	// export default ...
+	if p.eat(token.Default) {
+		var declaration ast.Node
+		dm := p.mark()
+		if p.is(token.Function) {
+			declaration = p.parseFunctionDeclaration(dm, false, true)
+		} else if p.isAsyncFunction() {
+			p.next()
+			declaration = p.parseFunctionDeclaration(dm, true, true)
+		} else if p.is(token.Class) {
+			declaration = p.parseClassDeclaration(dm, true)
+		} else {
+			declaration = p.parseMaybeAssign(false)
+			p.semicolon()
+		}
+		return &ast.ExportDefaultDeclaration{Base: p.finish("ExportDefaultDeclaration", m), Declaration: declaration}
+	}
+
+	decl := &ast.ExportNamedDeclaration{Specifiers: []*ast.ExportSpecifier{}, Attributes: []*ast.ImportAttribute{}}
+	switch {
+	case p.is(token.Var), p.is(token.Const), p.isWord("let"),
+		p.is(token.Function), p.is(token.Class), p.isAsyncFunction():
+		decl.Declaration = p.parseStatement()
+	default:
+		p.expect(token.LBrace)
+		for !p.eat(token.RBrace) {
+			sm := p.mark()
+			local := p.parseModuleExportName()
+			exported := local
+			if p.eatWord("as") {
+				exported = p.parseModuleExportName()
+			}
+			decl.Specifiers = append(decl.Specifiers,
+				&ast.ExportSpecifier{Base: p.finish("ExportSpecifier", sm), Local: local, Exported: exported})
+			if !p.is(token.RBrace) {
+				p.expect(token.Comma)
+			}
+		}
+		if p.eatWord("from") {
+			if !p.is(token.String) {
+				p.unexpected()
+			}
+			decl.Source = p.parseLiteral()
+			decl.Attributes = p.parseImportAttributes()
+		} else {
+			for _, spec := range decl.Specifiers {
+				if _, ok := spec.Local.(*ast.Literal); ok {
+					p.raiseAt(spec.Local, "A string literal cannot be used as an exported binding without `from`")
+				}
+			}
+		}
+		p.semicolon()
+	}
+	decl.Base = p.finish("ExportNamedDeclaration", m)
+	return decl
+}
@@ -0,0 +1,311 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Original is the position in a source that a generated position maps to.
+// Line is zero-based, and Column counts UTF-16 code units from zero, as in
+// the map.
+type Original struct {
+	Source string
+	Line   int
+	Column int
+	// Name is the original name of the identifier at the position, if the
+	// map records one.
+	Name string
+}
+
+// Consumer looks up the original positions of positions in generated code
+// in its source map.
+type Consumer struct {
+	sources  []string
+	contents []*string
+	names    []string
+	// Segments of each line of the generated code, by column
+	lines [][]decodedSegment
+	// Sections of an index map, whose lines are nil
+	sections []consumerSection
+	code     lineTable
+}
+
+type decodedSegment struct {
+	column   int
+	source   int // -1 if the segment is not mapped
+	origLine int
+	origCol  int
+	name     int // -1 if the segment has no name
+}
+
+type consumerSection struct {
+	offset Offset
+	c      *Consumer
+}
+
+// rawMap is the JSON of a source map or an index map.
+type rawMap struct {
+	Version        int       `json:"version"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []*string `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Names          []string  `json:"names"`
+	Mappings       string    `json:"mappings"`
+	Sections       []struct {
+		Offset Offset  `json:"offset"`
+		URL    string  `json:"url"`
+		Map    *rawMap `json:"map"`
+	} `json:"sections"`
+}
+
+// NewConsumer decodes the source map data, which may be an index map.
+// code is the generated code the map is for, which is needed to look up
+// positions with byte columns, such as token positions; if it is empty,
+// columns are taken as counting UTF-16 code units.
+func NewConsumer(data []byte, code string) (*Consumer, error) {
+	var raw rawMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("sourcemap: %w", err)
+	}
+	c, err := newConsumer(&raw)
+	if err != nil {
+		return nil, err
+	}
+	c.code = lineTable{text: code}
+	return c, nil
+}
+
+func newConsumer(raw *rawMap) (*Consumer, error) {
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("sourcemap: unsupported version %d", raw.Version)
+	}
+
+	c := &Consumer{}
+	if raw.Sections != nil {
+		for _, s := range raw.Sections {
+			if s.Map == nil {
+				return nil, errors.New("sourcemap: sections with a url are not supported")
+			}
+			sc, err := newConsumer(s.Map)
+			if err != nil {
+				return nil, err
+			}
+			c.sections = append(c.sections, consumerSection{offset: s.Offset, c: sc})
+		}
+		return c, nil
+	}
+
+	for _, source := range raw.Sources {
+		name := ""
+		if source != nil {
+			name = *source
+		}
+		if raw.SourceRoot != "" {
+			name = strings.TrimSuffix(raw.SourceRoot, "/") + "/" + name
+		}
+		c.sources = append(c.sources, name)
+	}
+	c.contents = raw.SourcesContent
+	c.names = raw.Names
+
+	var err error
+	if c.lines, err = decodeMappings(raw.Mappings, len(c.sources), len(c.names)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// decodeMappings decodes the mappings field of a source map with the
+// given numbers of sources and names.
+func decodeMappings(mappings string, sources, names int) ([][]decodedSegment, error) {
+	var lines [][]decodedSegment
+	var segments []decodedSegment
+	var prev decodedSegment
+	i := 0
+	for i <= len(mappings) {
+		if i == len(mappings) || mappings[i] == ';' {
+			sort.SliceStable(segments, func(a, b int) bool { return segments[a].column < segments[b].column })
+			lines = append(lines, segments)
+			segments = nil
+			prev.column = 0
+			i++
+			continue
+		}
+		if mappings[i] == ',' {
+			i++
+			continue
+		}
+
+		var fields [5]int
+		n := 0
+		for ; i < len(mappings) && mappings[i] != ',' && mappings[i] != ';'; n++ {
+			if n == len(fields) {
+				return nil, errors.New("sourcemap: invalid mappings: too many fields in a segment")
+			}
+			var err error
+			if fields[n], i, err = decodeVLQ(mappings, i); err != nil {
+				return nil, err
+			}
+		}
+		if n != 1 && n != 4 && n != 5 {
+			return nil, fmt.Errorf("sourcemap: invalid mappings: segment of %d fields", n)
+		}
+
+		seg := decodedSegment{column: prev.column + fields[0], source: -1, name: -1}
+		prev.column = seg.column
+		if n >= 4 {
+			prev.source += fields[1]
+			prev.origLine += fields[2]
+			prev.origCol += fields[3]
+			if prev.source < 0 || prev.source >= sources {
+				return nil, fmt.Errorf("sourcemap: invalid mappings: source %d out of range", prev.source)
+			}
+			seg.source, seg.origLine, seg.origCol = prev.source, prev.origLine, prev.origCol
+		}
+		if n == 5 {
+			prev.name += fields[4]
+			if prev.name < 0 || prev.name >= names {
+				return nil, fmt.Errorf("sourcemap: invalid mappings: name %d out of range", prev.name)
+			}
+			seg.name = prev.name
+		}
+		segments = append(segments, seg)
+	}
+	return lines, nil
+}
+
+// Original returns the original position of pos, a position in the
+// generated code whose column counts bytes, like the positions of tokens,
+// if the map maps it. The segment of the map that is looked up is the last
+// one on the line of pos not after it.
+func (c *Consumer) Original(pos token.Position) (Original, bool) {
+	return c.originalFor(pos.Line, c.code.utf16Column(pos))
+}
+
+// OriginalLocation returns the original positions of the start and the end
+// of loc, such as the location of a token.
+func (c *Consumer) OriginalLocation(loc token.SourceLocation) (start, end Original, ok bool) {
+	start, ok = c.Original(loc.Start)
+	if !ok {
+		return start, end, false
+	}
+	end, ok = c.Original(loc.End)
+	return start, end, ok
+}
+
+func (c *Consumer) originalFor(line, column int) (Original, bool) {
+	if c.sections != nil {
+		i := sort.Search(len(c.sections), func(i int) bool {
+			o := c.sections[i].offset
+			return o.Line > line || o.Line == line && o.Column > column
+		}) - 1
+		if i < 0 {
+			return Original{}, false
+		}
+		o := c.sections[i].offset
+		if line == o.Line {
+			column -= o.Column
+		}
+		return c.sections[i].c.originalFor(line-o.Line, column)
+	}
+
+	if line < 0 || line >= len(c.lines) {
+		return Original{}, false
+	}
+	segments := c.lines[line]
+	i := sort.Search(len(segments), func(i int) bool { return segments[i].column > column }) - 1
+	if i < 0 || segments[i].source < 0 {
+		return Original{}, false
+	}
+	seg := segments[i]
+	orig := Original{Source: c.sources[seg.source], Line: seg.origLine, Column: seg.origCol}
+	if seg.name >= 0 {
+		orig.Name = c.names[seg.name]
+	}
+	return orig, true
+}
+
+// SourceContent returns the content of the source named source if the map
+// includes it.
+func (c *Consumer) SourceContent(source string) (string, bool) {
+	for i, s := range c.sources {
+		if s == source && i < len(c.contents) && c.contents[i] != nil {
+			return *c.contents[i], true
+		}
+	}
+	for _, s := range c.sections {
+		if content, ok := s.c.SourceContent(source); ok {
+			return content, true
+		}
+	}
+	return "", false
+}
+
+// Load returns a Consumer of the source map linked to by the
+// sourceMappingURL comment of code, which is read with a lexer created
+// with opts, after lexer.WithRegExpHeuristic. Syntax errors in code are
+// skipped, as only the comment matters. The map may be inline, in a data
+// URL, or in a file, which is looked for relative to dir.
+func Load(code, dir string, opts ...lexer.Option) (*Consumer, error) {
+	l := lexer.New(code, append([]lexer.Option{lexer.WithRegExpHeuristic()}, opts...)...)
+	for {
+		tok, _ := l.NextToken()
+		if tok != nil && tok.Type.Label == token.EOF {
+			break
+		}
+	}
+	mapURL := l.SourceMappingURL()
+	if mapURL == "" {
+		return nil, errors.New("sourcemap: no sourceMappingURL comment")
+	}
+	data, err := readURL(mapURL, dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewConsumer(data, code)
+}
+
+// readURL returns the source map at mapURL, a data URL or the path of a
+// file relative to dir.
+func readURL(mapURL, dir string) ([]byte, error) {
+	if rest, ok := strings.CutPrefix(mapURL, "data:"); ok {
+		header, data, ok := strings.Cut(rest, ",")
+		if !ok {
+			return nil, errors.New("sourcemap: invalid data URL")
+		}
+		if strings.HasSuffix(header, ";base64") {
+			b, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, fmt.Errorf("sourcemap: invalid data URL: %w", err)
+			}
+			return b, nil
+		}
+		s, err := url.PathUnescape(data)
+		if err != nil {
+			return nil, fmt.Errorf("sourcemap: invalid data URL: %w", err)
+		}
+		return []byte(s), nil
+	}
+
+	if strings.Contains(mapURL, "://") {
+		return nil, fmt.Errorf("sourcemap: cannot load %s", mapURL)
+	}
+	path, err := url.PathUnescape(mapURL)
+	if err != nil {
+		path = mapURL
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+	}
+	return os.ReadFile(path)
+}
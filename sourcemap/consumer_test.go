@@ -0,0 +1,195 @@
+package sourcemap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+func TestConsumer(t *testing.T) {
+	g := NewGenerator("out.js")
+	g.AddSource("in.js", "let answer = 42;")
+	g.AddMapping(Mapping{Generated: pos(0, 0), Source: "in.js", Original: pos(0, 0)})
+	g.AddMapping(Mapping{Generated: pos(0, 4), Source: "in.js", Original: pos(0, 4), Name: "answer"})
+	g.AddMapping(Mapping{Generated: pos(0, 6), Source: "in.js", Original: pos(0, 13)})
+	g.AddMapping(Mapping{Generated: pos(1, 2), Source: "other.js", Original: pos(3, 1)})
+
+	c, err := NewConsumer(g.Map("").JSON(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	tests := []struct {
+		input    int
+		column   int
+		expected Original
+		ok       bool
+	}{
+		{0, 0, Original{"in.js", 0, 0, ""}, true},
+		{0, 3, Original{"in.js", 0, 0, ""}, true},
+		{0, 4, Original{"in.js", 0, 4, "answer"}, true},
+		{0, 5, Original{"in.js", 0, 4, "answer"}, true},
+		{0, 9, Original{"in.js", 0, 13, ""}, true},
+		{1, 0, Original{}, false},
+		{1, 2, Original{"other.js", 3, 1, ""}, true},
+		{2, 0, Original{}, false},
+	}
+
+	for i, tt := range tests {
+		got, ok := c.Original(pos(tt.input, tt.column))
+		if ok != tt.ok {
+			t.Fatalf("tests[%d] - ok wrong. expected=%t, got=%t", i, tt.ok, ok)
+		}
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - original wrong. expected=%+v, got=%+v", i, tt.expected, got)
+		}
+	}
+
+	if content, ok := c.SourceContent("in.js"); !ok || content != "let answer = 42;" {
+		t.Fatalf("source content wrong. got=%q", content)
+	}
+	if _, ok := c.SourceContent("other.js"); ok {
+		t.Fatalf("expected no content for other.js")
+	}
+}
+
+func TestConsumerTokens(t *testing.T) {
+	// Each token of the generated code maps to the same token in the
+	// original, whose columns count UTF-16 code units.
+	original := "const s = '😀';\n  f(s,\n    s);"
+	code := "const s='😀';f(s,s);"
+
+	ol := lexer.New(original)
+	cl := lexer.New(code)
+	g := NewGenerator("")
+	g.AddSource("in.js", original)
+	var expected []Original
+	for {
+		otok, err := ol.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		ctok, err := cl.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		if otok.Type.Label == token.EOF {
+			break
+		}
+		g.AddMapping(Mapping{Generated: ctok.Loc.Start, Source: "in.js", Original: otok.Loc.Start})
+		expected = append(expected, Original{Source: "in.js", Line: otok.Loc.Start.Line, Column: otok.Loc.Start.Column})
+	}
+	// The semicolon after the emoji, which takes four bytes and two UTF-16
+	// code units
+	expected[4].Column -= 2
+
+	c, err := NewConsumer(g.Map(code).JSON(), code)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	toks, err := lexer.Tokenize(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+	for i, tok := range toks {
+		got, ok := c.Original(tok.Loc.Start)
+		if !ok || got != expected[i] {
+			t.Fatalf("tests[%d] - original of %q wrong. expected=%+v, got=%+v", i, tok.Literal, expected[i], got)
+		}
+	}
+}
+
+func TestConsumerIndexMap(t *testing.T) {
+	a := NewGenerator("")
+	a.AddMapping(Mapping{Generated: pos(0, 0), Source: "a.js", Original: pos(5, 0)})
+	b := NewGenerator("")
+	b.AddMapping(Mapping{Generated: pos(0, 0), Source: "b.js", Original: pos(1, 1)})
+	b.AddMapping(Mapping{Generated: pos(1, 0), Source: "b.js", Original: pos(2, 2)})
+
+	im := NewIndexMap("")
+	im.AddSection(0, 0, a.Map(""))
+	im.AddSection(0, 10, b.Map(""))
+
+	c, err := NewConsumer(im.JSON(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err.Error())
+	}
+
+	tests := []struct {
+		line, column int
+		expected     Original
+	}{
+		{0, 9, Original{"a.js", 5, 0, ""}},
+		{0, 12, Original{"b.js", 1, 1, ""}},
+		{1, 3, Original{"b.js", 2, 2, ""}},
+	}
+	for i, tt := range tests {
+		got, ok := c.Original(pos(tt.line, tt.column))
+		if !ok || got != tt.expected {
+			t.Fatalf("tests[%d] - original wrong. expected=%+v, got=%+v", i, tt.expected, got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	g := NewGenerator("out.js")
+	g.AddMapping(Mapping{Generated: pos(0, 4), Source: "in.ts", Original: pos(2, 6), Name: "x"})
+	m := g.Map("")
+	code := "var x = 1;\n"
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.js.map"), m.JSON(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []string{
+		code + Comment(m.DataURL()),
+		code + Comment("out.js.map") + "\n",
+		code + "/*# sourceMappingURL=out.js.map */",
+		code + "//@ sourceMappingURL=data:application/json," + `{"version":3,"sources":["in.ts"],"names":["x"],"mappings":"IAEMA"}`,
+		code + "x=/\"/.test(a)\n" + Comment(m.DataURL()),
+		code + "a # b '\n" + Comment(m.DataURL()),
+	}
+	for i, input := range tests {
+		c, err := Load(input, dir)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %q", i, err.Error())
+		}
+		got, ok := c.Original(pos(0, 4))
+		if expected := (Original{"in.ts", 2, 6, "x"}); !ok || got != expected {
+			t.Fatalf("tests[%d] - original wrong. expected=%+v, got=%+v", i, expected, got)
+		}
+	}
+
+	if _, err := Load(code, dir); err == nil {
+		t.Fatalf("expected error without a sourceMappingURL comment")
+	}
+	if _, err := Load(code+"'//# sourceMappingURL=out.js.map'", dir); err == nil {
+		t.Fatalf("expected error for a comment inside a string")
+	}
+	if _, err := Load(code+Comment("missing.map"), dir); err == nil {
+		t.Fatalf("expected error for a missing file")
+	}
+}
+
+func TestConsumerError(t *testing.T) {
+	tests := []string{
+		`{"version":2,"sources":[],"names":[],"mappings":""}`,
+		`{"version":3,"sources":[],"names":[],"mappings":"AAAA"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"AA"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"AAAAC"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"A!AA"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"g"}`,
+		`{"version":3,"sections":[{"offset":{"line":0,"column":0},"url":"a.map"}]}`,
+		`not json`,
+	}
+
+	for i, input := range tests {
+		if _, err := NewConsumer([]byte(input), ""); err == nil {
+			t.Fatalf("tests[%d] - expected error, got none", i)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package sourcemap generates source maps in the format of revision 3 of
+// the Source Map specification.
+package sourcemap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// Map is a source map, which marshals to the JSON of the format. Columns in
+// Mappings count UTF-16 code units, as the format requires.
+type Map struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file,omitempty"`
+	SourceRoot     string    `json:"sourceRoot,omitempty"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent,omitempty"`
+	Names          []string  `json:"names"`
+	Mappings       string    `json:"mappings"`
+}
+
+// JSON returns the encoding of m.
+func (m *Map) JSON() []byte {
+	b, _ := json.Marshal(m)
+	return b
+}
+
+// DataURL returns a data URL holding m, to be referred to by the
+// sourceMappingURL comment of an inline source map.
+func (m *Map) DataURL() string {
+	return "data:application/json;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(m.JSON())
+}
+
+// Comment returns the comment that links generated JavaScript code to its
+// source map at url, to be appended to the code on a line of its own.
+func Comment(url string) string {
+	return "//# sourceMappingURL=" + url
+}
+
+// Mapping maps a position in the generated code to one in a source, such
+// as the start of the location of a token that was copied or rewritten.
+// Columns count bytes, like those of the lexer, and are converted to UTF-16
+// code units for the map when the text they are in is known.
+type Mapping struct {
+	Generated token.Position
+	Source    string
+	Original  token.Position
+	// Name is the original name of the identifier at the position, if it
+	// was renamed.
+	Name string
+}
+
+// Generator builds a Map from mappings.
+type Generator struct {
+	file     string
+	sources  []source
+	index    map[string]int
+	mappings []Mapping
+}
+
+type source struct {
+	name    string
+	content *string
+	lines   lineTable
+}
+
+// NewGenerator returns a Generator of a source map for the generated file
+// named file.
+func NewGenerator(file string) *Generator {
+	return &Generator{file: file, index: map[string]int{}}
+}
+
+// AddSource adds the source named name with its content, which the map
+// includes as sourcesContent. Sources that mappings refer to without having
+// been added are added without content, and their columns are taken as
+// counting UTF-16 code units already.
+func (g *Generator) AddSource(name, content string) {
+	i := g.sourceIndex(name)
+	g.sources[i].content = &content
+	g.sources[i].lines = lineTable{text: content}
+}
+
+func (g *Generator) sourceIndex(name string) int {
+	i, ok := g.index[name]
+	if !ok {
+		i = len(g.sources)
+		g.sources = append(g.sources, source{name: name})
+		g.index[name] = i
+	}
+	return i
+}
+
+// AddMapping adds m to the source map. Mappings may be added in any order.
+func (g *Generator) AddMapping(m Mapping) {
+	g.sourceIndex(m.Source)
+	g.mappings = append(g.mappings, m)
+}
+
+// Map returns the source map of the mappings added so far. code is the
+// generated code, whose columns are converted to UTF-16 code units; if it
+// is empty, they are taken as counting UTF-16 code units already.
+func (g *Generator) Map(code string) *Map {
+	generated := lineTable{text: code}
+	mappings := make([]Mapping, len(g.mappings))
+	copy(mappings, g.mappings)
+	sort.SliceStable(mappings, func(i, j int) bool {
+		a, b := mappings[i].Generated, mappings[j].Generated
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	m := &Map{Version: 3, File: g.file, Sources: []string{}, Names: []string{}}
+	withContent := false
+	for _, s := range g.sources {
+		m.Sources = append(m.Sources, s.name)
+		m.SourcesContent = append(m.SourcesContent, s.content)
+		withContent = withContent || s.content != nil
+	}
+	if !withContent {
+		m.SourcesContent = nil
+	}
+
+	names := map[string]int{}
+	var b []byte
+	var prev segment
+	line := 0
+	first := true
+	for _, mapping := range mappings {
+		for line < mapping.Generated.Line {
+			b = append(b, ';')
+			line++
+			prev.column = 0
+			first = true
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+
+		src := g.index[mapping.Source]
+		seg := segment{
+			column:   generated.utf16Column(mapping.Generated),
+			source:   src,
+			origLine: mapping.Original.Line,
+			origCol:  g.sources[src].lines.utf16Column(mapping.Original),
+			name:     prev.name,
+		}
+		b = appendVLQ(b, seg.column-prev.column)
+		b = appendVLQ(b, seg.source-prev.source)
+		b = appendVLQ(b, seg.origLine-prev.origLine)
+		b = appendVLQ(b, seg.origCol-prev.origCol)
+		if mapping.Name != "" {
+			i, ok := names[mapping.Name]
+			if !ok {
+				i = len(m.Names)
+				m.Names = append(m.Names, mapping.Name)
+				names[mapping.Name] = i
+			}
+			seg.name = i
+			b = appendVLQ(b, seg.name-prev.name)
+		}
+		prev = seg
+	}
+	m.Mappings = string(b)
+	return m
+}
+
+// segment holds the fields of a segment of the mappings, which are encoded
+// relative to those of the previous segment.
+type segment struct {
+	column   int
+	source   int
+	origLine int
+	origCol  int
+	name     int
+}
+
+// IndexMap is a source map made of sections, each a source map for the
+// generated code from an offset on, as produced by concatenating files.
+type IndexMap struct {
+	Version  int       `json:"version"`
+	File     string    `json:"file,omitempty"`
+	Sections []Section `json:"sections"`
+}
+
+// Section is a section of an IndexMap.
+type Section struct {
+	Offset Offset `json:"offset"`
+	Map    *Map   `json:"map"`
+}
+
+// Offset is the zero-based position in the generated code at which a
+// section starts, its column counting UTF-16 code units.
+type Offset struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// NewIndexMap returns an IndexMap for the generated file named file.
+func NewIndexMap(file string) *IndexMap {
+	return &IndexMap{Version: 3, File: file, Sections: []Section{}}
+}
+
+// AddSection adds the source map m of the part of the generated code that
+// starts at line and column. Sections must be added in order.
+func (im *IndexMap) AddSection(line, column int, m *Map) {
+	im.Sections = append(im.Sections, Section{Offset: Offset{Line: line, Column: column}, Map: m})
+}
+
+// JSON returns the encoding of im.
+func (im *IndexMap) JSON() []byte {
+	b, _ := json.Marshal(im)
+	return b
+}
@@ -0,0 +1,116 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+func pos(line, column int) token.Position {
+	return token.Position{Line: line, Column: column}
+}
+
+func TestAppendVLQ(t *testing.T) {
+	tests := []struct {
+		input    int
+		expected string
+	}{
+		{0, "A"},
+		{1, "C"},
+		{-1, "D"},
+		{15, "e"},
+		{16, "gB"},
+		{-16, "hB"},
+		{123456, "gkxH"},
+	}
+
+	for i, tt := range tests {
+		got := string(appendVLQ(nil, tt.input))
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - encoding of %d wrong. expected=%q, got=%q",
+				i, tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator("out.js")
+	g.AddMapping(Mapping{Generated: pos(0, 0), Source: "a.js", Original: pos(0, 0)})
+	g.AddMapping(Mapping{Generated: pos(2, 3), Source: "a.js", Original: pos(1, 0)})
+	g.AddMapping(Mapping{Generated: pos(0, 4), Source: "a.js", Original: pos(1, 2), Name: "foo"})
+	g.AddMapping(Mapping{Generated: pos(2, 1), Source: "b.js", Original: pos(0, 0), Name: "bar"})
+	g.AddMapping(Mapping{Generated: pos(2, 5), Source: "b.js", Original: pos(0, 4), Name: "foo"})
+
+	expected := `{"version":3,"file":"out.js","sources":["a.js","b.js"],"names":["foo","bar"],"mappings":"AAAA,IACEA;;CCDFC,EDCA,ECDID"}`
+	if got := string(g.Map("").JSON()); got != expected {
+		t.Fatalf("source map wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestGeneratorUTF16(t *testing.T) {
+	// é takes two bytes and one UTF-16 code unit, 😀 four bytes and two
+	// code units.
+	original := "let s = 'é😀'; x\nlet t = 'é'; y"
+	code := "let s='é😀';x;let t='é';y"
+
+	g := NewGenerator("")
+	g.AddSource("in.js", original)
+	g.AddMapping(Mapping{Generated: pos(0, 15), Source: "in.js", Original: pos(0, 18)})
+	g.AddMapping(Mapping{Generated: pos(0, 28), Source: "in.js", Original: pos(1, 14)})
+	m := g.Map(code)
+
+	// x at UTF-16 columns 12 and 15, y at 24 and 13
+	if m.Mappings != "YAAe,YACF" {
+		t.Fatalf("mappings wrong. got=%q", m.Mappings)
+	}
+	if len(m.SourcesContent) != 1 || *m.SourcesContent[0] != original {
+		t.Fatalf("sourcesContent wrong. got=%v", m.SourcesContent)
+	}
+}
+
+func TestSourcesContent(t *testing.T) {
+	g := NewGenerator("")
+	g.AddMapping(Mapping{Source: "a.js"})
+	g.AddSource("b.js", "b")
+	expected := `{"version":3,"sources":["a.js","b.js"],"sourcesContent":[null,"b"],"names":[],"mappings":"AAAA"}`
+	if got := string(g.Map("").JSON()); got != expected {
+		t.Fatalf("source map wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestIndexMap(t *testing.T) {
+	a := NewGenerator("a.js")
+	a.AddMapping(Mapping{Source: "a.ts"})
+	b := NewGenerator("b.js")
+	b.AddMapping(Mapping{Generated: pos(0, 2), Source: "b.ts", Original: pos(1, 0)})
+
+	im := NewIndexMap("bundle.js")
+	im.AddSection(0, 0, a.Map(""))
+	im.AddSection(10, 0, b.Map(""))
+
+	expected := `{"version":3,"file":"bundle.js","sections":[` +
+		`{"offset":{"line":0,"column":0},"map":{"version":3,"file":"a.js","sources":["a.ts"],"names":[],"mappings":"AAAA"}},` +
+		`{"offset":{"line":10,"column":0},"map":{"version":3,"file":"b.js","sources":["b.ts"],"names":[],"mappings":"EACA"}}]}`
+	if got := string(im.JSON()); got != expected {
+		t.Fatalf("index map wrong. expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestURL(t *testing.T) {
+	m := NewGenerator("").Map("")
+	url := m.DataURL()
+	prefix := "data:application/json;charset=utf-8;base64,"
+	if !strings.HasPrefix(url, prefix) {
+		t.Fatalf("data URL wrong. got=%q", url)
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(url, prefix))
+	if err != nil || string(b) != string(m.JSON()) {
+		t.Fatalf("data URL content wrong. got=%q", b)
+	}
+
+	if got := Comment("a.js.map"); got != "//# sourceMappingURL=a.js.map" {
+		t.Fatalf("comment wrong. got=%q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package sourcemap
+
+import (
+	"strings"
+
+	"github.com/morinokami/js-lexer/token"
+)
+
+// lineTable converts byte columns in text to UTF-16 columns, finding the
+// starts of lines as they are needed.
+type lineTable struct {
+	text       string
+	lineStarts []int
+}
+
+// lineStart returns the offset of the start of line, or -1 if the text has
+// fewer lines.
+func (t *lineTable) lineStart(line int) int {
+	if t.lineStarts == nil {
+		t.lineStarts = []int{0}
+	}
+	for len(t.lineStarts) <= line {
+		last := t.lineStarts[len(t.lineStarts)-1]
+		i := strings.IndexByte(t.text[last:], '\n')
+		if i < 0 {
+			return -1
+		}
+		t.lineStarts = append(t.lineStarts, last+i+1)
+	}
+	return t.lineStarts[line]
+}
+
+// utf16Column returns the number of UTF-16 code units before the byte
+// column of pos on its line. Without text, or past its end, columns are
+// returned as they are.
+func (t *lineTable) utf16Column(pos token.Position) int {
+	if t.text == "" {
+		return pos.Column
+	}
+	start := t.lineStart(pos.Line)
+	if start < 0 {
+		return pos.Column
+	}
+	end := start + pos.Column
+	if end > len(t.text) {
+		end = len(t.text)
+	}
+	n := 0
+	for _, r := range t.text[start:end] {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n + pos.Column - (end - start)
+}
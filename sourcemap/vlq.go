@@ -0,0 +1,58 @@
+package sourcemap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// appendVLQ appends the Base64 VLQ encoding of n to b: the sign in the
+// lowest bit, then five bits per digit, least significant first, with the
+// sixth bit of a digit set when more digits follow.
+func appendVLQ(b []byte, n int) []byte {
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		b = append(b, base64Digits[digit])
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+// decodeVLQ decodes the Base64 VLQ starting at s[i], and returns its value
+// and the index following it.
+func decodeVLQ(s string, i int) (int, int, error) {
+	v, shift := 0, 0
+	for {
+		if i >= len(s) {
+			return 0, i, errors.New("sourcemap: invalid mappings: unterminated VLQ")
+		}
+		digit := strings.IndexByte(base64Digits, s[i])
+		if digit < 0 {
+			return 0, i, fmt.Errorf("sourcemap: invalid mappings: unexpected %q", s[i])
+		}
+		i++
+		v |= digit & 0x1f << shift
+		shift += 5
+		if digit&0x20 == 0 {
+			break
+		}
+		if shift > 60 {
+			return 0, i, errors.New("sourcemap: invalid mappings: VLQ out of range")
+		}
+	}
+	if v&1 != 0 {
+		return -(v >> 1), i, nil
+	}
+	return v >> 1, i, nil
+}
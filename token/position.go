@@ -0,0 +1,208 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Pos is a compact position in a FileSet: the base of a file plus a byte
+// offset in it. Unlike a Position, it identifies the file, and it takes a
+// single integer. The zero value is NoPos.
+type Pos int
+
+// NoPos is the position of no file.
+const NoPos Pos = 0
+
+// IsValid reports whether p is not NoPos.
+func (p Pos) IsValid() bool {
+	return p != NoPos
+}
+
+// FilePosition is a position in a named file. Like a Position, Line and
+// Column are zero-based and Column counts bytes. Offset is the byte offset
+// of the position in the file.
+type FilePosition struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// String formats p as file:line:col with one-based lines and columns, as
+// jslex reports syntax errors.
+func (p FilePosition) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line+1, p.Column+1)
+}
+
+// File is a file added to a FileSet, which maps the positions in it to
+// lines and columns. The offsets of its lines are only computed when a
+// position is first looked up.
+type File struct {
+	name string
+	base int
+	src  string
+
+	once  sync.Once
+	lines []int
+}
+
+// Name returns the name the file was added with.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Base returns the position of the start of the file.
+func (f *File) Base() int {
+	return f.base
+}
+
+// Size returns the size of the file in bytes.
+func (f *File) Size() int {
+	return len(f.src)
+}
+
+// Source returns the contents of the file.
+func (f *File) Source() string {
+	return f.src
+}
+
+// Pos returns the position of the byte offset in the file, which may be
+// the size of the file for its end. It panics if offset is out of range.
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > len(f.src) {
+		panic(fmt.Sprintf("token: offset %d out of range [0, %d] of file %s", offset, len(f.src), f.name))
+	}
+	return Pos(f.base + offset)
+}
+
+// Offset returns the byte offset of p in the file. It panics if p is not in
+// the file.
+func (f *File) Offset(p Pos) int {
+	if int(p) < f.base || int(p) > f.base+len(f.src) {
+		panic(fmt.Sprintf("token: position %d out of range [%d, %d] of file %s", p, f.base, f.base+len(f.src), f.name))
+	}
+	return int(p) - f.base
+}
+
+// LineCount returns the number of lines in the file.
+func (f *File) LineCount() int {
+	return len(f.lineStarts())
+}
+
+// Position returns the position of p in the file. It panics if p is not in
+// the file.
+func (f *File) Position(p Pos) FilePosition {
+	offset := f.Offset(p)
+	lines := f.lineStarts()
+	line := sort.Search(len(lines), func(i int) bool { return lines[i] > offset }) - 1
+	return FilePosition{Filename: f.name, Offset: offset, Line: line, Column: offset - lines[line]}
+}
+
+// lineStarts returns the byte offsets at which the lines of the file start.
+// Like the lexer, it ends lines at "\n" only.
+func (f *File) lineStarts() []int {
+	f.once.Do(func() {
+		f.lines = []int{0}
+		for i := 0; i < len(f.src); i++ {
+			if f.src[i] == '\n' {
+				f.lines = append(f.lines, i+1)
+			}
+		}
+	})
+	return f.lines
+}
+
+// FileSet is a set of files whose positions do not overlap, so that a Pos
+// identifies both a file and a position in it. It is safe for concurrent
+// use.
+type FileSet struct {
+	mu    sync.RWMutex
+	base  int
+	files []*File
+}
+
+// NewFileSet returns an empty file set.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// Base returns the base the next file added will have.
+func (s *FileSet) Base() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.base
+}
+
+// AddFile adds a file with the given name and contents to the set. Its
+// positions start at the base of the set, which moves past the end of the
+// file, so that the end of each file has a position of its own.
+func (s *FileSet) AddFile(filename, src string) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := &File{name: filename, base: s.base, src: src}
+	s.base += len(src) + 1
+	s.files = append(s.files, f)
+	return f
+}
+
+// File returns the file p is in, or nil if there is none.
+func (s *FileSet) File(p Pos) *File {
+	if !p.IsValid() {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].base > int(p) }) - 1
+	if i < 0 || int(p) > s.files[i].base+len(s.files[i].src) {
+		return nil
+	}
+	return s.files[i]
+}
+
+// Position returns the position of p, or the zero FilePosition if p is not
+// in a file of the set.
+func (s *FileSet) Position(p Pos) FilePosition {
+	f := s.File(p)
+	if f == nil {
+		return FilePosition{}
+	}
+	return f.Position(p)
+}
+
+// Compact is a token that only keeps the positions of its start and end in
+// a FileSet, which gives its offsets and location. It is smaller than a
+// Token, for keeping the tokens of many files.
+type Compact struct {
+	Type    TokenType
+	Literal string
+	Pos     Pos
+	End     Pos
+}
+
+// Compact returns tok without its offsets and location, which must have a
+// position.
+func (tok Token) Compact() Compact {
+	return Compact{Type: tok.Type, Literal: tok.Literal, Pos: tok.Pos, End: tok.Pos + Pos(tok.End-tok.Start)}
+}
+
+// Token returns the token c was made from, with its offsets and location
+// in its file. It panics if c is not in a file of the set.
+func (s *FileSet) Token(c Compact) Token {
+	f := s.File(c.Pos)
+	if f == nil {
+		panic(fmt.Sprintf("token: position %d not in the file set", c.Pos))
+	}
+	start, end := f.Position(c.Pos), f.Position(c.End)
+	return Token{
+		Type:    c.Type,
+		Literal: c.Literal,
+		Loc: SourceLocation{
+			Start: Position{Line: start.Line, Column: start.Column},
+			End:   Position{Line: end.Line, Column: end.Column},
+		},
+		Start: start.Offset,
+		End:   end.Offset,
+		Pos:   c.Pos,
+	}
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFileSet(t *testing.T) {
+	fset := NewFileSet()
+	a := fset.AddFile("a.js", "let a\n= 1\n")
+	b := fset.AddFile("b.js", "")
+	c := fset.AddFile("c.js", "x\r\ny")
+
+	if a.Base() != 1 || b.Base() != 12 || c.Base() != 13 || fset.Base() != 18 {
+		t.Fatalf("bases wrong. got=%d, %d, %d, %d", a.Base(), b.Base(), c.Base(), fset.Base())
+	}
+
+	tests := []struct {
+		pos      Pos
+		expected FilePosition
+	}{
+		{a.Pos(0), FilePosition{"a.js", 0, 0, 0}},
+		{a.Pos(4), FilePosition{"a.js", 4, 0, 4}},
+		{a.Pos(5), FilePosition{"a.js", 5, 0, 5}},
+		{a.Pos(6), FilePosition{"a.js", 6, 1, 0}},
+		{a.Pos(10), FilePosition{"a.js", 10, 2, 0}},
+		{b.Pos(0), FilePosition{"b.js", 0, 0, 0}},
+		{c.Pos(2), FilePosition{"c.js", 2, 0, 2}},
+		{c.Pos(4), FilePosition{"c.js", 4, 1, 1}},
+		{NoPos, FilePosition{}},
+		{Pos(100), FilePosition{}},
+	}
+
+	for i, tt := range tests {
+		got := fset.Position(tt.pos)
+		if got != tt.expected {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v", i, tt.expected, got)
+		}
+	}
+
+	if s := fset.Position(a.Pos(8)).String(); s != "a.js:2:3" {
+		t.Fatalf("string wrong. expected=%q, got=%q", "a.js:2:3", s)
+	}
+	if a.LineCount() != 3 || b.LineCount() != 1 || c.LineCount() != 2 {
+		t.Fatalf("line counts wrong. got=%d, %d, %d", a.LineCount(), b.LineCount(), c.LineCount())
+	}
+	if f := fset.File(c.Pos(4)); f != c {
+		t.Fatalf("file wrong. expected=%q, got=%v", c.Name(), f)
+	}
+}
+
+func TestFileSetConcurrent(t *testing.T) {
+	fset := NewFileSet()
+	files := make([]*File, 100)
+	var wg sync.WaitGroup
+	for i := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			files[i] = fset.AddFile("f.js", "a\nbc")
+			fset.Position(files[i].Pos(3))
+		}()
+	}
+	wg.Wait()
+
+	for i, f := range files {
+		if got := fset.Position(f.Pos(3)); got != (FilePosition{"f.js", 3, 1, 1}) {
+			t.Fatalf("files[%d] - position wrong. got=%+v", i, got)
+		}
+		if fset.File(f.Pos(0)) != f {
+			t.Fatalf("files[%d] - file wrong", i)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	fset := NewFileSet()
+	fset.AddFile("a.js", "a")
+	f := fset.AddFile("b.js", "x = `a\nb`")
+	tok := Token{
+		Type:    TokenType{Label: String},
+		Literal: "a\nb",
+		Loc:     SourceLocation{Start: Position{Line: 0, Column: 5}, End: Position{Line: 1, Column: 1}},
+		Start:   5,
+		End:     8,
+		Pos:     f.Pos(5),
+	}
+
+	c := tok.Compact()
+	if c.Pos != f.Pos(5) || c.End != f.Pos(8) {
+		t.Fatalf("compact token wrong. got=%+v", c)
+	}
+	if got := fset.Token(c); got != tok {
+		t.Fatalf("token wrong. expected=%+v, got=%+v", tok, got)
+	}
+}
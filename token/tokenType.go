@@ -5,25 +5,36 @@ type TokenType struct {
 }
 
 type Position struct {
-	Line   int
-	Column int
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 type SourceLocation struct {
-	Start Position
-	End   Position
+	Start Position `json:"start"`
+	End   Position `json:"end"`
 }
 
 type Token struct {
 	Type    TokenType
 	Literal string
 	Loc     SourceLocation
+	// Start and End are the byte offsets of the token in the input.
+	Start int
+	End   int
+	// Pos is the position of the start of the token in a FileSet, if the
+	// lexer was given the file of the input, and NoPos otherwise.
+	Pos Pos
 }
 
 const (
 	EOF = "eof"
 
-	Identifier = "identifier"
+	Identifier  = "identifier"
+	PrivateName = "private-name"
+
+	// Comments
+	LineComment  = "line-comment"
+	BlockComment = "block-comment"
 
 	// Keywords
 	Await      = "await"
@@ -46,7 +57,7 @@ const (
 	For        = "for"
 	Function   = "function"
 	If         = "if"
-	Import     = "Import"
+	Import     = "import"
 	In         = "in"
 	Instanceof = "instanceof"
 	New        = "new"
@@ -65,6 +76,32 @@ const (
 	With       = "with"
 	Yield      = "yield"
 
+	// Contextual keywords, which are keywords only in some positions and
+	// identifiers elsewhere
+	Accessor = "accessor"
+
+	// TypeScript contextual keywords
+	Abstract   = "abstract"
+	As         = "as"
+	Asserts    = "asserts"
+	Declare    = "declare"
+	Global     = "global"
+	Implements = "implements"
+	Infer      = "infer"
+	Interface  = "interface"
+	Is         = "is"
+	Keyof      = "keyof"
+	Module     = "module"
+	Namespace  = "namespace"
+	Override   = "override"
+	Private    = "private"
+	Protected  = "protected"
+	Public     = "public"
+	Readonly   = "readonly"
+	Satisfies  = "satisfies"
+	Type       = "type"
+	Unique     = "unique"
+
 	// Punctuators
 	LParen           = "("
 	RParen           = ")"
@@ -79,6 +116,7 @@ const (
 	Comma            = ","
 	Question         = "?"
 	OptionalChaining = "?."
+	At               = "@"
 
 	// Operators
 	LT                           = "<"
@@ -121,7 +159,11 @@ const (
 	BitwiseAndAssignment         = "&="
 	BitwiseOrAssignment          = "|="
 	BitwiseXorAssignment         = "^="
+	LogicalAndAssignment         = "&&="
+	LogicalOrAssignment          = "||="
+	NullishCoalescingAssignment  = "??="
 	Arrow                        = "=>"
+	NonNull                      = "non-null" // TypeScript's postfix !
 
 	// Literals
 	Numeric           = "numeric"
@@ -131,6 +173,13 @@ const (
 	TemplateEnd       = "template-end"
 	SubstitutionStart = "substitution-start"
 	SubstitutionEnd   = "substitution-end"
+
+	// JSX
+	JSXIdentifier = "jsx-identifier"
+	JSXString     = "jsx-string"
+	JSXText       = "jsx-text"
+	JSXTagStart   = "jsx-tag-start"
+	JSXTagEnd     = "jsx-tag-end"
 )
 
 var keywords = map[string]TokenType{
@@ -174,9 +223,64 @@ var keywords = map[string]TokenType{
 	"yield":      {Yield},
 }
 
+var contextualKeywords = map[string]TokenType{
+	"accessor": {Accessor},
+}
+
+// IsKeyword reports whether label is the label of a keyword token.
+func IsKeyword(label string) bool {
+	_, ok := keywords[label]
+	return ok
+}
+
 func LookupIdent(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
 		return tok
 	}
+	if tok, ok := contextualKeywords[ident]; ok {
+		return tok
+	}
 	return TokenType{Identifier}
 }
+
+var tsKeywords = map[string]TokenType{
+	"abstract":   {Abstract},
+	"as":         {As},
+	"asserts":    {Asserts},
+	"declare":    {Declare},
+	"global":     {Global},
+	"implements": {Implements},
+	"infer":      {Infer},
+	"interface":  {Interface},
+	"is":         {Is},
+	"keyof":      {Keyof},
+	"module":     {Module},
+	"namespace":  {Namespace},
+	"override":   {Override},
+	"private":    {Private},
+	"protected":  {Protected},
+	"public":     {Public},
+	"readonly":   {Readonly},
+	"satisfies":  {Satisfies},
+	"type":       {Type},
+	"unique":     {Unique},
+}
+
+// IsContextualKeyword reports whether label is the label of a contextual
+// keyword token, which a parser may have to take for an identifier.
+func IsContextualKeyword(label string) bool {
+	if _, ok := contextualKeywords[label]; ok {
+		return true
+	}
+	_, ok := tsKeywords[label]
+	return ok
+}
+
+// LookupTSIdent is like LookupIdent, but also classifies the contextual
+// keywords of TypeScript.
+func LookupTSIdent(ident string) TokenType {
+	if tok, ok := tsKeywords[ident]; ok {
+		return tok
+	}
+	return LookupIdent(ident)
+}
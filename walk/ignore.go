@@ -0,0 +1,128 @@
+package walk
+
+import (
+	"bufio"
+	"path"
+	"strings"
+)
+
+// pattern is a glob pattern matched against slash-separated paths, with
+// the syntax of .gitignore files: "*", "?", and "[...]" match within a path
+// element, "**" matches any number of elements, a pattern without a slash
+// but at its end matches the last element at any depth, and a trailing
+// slash matches directories only.
+type pattern struct {
+	elems   []string
+	negate  bool
+	dirOnly bool
+}
+
+// parsePattern parses a glob pattern. Patterns starting with "!" negate,
+// which only matters in .gitignore files.
+func parsePattern(s string) pattern {
+	var p pattern
+	if strings.HasPrefix(s, "!") {
+		p.negate = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, `\!`) || strings.HasPrefix(s, `\#`) {
+		s = s[1:]
+	}
+	if strings.HasSuffix(s, "/") {
+		p.dirOnly = true
+		s = strings.TrimRight(s, "/")
+	}
+	if !strings.Contains(s, "/") {
+		// Not anchored, so that it matches at any depth
+		s = "**/" + s
+	}
+	p.elems = strings.Split(strings.TrimPrefix(s, "/"), "/")
+	return p
+}
+
+// match reports whether p matches name, a slash-separated path relative to
+// the directory the pattern applies to.
+func (p pattern) match(name string, dir bool) bool {
+	if p.dirOnly && !dir {
+		return false
+	}
+	return matchElems(p.elems, strings.Split(name, "/"))
+}
+
+func matchElems(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchAny reports whether any of patterns matches name.
+func matchAny(patterns []pattern, name string, dir bool) bool {
+	for _, p := range patterns {
+		if p.match(name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreFile is the patterns of a .gitignore file in dir, a slash-separated
+// path relative to the root of the walk, which is "." for the root itself.
+type ignoreFile struct {
+	dir      string
+	patterns []pattern
+}
+
+func parseIgnoreFile(dir, src string) ignoreFile {
+	f := ignoreFile{dir: dir}
+	s := bufio.NewScanner(strings.NewReader(src))
+	for s.Scan() {
+		line := strings.TrimRight(strings.TrimSuffix(s.Text(), "\r"), " ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f.patterns = append(f.patterns, parsePattern(line))
+	}
+	return f
+}
+
+// contains reports whether the directory of f contains name, a path
+// relative to the root, returning name relative to the directory.
+func (f ignoreFile) contains(name string) (string, bool) {
+	if f.dir == "." {
+		return name, true
+	}
+	rest, ok := strings.CutPrefix(name, f.dir+"/")
+	return rest, ok
+}
+
+// ignored reports whether the .gitignore files, from the root down to the
+// directory of name, ignore it. The last pattern that matches decides.
+func ignored(files []ignoreFile, name string, dir bool) bool {
+	ignore := false
+	for _, f := range files {
+		rel, ok := f.contains(name)
+		if !ok {
+			continue
+		}
+		for _, p := range f.patterns {
+			if p.match(rel, dir) {
+				ignore = !p.negate
+			}
+		}
+	}
+	return ignore
+}
@@ -0,0 +1,302 @@
+// Package walk lexes the JavaScript files of a directory tree concurrently,
+// with a bounded number of workers, and yields the results in the order of
+// the files in the tree, so that the output of a program using it does not
+// depend on the scheduling of the workers.
+package walk
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// DefaultInclude is the patterns of the files lexed when Options.Include
+// is empty.
+var DefaultInclude = []string{"*.js", "*.mjs", "*.cjs", "*.jsx", "*.ts", "*.mts", "*.cts", "*.tsx"}
+
+// Options configures Lex.
+type Options struct {
+	// Include and Exclude are glob patterns, with the syntax of .gitignore
+	// files, matched against the slash-separated paths of the files
+	// relative to the root. Files are lexed if they match a pattern of
+	// Include, or of DefaultInclude if it is empty, and no pattern of
+	// Exclude. Directories matching a pattern of Exclude are skipped.
+	Include []string
+	Exclude []string
+	// NoGitIgnore lexes the files the .gitignore files of the tree ignore,
+	// which are skipped by default. The .git directory is always skipped.
+	NoGitIgnore bool
+	// Workers is the number of files lexed at once. Zero means
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// Recover keeps lexing a file after a syntax error.
+	Recover bool
+	// Lexer returns the options to lex the file at path with. If it is nil,
+	// ByExtension is used.
+	Lexer func(path string) []lexer.Option
+	// FileSet is the set the files are added to, in the order they are
+	// yielded. If it is nil, a new set is used.
+	FileSet *token.FileSet
+}
+
+// ByExtension returns options to lex the file at path with, according to
+// its extension: the TypeScript dialect for .ts, .mts, .cts, and .tsx
+// files, and JSX for .jsx and .tsx files.
+func ByExtension(path string) []lexer.Option {
+	opts := []lexer.Option{lexer.WithRegExpHeuristic()}
+	switch filepath.Ext(path) {
+	case ".ts", ".mts", ".cts":
+		opts = append(opts, lexer.WithDialect(lexer.TypeScript))
+	case ".tsx":
+		opts = append(opts, lexer.WithDialect(lexer.TypeScript), lexer.WithJSX())
+	case ".jsx":
+		opts = append(opts, lexer.WithJSX())
+	}
+	return opts
+}
+
+// Result is the outcome of lexing a file.
+type Result struct {
+	// Path is the path of the file, which starts with the root.
+	Path string
+	// File is the file in the file set, or nil if it could not be read.
+	File *token.File
+	// Tokens are the tokens of the file, with their positions in the file
+	// set. They end with the EOF token unless lexing stopped at an error.
+	Tokens []token.Token
+	// Errors are the syntax errors in the file, or the error reading it or
+	// its directory.
+	Errors []error
+}
+
+// job is a file to lex, and the channel its result is sent on. The worker
+// lexing it sets src and read before sending the result.
+type job struct {
+	path string
+	err  error
+	src  string
+	read bool
+	done chan Result
+}
+
+// Lex returns an iterator over the results of lexing the files under root,
+// or root itself if it is a file, which is lexed whatever its name. The
+// results are in lexical order of the paths of the files, as
+// filepath.WalkDir visits them. If ctx is canceled, the iterator yields its
+// error and stops; stopping the iteration stops the workers.
+func Lex(ctx context.Context, root string, o Options) iter.Seq2[Result, error] {
+	return func(yield func(Result, error) bool) {
+		workers := o.Workers
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		fset := o.FileSet
+		if fset == nil {
+			fset = token.NewFileSet()
+		}
+		lexerOptions := o.Lexer
+		if lexerOptions == nil {
+			lexerOptions = ByExtension
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		jobs := make(chan *job)
+		// The files in the order they are yielded, which bounds the number
+		// of results waiting for the ones before them
+		queue := make(chan *job, 4*workers)
+		done := make(chan struct{})
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		finished := make(chan struct{}, workers)
+		for range workers {
+			go func() {
+				defer func() { finished <- struct{}{} }()
+				for j := range jobs {
+					if ctx.Err() != nil {
+						continue
+					}
+					j.done <- lexFile(j, lexerOptions(j.path), o.Recover)
+				}
+			}()
+		}
+		go func() {
+			defer func() {
+				close(jobs)
+				for range workers {
+					<-finished
+				}
+				close(done)
+			}()
+			defer close(queue)
+			o.walk(root, func(j *job) bool {
+				select {
+				case queue <- j:
+				case <-ctx.Done():
+					return false
+				}
+				if j.err != nil {
+					j.done <- Result{Path: j.path, Errors: []error{j.err}}
+					return true
+				}
+				select {
+				case jobs <- j:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		}()
+
+		for j := range queue {
+			var r Result
+			select {
+			case r = <-j.done:
+			case <-ctx.Done():
+				yield(Result{}, ctx.Err())
+				return
+			}
+			if j.read {
+				r.File = addFile(fset, r, j.src)
+			}
+			if !yield(r, nil) {
+				return
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			yield(Result{}, err)
+		}
+	}
+}
+
+// lexFile reads and lexes the file of j.
+func lexFile(j *job, opts []lexer.Option, recover bool) Result {
+	r := Result{Path: j.path}
+	b, err := os.ReadFile(j.path)
+	if err != nil {
+		r.Errors = []error{err}
+		return r
+	}
+	j.src, j.read = string(b), true
+
+	l := lexer.New(j.src, opts...)
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			r.Errors = append(r.Errors, err)
+			if !recover {
+				break
+			}
+			if tok == nil {
+				continue
+			}
+		}
+		r.Tokens = append(r.Tokens, *tok)
+		if tok.Type.Label == token.EOF {
+			break
+		}
+	}
+	return r
+}
+
+// addFile adds the file of r to fset, and sets the positions of its tokens
+// and syntax errors in the set. Adding the files as they are yielded
+// rather than as they are lexed keeps the positions the same from run to
+// run.
+func addFile(fset *token.FileSet, r Result, src string) *token.File {
+	f := fset.AddFile(r.Path, src)
+	for i := range r.Tokens {
+		r.Tokens[i].Pos = f.Pos(r.Tokens[i].Start)
+	}
+	for _, err := range r.Errors {
+		var serr *lexer.SyntaxError
+		if errors.As(err, &serr) {
+			serr.Pos = f.Pos(serr.Offset)
+		}
+	}
+	return f
+}
+
+// walk calls visit with the files to lex under root, in lexical order,
+// until it returns false. Errors reading directories are passed to visit as
+// jobs with an error.
+func (o Options) walk(root string, visit func(j *job) bool) {
+	include := DefaultInclude
+	if len(o.Include) > 0 {
+		include = o.Include
+	}
+	includes, excludes := parsePatterns(include), parsePatterns(o.Exclude)
+	var ignores []ignoreFile
+
+	stop := errors.New("stop")
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !visit(&job{path: p, err: err, done: make(chan Result, 1)}) {
+				return stop
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		// Leave the .gitignore files of the directories walked out of
+		for len(ignores) > 0 {
+			if _, ok := ignores[len(ignores)-1].contains(rel); ok {
+				break
+			}
+			ignores = ignores[:len(ignores)-1]
+		}
+
+		if d.IsDir() {
+			if rel != "." {
+				if d.Name() == ".git" || matchAny(excludes, rel, true) ||
+					!o.NoGitIgnore && ignored(ignores, rel, true) {
+					return filepath.SkipDir
+				}
+			}
+			if !o.NoGitIgnore {
+				src, err := os.ReadFile(filepath.Join(p, ".gitignore"))
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					if !visit(&job{path: filepath.Join(p, ".gitignore"), err: err, done: make(chan Result, 1)}) {
+						return stop
+					}
+				}
+				if err == nil {
+					ignores = append(ignores, parseIgnoreFile(rel, string(src)))
+				}
+			}
+			return nil
+		}
+
+		if rel != "." && (!d.Type().IsRegular() || !matchAny(includes, rel, false) ||
+			matchAny(excludes, rel, false) || !o.NoGitIgnore && ignored(ignores, rel, false)) {
+			return nil
+		}
+		if !visit(&job{path: p, done: make(chan Result, 1)}) {
+			return stop
+		}
+		return nil
+	})
+}
+
+func parsePatterns(globs []string) []pattern {
+	var patterns []pattern
+	for _, g := range globs {
+		if g = strings.TrimSpace(g); g != "" {
+			patterns = append(patterns, parsePattern(g))
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,245 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/morinokami/js-lexer/lexer"
+	"github.com/morinokami/js-lexer/token"
+)
+
+// makeTree creates the files in a temporary directory and returns it.
+func makeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// paths returns the paths of the results of lexing root, relative to it.
+func paths(t *testing.T, root string, o Options) []string {
+	t.Helper()
+	var got []string
+	for r, err := range Lex(context.Background(), root, o) {
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		rel, _ := filepath.Rel(root, r.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	return got
+}
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		dir      bool
+		expected bool
+	}{
+		{"*.js", "a.js", false, true},
+		{"*.js", "src/lib/a.js", false, true},
+		{"*.js", "a.ts", false, false},
+		{"/a.js", "a.js", false, true},
+		{"/a.js", "src/a.js", false, false},
+		{"src/*.js", "src/a.js", false, true},
+		{"src/*.js", "src/lib/a.js", false, false},
+		{"src/**/*.js", "src/a.js", false, true},
+		{"src/**/*.js", "src/lib/deep/a.js", false, true},
+		{"**/test", "a/b/test", true, true},
+		{"dist/", "pkg/dist", true, true},
+		{"dist/", "pkg/dist", false, false},
+		{"a?[bc].js", "abc.js", false, true},
+		{`\#a.js`, "#a.js", false, true},
+	}
+
+	for i, tt := range tests {
+		if got := parsePattern(tt.pattern).match(tt.name, tt.dir); got != tt.expected {
+			t.Fatalf("tests[%d] - %q matching %q wrong. expected=%t, got=%t", i, tt.pattern, tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestLex(t *testing.T) {
+	var files = map[string]string{
+		"b.js":                "b",
+		"a.ts":                "let a: number",
+		"c.txt":               "not lexed",
+		"src/z.jsx":           "<a>{b}</a>",
+		"src/lib/y.mjs":       "y",
+		"src/lib/x.test.js":   "x",
+		"src/a.js":            "a",
+		"node_modules/m/m.js": "m",
+		"dist/out.js":         "out",
+		"gen/keep.js":         "keep",
+		"gen/drop.js":         "drop",
+		".git/hooks/h.js":     "h",
+		".gitignore":          "node_modules/\n# comment\n\n/dist\n",
+		"gen/.gitignore":      "*.js\n!keep.js\n",
+	}
+	root := makeTree(t, files)
+
+	tests := []struct {
+		options  Options
+		expected string
+	}{
+		{Options{}, "a.ts b.js gen/keep.js src/a.js src/lib/x.test.js src/lib/y.mjs src/z.jsx"},
+		{Options{Workers: 1}, "a.ts b.js gen/keep.js src/a.js src/lib/x.test.js src/lib/y.mjs src/z.jsx"},
+		{Options{Include: []string{"src/**/*.js"}}, "src/a.js src/lib/x.test.js"},
+		{Options{Exclude: []string{"*.test.js", "gen"}}, "a.ts b.js src/a.js src/lib/y.mjs src/z.jsx"},
+		{Options{Include: []string{"*.js"}, NoGitIgnore: true},
+			"b.js dist/out.js gen/drop.js gen/keep.js node_modules/m/m.js src/a.js src/lib/x.test.js"},
+	}
+
+	for i, tt := range tests {
+		if got := strings.Join(paths(t, root, tt.options), " "); got != tt.expected {
+			t.Fatalf("tests[%d] - paths wrong. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestLexResults(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"a.js":  "let a = 1",
+		"b.tsx": "let x = <b>{y!}</b>",
+		"c.js":  "a #\nb # c",
+	})
+
+	fset := token.NewFileSet()
+	var results []Result
+	for r, err := range Lex(context.Background(), root, Options{FileSet: fset, Workers: 2, Recover: true}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		results = append(results, r)
+	}
+	if len(results) != 3 {
+		t.Fatalf("number of results wrong. expected=3, got=%d", len(results))
+	}
+
+	for i, r := range results {
+		src, err := os.ReadFile(r.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := lexer.Tokenize(string(src), ByExtension(r.Path)...)
+		if i == 2 {
+			break
+		}
+		if len(r.Errors) != 0 {
+			t.Fatalf("results[%d] - unexpected error: %q", i, r.Errors[0].Error())
+		}
+		if len(r.Tokens) != len(expected)+1 || r.Tokens[len(r.Tokens)-1].Type.Label != token.EOF {
+			t.Fatalf("results[%d] - number of tokens wrong. expected=%d, got=%d", i, len(expected)+1, len(r.Tokens))
+		}
+		for j, tok := range expected {
+			tok.Pos = r.File.Pos(tok.Start)
+			if r.Tokens[j] != tok {
+				t.Fatalf("results[%d] - tokens[%d] wrong. expected=%+v, got=%+v", i, j, tok, r.Tokens[j])
+			}
+		}
+	}
+
+	// Positions follow the order of the files
+	if results[0].File.Base() != 1 || results[1].File.Base() != 11 {
+		t.Fatalf("bases wrong. got=%d, %d", results[0].File.Base(), results[1].File.Base())
+	}
+
+	// Errors, past which the lexer recovers
+	c := results[2]
+	var got []string
+	for _, err := range c.Errors {
+		var serr *lexer.SyntaxError
+		if !errors.As(err, &serr) {
+			t.Fatalf("expected a syntax error, got=%v", err)
+		}
+		got = append(got, fset.Position(serr.Pos).String()+": "+serr.Message)
+	}
+	expected := filepath.Join(root, "c.js") + ":1:3: Unexpected character '#'," +
+		filepath.Join(root, "c.js") + ":2:3: Unexpected character '#'"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("errors wrong. expected=%q, got=%q", expected, strings.Join(got, ","))
+	}
+	if len(c.Tokens) != 4 {
+		t.Fatalf("number of tokens wrong. expected=4, got=%d", len(c.Tokens))
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	root := makeTree(t, map[string]string{"a.js": "a"})
+
+	// A missing root
+	var got []Result
+	for r, err := range Lex(context.Background(), filepath.Join(root, "missing"), Options{}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err.Error())
+		}
+		got = append(got, r)
+	}
+	if len(got) != 1 || len(got[0].Errors) != 1 || !errors.Is(got[0].Errors[0], os.ErrNotExist) || got[0].File != nil {
+		t.Fatalf("results wrong. got=%+v", got)
+	}
+
+	// A root file is lexed whatever its name
+	n := 0
+	for r, err := range Lex(context.Background(), filepath.Join(root, "a.js"), Options{Include: []string{"*.ts"}}) {
+		if err != nil || len(r.Tokens) != 2 {
+			t.Fatalf("result wrong. got=%+v, %v", r, err)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("number of results wrong. expected=1, got=%d", n)
+	}
+}
+
+func TestLexCancel(t *testing.T) {
+	files := map[string]string{}
+	for i := range 200 {
+		files[filepath.Join("d", strings.Repeat("a", i%10+1), string(rune('a'+i%26))+strings.Repeat("b", i/26)+".js")] = "a + b"
+	}
+	root := makeTree(t, files)
+
+	// Stopping the iteration
+	n := 0
+	for range Lex(context.Background(), root, Options{Workers: 4}) {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+
+	// Canceling the context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n = 0
+	var last error
+	for r, err := range Lex(ctx, root, Options{Workers: 4}) {
+		if err != nil {
+			last = err
+			continue
+		}
+		if len(r.Errors) != 0 {
+			t.Fatalf("unexpected error: %q", r.Errors[0].Error())
+		}
+		n++
+		if n == 10 {
+			cancel()
+		}
+	}
+	if !errors.Is(last, context.Canceled) || n >= 200 {
+		t.Fatalf("cancellation wrong. got=%d results, error %v", n, last)
+	}
+}